@@ -0,0 +1,134 @@
+// Package budget totals the spend recorded against cases and checks it
+// against the limits declared in .agentops/budget.yaml.
+package budget
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/gh-xj/agentops/strategy"
+)
+
+// Sources of recorded spend.
+const (
+	SourceDispatch = "dispatch" // a dispatch cycle
+	SourceWorker   = "worker"   // a worker run, reported through its sidecar
+	SourceLoop     = "loop"     // an agentops loop run
+)
+
+// Entry is one unit of spend recorded against a case.
+type Entry struct {
+	Timestamp         string `json:"timestamp"` // RFC 3339
+	Case              string `json:"case"`
+	CaseType          string `json:"case_type"`
+	Slot              string `json:"slot,omitempty"`
+	Source            string `json:"source"`
+	Worker            string `json:"worker,omitempty"`
+	WorkerInvocations int    `json:"worker_invocations,omitempty"`
+	WallClockMS       int64  `json:"wall_clock_ms,omitempty"`
+	LoopIterations    int    `json:"loop_iterations,omitempty"`
+	Tokens            int    `json:"tokens,omitempty"`
+}
+
+// Time returns when the entry was recorded, or the zero time when its
+// timestamp does not parse.
+func (e Entry) Time() time.Time {
+	t, _ := time.Parse(time.RFC3339, e.Timestamp)
+	return t
+}
+
+// Totals is the sum of a set of entries.
+type Totals struct {
+	WorkerInvocations int
+	WallClock         time.Duration
+	LoopIterations    int
+	Tokens            int
+}
+
+// Add adds the spend of e to t.
+func (t *Totals) Add(e Entry) {
+	t.WorkerInvocations += e.WorkerInvocations
+	t.WallClock += time.Duration(e.WallClockMS) * time.Millisecond
+	t.LoopIterations += e.LoopIterations
+	t.Tokens += e.Tokens
+}
+
+// Sum totals the entries keep accepts. A nil keep accepts every entry.
+func Sum(entries []Entry, keep func(Entry) bool) Totals {
+	var t Totals
+	for _, e := range entries {
+		if keep == nil || keep(e) {
+			t.Add(e)
+		}
+	}
+	return t
+}
+
+// Exhausted lists the limits of limit that t has reached, such as
+// "worker_invocations 5/5". Zero limits are unlimited. It fails when the
+// wall_clock limit does not parse.
+func Exhausted(limit strategy.SpendLimit, t Totals) ([]string, error) {
+	var out []string
+	count := func(name string, used, max int) {
+		if max > 0 && used >= max {
+			out = append(out, fmt.Sprintf("%s %d/%d", name, used, max))
+		}
+	}
+	count("worker_invocations", t.WorkerInvocations, limit.WorkerInvocations)
+	if limit.WallClock != "" {
+		max, err := strategy.ParseAge(limit.WallClock)
+		if err != nil {
+			return nil, fmt.Errorf("wall_clock limit: %w", err)
+		}
+		if max > 0 && t.WallClock >= max {
+			out = append(out, fmt.Sprintf("wall_clock %s/%s", t.WallClock.Round(time.Second), limit.WallClock))
+		}
+	}
+	count("loop_iterations", t.LoopIterations, limit.LoopIterations)
+	count("tokens", t.Tokens, limit.Tokens)
+	return out, nil
+}
+
+// Row is the spend of one case type in one slot.
+type Row struct {
+	CaseType string
+	Slot     string
+	Cases    int // distinct cases with spend
+	Totals
+}
+
+// Report groups the entries recorded between since and until by case type
+// and slot, sorted by type then slot. A zero since or until leaves that end
+// of the range open.
+func Report(entries []Entry, since, until time.Time) []Row {
+	type key struct{ caseType, slot string }
+	rows := map[key]*Row{}
+	cases := map[key]map[string]bool{}
+	for _, e := range entries {
+		at := e.Time()
+		if (!since.IsZero() && at.Before(since)) || (!until.IsZero() && !at.Before(until)) {
+			continue
+		}
+		k := key{e.CaseType, e.Slot}
+		if rows[k] == nil {
+			rows[k] = &Row{CaseType: e.CaseType, Slot: e.Slot}
+			cases[k] = map[string]bool{}
+		}
+		rows[k].Add(e)
+		cases[k][e.Case] = true
+	}
+
+	out := make([]Row, 0, len(rows))
+	for k, r := range rows {
+		r.Cases = len(cases[k])
+		out = append(out, *r)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].CaseType != out[j].CaseType {
+			return out[i].CaseType < out[j].CaseType
+		}
+		return out[i].Slot < out[j].Slot
+	})
+	return out
+}
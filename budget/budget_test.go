@@ -0,0 +1,80 @@
+package budget
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gh-xj/agentops/strategy"
+)
+
+func TestSum(t *testing.T) {
+	entries := []Entry{
+		{Case: "A", WorkerInvocations: 1, WallClockMS: 1500, Tokens: 100},
+		{Case: "B", LoopIterations: 4, WallClockMS: 500},
+		{Case: "A", LoopIterations: 2},
+	}
+	got := Sum(entries, nil)
+	want := Totals{WorkerInvocations: 1, WallClock: 2 * time.Second, LoopIterations: 6, Tokens: 100}
+	if got != want {
+		t.Errorf("Sum = %+v, want %+v", got, want)
+	}
+	got = Sum(entries, func(e Entry) bool { return e.Case == "A" })
+	want = Totals{WorkerInvocations: 1, WallClock: 1500 * time.Millisecond, LoopIterations: 2, Tokens: 100}
+	if got != want {
+		t.Errorf("Sum of A = %+v, want %+v", got, want)
+	}
+}
+
+func TestExhausted(t *testing.T) {
+	limit := strategy.SpendLimit{WorkerInvocations: 5, WallClock: "1h", Tokens: 1000}
+	used := Totals{WorkerInvocations: 5, WallClock: 90 * time.Minute, LoopIterations: 100, Tokens: 999}
+
+	got, err := Exhausted(limit, used)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"worker_invocations 5/5", "wall_clock 1h30m0s/1h"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Exhausted = %q, want %q", got, want)
+	}
+
+	if got, _ := Exhausted(strategy.SpendLimit{}, used); got != nil {
+		t.Errorf("zero limits reached %q", got)
+	}
+	if _, err := Exhausted(strategy.SpendLimit{WallClock: "soon"}, used); err == nil {
+		t.Error("bad wall_clock limit: want error")
+	}
+}
+
+func TestReport(t *testing.T) {
+	entries := []Entry{
+		{Timestamp: "2026-03-01T10:00:00Z", Case: "A", CaseType: "pr", Slot: "alpha", WorkerInvocations: 2},
+		{Timestamp: "2026-03-02T10:00:00Z", Case: "B", CaseType: "pr", Slot: "alpha", WorkerInvocations: 1, Tokens: 50},
+		{Timestamp: "2026-03-02T11:00:00Z", Case: "B", CaseType: "pr", Slot: "alpha", LoopIterations: 3},
+		{Timestamp: "2026-03-02T12:00:00Z", Case: "C", CaseType: "incident", WallClockMS: 60000},
+		{Timestamp: "2026-03-03T00:00:00Z", Case: "D", CaseType: "pr", Slot: "beta", WorkerInvocations: 9},
+	}
+
+	got := Report(entries, time.Time{}, time.Time{})
+	want := []Row{
+		{CaseType: "incident", Cases: 1, Totals: Totals{WallClock: time.Minute}},
+		{CaseType: "pr", Slot: "alpha", Cases: 2, Totals: Totals{WorkerInvocations: 3, LoopIterations: 3, Tokens: 50}},
+		{CaseType: "pr", Slot: "beta", Cases: 1, Totals: Totals{WorkerInvocations: 9}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Report =\n%+v\nwant\n%+v", got, want)
+	}
+
+	// since is inclusive, until exclusive.
+	since := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC)
+	got = Report(entries, since, until)
+	want = []Row{
+		{CaseType: "incident", Cases: 1, Totals: Totals{WallClock: time.Minute}},
+		{CaseType: "pr", Slot: "alpha", Cases: 1, Totals: Totals{WorkerInvocations: 1, LoopIterations: 3, Tokens: 50}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Report in range =\n%+v\nwant\n%+v", got, want)
+	}
+}
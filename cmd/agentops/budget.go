@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	agentops "github.com/gh-xj/agentops"
+	"github.com/gh-xj/agentops/budget"
+	"github.com/gh-xj/agentops/cobrax"
+	"github.com/gh-xj/agentops/resource"
+	caseresource "github.com/gh-xj/agentops/resource/case"
+	"github.com/spf13/cobra"
+)
+
+// budgetReportSchema describes the rows rendered by budget report.
+var budgetReportSchema = resource.ResourceSchema{
+	Kind: "budget",
+	Fields: []resource.FieldDef{
+		{Name: "case_type", Type: "string"},
+		{Name: "slot", Type: "string"},
+		{Name: "cases", Type: "int"},
+		{Name: "worker_invocations", Type: "int"},
+		{Name: "wall_clock", Type: "string"},
+		{Name: "loop_iterations", Type: "int"},
+		{Name: "tokens", Type: "int"},
+	},
+}
+
+// dateLayout is the format of the --since and --until flags.
+const dateLayout = "2006-01-02"
+
+func newBudgetCmd(cases *caseresource.CaseResource, ctx *agentops.AppContext) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "budget",
+		Short: "Inspect spend recorded against budget.yaml",
+	}
+	cmd.AddCommand(newBudgetReportCmd(cases, ctx))
+	return cmd
+}
+
+func newBudgetReportCmd(cases *caseresource.CaseResource, ctx *agentops.AppContext) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "Total spend by case type and slot",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			since, err := dateFlag(cmd, "since")
+			if err != nil {
+				return err
+			}
+			until, err := dateFlag(cmd, "until")
+			if err != nil {
+				return err
+			}
+			if !until.IsZero() {
+				until = until.AddDate(0, 0, 1) // --until is inclusive
+			}
+			entries, err := cases.Spend(ctx)
+			if err != nil {
+				return err
+			}
+			mode, fields, jqExpr := cobrax.ResolveOutputMode(cmd)
+			return cobrax.RenderRecords(cmd.OutOrStdout(), budgetRecords(budget.Report(entries, since, until)), budgetReportSchema, mode, fields, jqExpr)
+		},
+	}
+	cmd.Flags().String("since", "", "first day to include (YYYY-MM-DD)")
+	cmd.Flags().String("until", "", "last day to include (YYYY-MM-DD)")
+	return cmd
+}
+
+// dateFlag parses a YYYY-MM-DD flag as midnight UTC. An unset flag is the
+// zero time.
+func dateFlag(cmd *cobra.Command, name string) (time.Time, error) {
+	v, _ := cmd.Flags().GetString(name)
+	if v == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(dateLayout, v)
+	if err != nil {
+		return time.Time{}, agentops.NewCLIError(agentops.ExitUsage, "invalid_option",
+			fmt.Sprintf("--%s: expected YYYY-MM-DD, got %q", name, v), nil)
+	}
+	return t, nil
+}
+
+func budgetRecords(rows []budget.Row) []resource.Record {
+	records := make([]resource.Record, 0, len(rows))
+	for _, r := range rows {
+		records = append(records, resource.Record{
+			Kind: "budget",
+			ID:   orNone(r.CaseType) + "/" + orNone(r.Slot),
+			Fields: map[string]any{
+				"case_type":          orNone(r.CaseType),
+				"slot":               orNone(r.Slot),
+				"cases":              r.Cases,
+				"worker_invocations": r.WorkerInvocations,
+				"wall_clock":         r.WallClock.Round(time.Second).String(),
+				"loop_iterations":    r.LoopIterations,
+				"tokens":             r.Tokens,
+			},
+		})
+	}
+	return records
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
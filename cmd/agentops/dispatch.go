@@ -0,0 +1,37 @@
+package main
+
+import (
+	agentops "github.com/gh-xj/agentops"
+	"github.com/gh-xj/agentops/cobrax"
+	"github.com/gh-xj/agentops/dispatch"
+	"github.com/gh-xj/agentops/resource"
+	"github.com/spf13/cobra"
+)
+
+// dispatchSchema describes the per-phase rows rendered by dispatch.
+var dispatchSchema = resource.ResourceSchema{
+	Kind: "dispatch",
+	Fields: []resource.FieldDef{
+		{Name: "phase", Type: "string"},
+		{Name: "status", Type: "string"},
+		{Name: "detail", Type: "string"},
+	},
+}
+
+func newDispatchCmd(d *dispatch.Dispatcher, ctx *agentops.AppContext) *cobra.Command {
+	return &cobra.Command{
+		Use:   "dispatch <case-id|slug>",
+		Short: "Run the full lifecycle for a case and commit the result",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			report, err := d.Dispatch(ctx, args[0])
+			if report != nil {
+				mode, fields, jqExpr := cobrax.ResolveOutputMode(cmd)
+				if renderErr := cobrax.RenderRecords(cmd.OutOrStdout(), report.Records(), dispatchSchema, mode, fields, jqExpr); renderErr != nil {
+					return renderErr
+				}
+			}
+			return err
+		},
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	agentops "github.com/gh-xj/agentops"
 	"github.com/gh-xj/agentops/resource"
+	"github.com/gh-xj/agentops/strategy"
 	"github.com/spf13/cobra"
 )
 
@@ -25,12 +26,27 @@ func newDoctorCmd(reg *resource.Registry, ctx *agentops.AppContext) *cobra.Comma
 				OK:            true,
 			}
 
-			// Check that .agentops/ exists with required files.
+			// Check that .agentops/ exists and it, or a strategy it extends,
+			// has the required files.
 			requiredFiles := []string{"storage.yaml", "transitions.yaml"}
 			agentopsDir := filepath.Join(dir, ".agentops")
+			layers, extendsErr := strategy.Layers(dir)
+			if extendsErr != nil {
+				report.OK = false
+				report.Findings = append(report.Findings, agentops.DoctorFinding{
+					Code:    "invalid_extends",
+					Path:    filepath.Join(agentopsDir, "strategy.yaml"),
+					Message: extendsErr.Error(),
+				})
+			}
 			for _, name := range requiredFiles {
 				p := filepath.Join(agentopsDir, name)
-				if _, err := os.Stat(p); err != nil {
+				missing := !inAnyLayer(layers, name)
+				if extendsErr != nil {
+					_, statErr := os.Stat(p)
+					missing = statErr != nil
+				}
+				if missing {
 					report.OK = false
 					report.Findings = append(report.Findings, agentops.DoctorFinding{
 						Code:    "missing_file",
@@ -75,6 +91,39 @@ func newDoctorCmd(reg *resource.Registry, ctx *agentops.AppContext) *cobra.Comma
 				}
 			}
 
+			// Run the health checks of resources that have them. Warnings are
+			// reported without failing the run.
+			for _, res := range reg.All() {
+				doc, ok := res.(resource.Doctor)
+				if !ok {
+					continue
+				}
+				kind := res.Schema().Kind
+				checks, err := doc.Doctor(ctx)
+				if err != nil {
+					report.Findings = append(report.Findings, agentops.DoctorFinding{
+						Code:    "doctor_error",
+						Path:    kind,
+						Message: fmt.Sprintf("%s doctor: %s", kind, err),
+					})
+					continue
+				}
+				for _, c := range checks {
+					if c.Severity != "warn" && c.Severity != "err" {
+						continue
+					}
+					if c.Severity == "err" {
+						report.OK = false
+					}
+					report.Findings = append(report.Findings, agentops.DoctorFinding{
+						Code:    c.Status,
+						Path:    c.Name,
+						Message: c.Message,
+						Fix:     c.Fix,
+					})
+				}
+			}
+
 			jsonFlag, _ := cmd.Flags().GetString("json")
 			if jsonFlag != "" {
 				out, err := report.JSON()
@@ -87,8 +136,11 @@ func newDoctorCmd(reg *resource.Registry, ctx *agentops.AppContext) *cobra.Comma
 					fmt.Fprintln(cmd.OutOrStdout(), "doctor: ok")
 				} else {
 					fmt.Fprintln(cmd.OutOrStdout(), "doctor: issues found")
-					for _, f := range report.Findings {
-						fmt.Fprintf(cmd.OutOrStdout(), "  [%s] %s: %s\n", f.Code, f.Path, f.Message)
+				}
+				for _, f := range report.Findings {
+					fmt.Fprintf(cmd.OutOrStdout(), "  [%s] %s: %s\n", f.Code, f.Path, f.Message)
+					if f.Fix != "" {
+						fmt.Fprintf(cmd.OutOrStdout(), "      fix: %s\n", f.Fix)
 					}
 				}
 			}
@@ -101,3 +153,13 @@ func newDoctorCmd(reg *resource.Registry, ctx *agentops.AppContext) *cobra.Comma
 	}
 	return cmd
 }
+
+// inAnyLayer reports whether one of layers has the strategy file name.
+func inAnyLayer(layers []strategy.Layer, name string) bool {
+	for _, l := range layers {
+		if l.Has(name) {
+			return true
+		}
+	}
+	return false
+}
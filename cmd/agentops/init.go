@@ -3,26 +3,71 @@ package main
 import (
 	"fmt"
 
+	agentops "github.com/gh-xj/agentops"
+	"github.com/gh-xj/agentops/cobrax"
 	"github.com/gh-xj/agentops/dal"
+	"github.com/gh-xj/agentops/resource"
 	"github.com/gh-xj/agentops/strategy"
 	"github.com/spf13/cobra"
 )
 
+// presetSchema describes the rows rendered by init --list-presets.
+var presetSchema = resource.ResourceSchema{
+	Kind: "strategy_preset",
+	Fields: []resource.FieldDef{
+		{Name: "name", Type: "string"},
+		{Name: "description", Type: "string"},
+	},
+}
+
 func newInitCmd(fs dal.FileSystem) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "init",
 		Short: "Bootstrap .agentops/ with default strategy files",
+		Long: "Bootstrap .agentops/ with default strategy files, or with the files of\n" +
+			"--preset: an embedded preset (see --list-presets) or a strategy directory.",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if list, _ := cmd.Flags().GetBool("list-presets"); list {
+				return listPresets(cmd)
+			}
 			dir, _ := cmd.Flags().GetString("dir")
 			if dir == "" {
 				dir = "."
 			}
-			if err := strategy.Bootstrap(dir); err != nil {
+			name, _ := cmd.Flags().GetString("preset")
+			preset, err := strategy.LoadPreset(name)
+			if err != nil {
+				return agentops.NewCLIError(agentops.ExitUsage, "unknown_preset", "cannot load preset", err)
+			}
+			if err := strategy.BootstrapPreset(dir, preset); err != nil {
 				return err
 			}
-			fmt.Fprintf(cmd.OutOrStdout(), "Initialized .agentops/ in %s\n", dir)
+			if name == strategy.DefaultPreset {
+				fmt.Fprintf(cmd.OutOrStdout(), "Initialized .agentops/ in %s\n", dir)
+			} else {
+				fmt.Fprintf(cmd.OutOrStdout(), "Initialized .agentops/ in %s from preset %s\n", dir, name)
+			}
 			return nil
 		},
 	}
+	cmd.Flags().String("preset", strategy.DefaultPreset, "embedded preset or strategy directory to initialize from")
+	cmd.Flags().Bool("list-presets", false, "list the embedded presets and exit")
 	return cmd
 }
+
+func listPresets(cmd *cobra.Command) error {
+	presets, err := strategy.Presets()
+	if err != nil {
+		return err
+	}
+	records := make([]resource.Record, 0, len(presets))
+	for _, p := range presets {
+		records = append(records, resource.Record{
+			Kind:   "strategy_preset",
+			ID:     p.Name,
+			Fields: map[string]any{"name": p.Name, "description": p.Description},
+		})
+	}
+	mode, fields, jqExpr := cobrax.ResolveOutputMode(cmd)
+	return cobrax.RenderRecords(cmd.OutOrStdout(), records, presetSchema, mode, fields, jqExpr)
+}
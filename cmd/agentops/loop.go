@@ -47,7 +47,7 @@ func runLoop(args []string) int {
 		)
 	}
 
-	handler := loopcommands.NewLoopHandler(runLoopWithOptionalAPI)
+	handler := loopcommands.NewLoopHandler(budgetedLoop(runLoopWithOptionalAPI))
 	action := remaining[0]
 	actionArgs := remaining[1:]
 	summary, execErr := harness.Run(harness.CommandInput{
@@ -0,0 +1,63 @@
+//go:build !agentcli_core
+// +build !agentcli_core
+
+package main
+
+import (
+	"context"
+	"os"
+	"time"
+
+	agentops "github.com/gh-xj/agentops"
+	"github.com/gh-xj/agentops/budget"
+	"github.com/gh-xj/agentops/dal"
+	"github.com/gh-xj/agentops/hooks"
+	harnessloop "github.com/gh-xj/agentops/internal/harnessloop"
+	caseresource "github.com/gh-xj/agentops/resource/case"
+	"github.com/gh-xj/agentops/strategy"
+	loopcommands "github.com/gh-xj/agentops/tools/harness/commands"
+)
+
+// budgetedLoop charges loop runs to the budget of the case they work on:
+// $AGENTOPS_CASE_ID, or else the case claimed by the current slot. A run is
+// refused once the case or slot has reached a budget.yaml limit, and its
+// iterations and wall-clock time are recorded afterwards. Loops outside a
+// project with a strategy, or not attributable to a case, run unbudgeted.
+func budgetedLoop(run loopcommands.RunLoopFunc) loopcommands.RunLoopFunc {
+	return func(apiURL, action string, cfg harnessloop.Config) (harnessloop.RunResult, error) {
+		root := cfg.RepoRoot
+		if root == "" {
+			root = "."
+		}
+		strat, err := strategy.Discover(root)
+		if err != nil {
+			return run(apiURL, action, cfg)
+		}
+		ctx := agentops.NewAppContext(context.Background())
+		cases := caseresource.New(dal.NewFileSystem(), dal.NewExecutor(), strat)
+		id := os.Getenv(hooks.EnvCaseID)
+		if id == "" {
+			if id, err = cases.ClaimedCase(ctx); err != nil {
+				return harnessloop.RunResult{}, err
+			}
+		}
+		if err := cases.CheckBudget(ctx, id); err != nil {
+			return harnessloop.RunResult{}, err
+		}
+
+		started := time.Now()
+		result, err := run(apiURL, action, cfg)
+		if id == "" {
+			return result, err
+		}
+		spent := budget.Entry{
+			Source:         budget.SourceLoop,
+			LoopIterations: result.Iterations,
+			WallClockMS:    time.Since(started).Milliseconds(),
+		}
+		if recErr := cases.RecordSpend(ctx, id, spent); recErr != nil && err == nil {
+			err = recErr
+		}
+		return result, err
+	}
+}
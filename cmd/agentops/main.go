@@ -7,10 +7,12 @@ import (
 	agentops "github.com/gh-xj/agentops"
 	"github.com/gh-xj/agentops/cobrax"
 	"github.com/gh-xj/agentops/dal"
+	"github.com/gh-xj/agentops/dispatch"
 	"github.com/gh-xj/agentops/resource"
 	caseresource "github.com/gh-xj/agentops/resource/case"
 	projectresource "github.com/gh-xj/agentops/resource/project"
 	slotresource "github.com/gh-xj/agentops/resource/slot"
+	workerresource "github.com/gh-xj/agentops/resource/worker"
 	"github.com/gh-xj/agentops/strategy"
 )
 
@@ -29,10 +31,13 @@ func main() {
 	// Strategy loading is optional (commands like "new" don't need it).
 	strat, _ := strategy.Discover(".")
 
+	cases := caseresource.New(fs, exec, strat)
+
 	reg := resource.NewRegistry()
-	reg.Register(caseresource.New(fs, exec, strat))
+	reg.Register(cases)
 	reg.Register(slotresource.New(fs, exec))
 	reg.Register(projectresource.New(fs, exec))
+	reg.Register(workerresource.New(fs, strat))
 
 	root := cobrax.BuildRoot(cobrax.RootSpec{
 		Use:   "agentops",
@@ -42,7 +47,11 @@ func main() {
 
 	root.AddCommand(newInitCmd(fs))
 	root.AddCommand(newDoctorCmd(reg, ctx))
+	root.AddCommand(newStrategyCmd())
 	root.AddCommand(newNewCmd(reg, ctx))
+	root.AddCommand(newDispatchCmd(dispatch.New(fs, exec, strat, cases), ctx))
+	root.AddCommand(newBudgetCmd(cases, ctx))
+	root.AddCommand(newWatchCmd(fs, exec))
 	root.AddCommand(newVersionCmd())
 	root.AddCommand(newLoopCmd())
 	root.AddCommand(newLoopServerCmd())
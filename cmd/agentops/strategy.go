@@ -0,0 +1,242 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	agentops "github.com/gh-xj/agentops"
+	"github.com/gh-xj/agentops/cobrax"
+	"github.com/gh-xj/agentops/resource"
+	"github.com/gh-xj/agentops/strategy"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+func newStrategyCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "strategy",
+		Short: "Inspect and check the .agentops/ strategy",
+	}
+	cmd.AddCommand(newStrategyValidateCmd())
+	cmd.AddCommand(newStrategyShowCmd())
+	cmd.AddCommand(newStrategyDiffCmd())
+	cmd.AddCommand(newStrategyUpgradeCmd())
+	return cmd
+}
+
+func newStrategyValidateCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "validate",
+		Short: "Check strategy files for unknown keys, bad types and unreachable statuses",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			report, err := strategy.Validate(strategyDir(cmd))
+			if err != nil {
+				return agentops.NewCLIError(agentops.ExitStrategyMissing, "strategy_missing", "cannot validate strategy", err)
+			}
+			jsonFields, _ := cmd.Flags().GetString("json")
+			jqExpr, _ := cmd.Flags().GetString("jq")
+			if err := cobrax.RenderDoctorReport(cmd.OutOrStdout(), report, jsonFields != "" || jqExpr != ""); err != nil {
+				return err
+			}
+			if !report.OK {
+				return agentops.NewCLIError(agentops.ExitValidationFailed, "strategy_invalid",
+					fmt.Sprintf("%d problems found", len(report.Findings)), nil)
+			}
+			return nil
+		},
+	}
+}
+
+// strategyLayerSchema describes the rows rendered by strategy show.
+var strategyLayerSchema = resource.ResourceSchema{
+	Kind: "strategy_layer",
+	Fields: []resource.FieldDef{
+		{Name: "layer", Type: "string"},
+		{Name: "dir", Type: "string"},
+	},
+}
+
+// resolvedValueSchema describes the rows rendered by strategy show --resolved
+// in JSON mode.
+var resolvedValueSchema = resource.ResourceSchema{
+	Kind: "strategy_value",
+	Fields: []resource.FieldDef{
+		{Name: "file", Type: "string"},
+		{Name: "key", Type: "string"},
+		{Name: "value", Type: "any"},
+		{Name: "layer", Type: "string"},
+	},
+}
+
+func newStrategyShowCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "show",
+		Short: "List the strategy layers, or with --resolved the merged settings",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			res, err := strategy.Resolve(strategyDir(cmd))
+			if err != nil {
+				return agentops.NewCLIError(agentops.ExitStrategyMissing, "strategy_missing", "cannot resolve strategy", err)
+			}
+			mode, fields, jqExpr := cobrax.ResolveOutputMode(cmd)
+			if resolved, _ := cmd.Flags().GetBool("resolved"); !resolved {
+				records := make([]resource.Record, 0, len(res.Layers))
+				for _, l := range res.Layers {
+					records = append(records, resource.Record{
+						Kind:   "strategy_layer",
+						ID:     l.Name,
+						Fields: map[string]any{"layer": l.Name, "dir": l.Dir},
+					})
+				}
+				return cobrax.RenderRecords(cmd.OutOrStdout(), records, strategyLayerSchema, mode, fields, jqExpr)
+			}
+			if mode == cobrax.OutputJSON || mode == cobrax.OutputJQ {
+				var records []resource.Record
+				for _, v := range res.Values() {
+					records = append(records, resource.Record{
+						Kind:   "strategy_value",
+						ID:     v.File + ":" + v.Key,
+						Fields: map[string]any{"file": v.File, "key": v.Key, "value": v.Value, "layer": v.Layer},
+					})
+				}
+				return cobrax.RenderRecords(cmd.OutOrStdout(), records, resolvedValueSchema, mode, fields, jqExpr)
+			}
+			out, err := res.Annotated()
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprint(cmd.OutOrStdout(), out)
+			return err
+		},
+	}
+	cmd.Flags().Bool("resolved", false, "print the effective settings and the layer each came from")
+	return cmd
+}
+
+// strategyDriftSchema describes the rows rendered by strategy diff.
+var strategyDriftSchema = resource.ResourceSchema{
+	Kind: "strategy_drift",
+	Fields: []resource.FieldDef{
+		{Name: "file", Type: "string"},
+		{Name: "key", Type: "string"},
+		{Name: "change", Type: "string"},
+		{Name: "local", Type: "any"},
+		{Name: "default", Type: "any"},
+	},
+}
+
+func newStrategyDiffCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "diff",
+		Short: "Compare .agentops/ files with their preset defaults, key by key",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			drifts, err := strategy.Diff(strategyDir(cmd))
+			if err != nil {
+				return agentops.NewCLIError(agentops.ExitStrategyMissing, "strategy_missing", "cannot diff strategy", err)
+			}
+			mode, fields, jqExpr := cobrax.ResolveOutputMode(cmd)
+			text := mode != cobrax.OutputJSON && mode != cobrax.OutputJQ
+			records := make([]resource.Record, 0, len(drifts))
+			for _, d := range drifts {
+				local, def := d.Local, d.Default
+				if text {
+					local, def = flowYAML(local), flowYAML(def)
+				}
+				records = append(records, resource.Record{
+					Kind:   "strategy_drift",
+					ID:     d.File + ":" + d.Key,
+					Fields: map[string]any{"file": d.File, "key": d.Key, "change": d.Change, "local": local, "default": def},
+				})
+			}
+			return cobrax.RenderRecords(cmd.OutOrStdout(), records, strategyDriftSchema, mode, fields, jqExpr)
+		},
+	}
+}
+
+// strategyUpgradeSchema describes the rows rendered by strategy upgrade.
+var strategyUpgradeSchema = resource.ResourceSchema{
+	Kind: "strategy_upgrade",
+	Fields: []resource.FieldDef{
+		{Name: "file", Type: "string"},
+		{Name: "outcome", Type: "string"},
+		{Name: "conflicts", Type: "[]string"},
+		{Name: "backup", Type: "string"},
+	},
+}
+
+func newStrategyUpgradeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "upgrade [file...]",
+		Short: "Merge changes to the preset defaults into .agentops/",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			force, _ := cmd.Flags().GetBool("force")
+			results, err := strategy.Upgrade(strategyDir(cmd), force, args...)
+			if err != nil {
+				return agentops.NewCLIError(agentops.ExitStrategyMissing, "strategy_missing", "cannot upgrade strategy", err)
+			}
+			mode, fields, jqExpr := cobrax.ResolveOutputMode(cmd)
+			text := mode != cobrax.OutputJSON && mode != cobrax.OutputJQ
+			records := make([]resource.Record, 0, len(results))
+			var conflicted []string
+			for _, r := range results {
+				if r.Outcome == strategy.UpgradeConflict {
+					conflicted = append(conflicted, r.File)
+				}
+				var conflicts any = r.Conflicts
+				if text {
+					conflicts = strings.Join(r.Conflicts, ", ")
+				}
+				records = append(records, resource.Record{
+					Kind:   "strategy_upgrade",
+					ID:     r.File,
+					Fields: map[string]any{"file": r.File, "outcome": r.Outcome, "conflicts": conflicts, "backup": r.Backup},
+				})
+			}
+			if err := cobrax.RenderRecords(cmd.OutOrStdout(), records, strategyUpgradeSchema, mode, fields, jqExpr); err != nil {
+				return err
+			}
+			if len(conflicted) > 0 {
+				return agentops.NewCLIError(agentops.ExitUpgradeConflict, "upgrade_conflict",
+					fmt.Sprintf("local edits conflict with the defaults in %s; rerun with --force to take the defaults", strings.Join(conflicted, ", ")), nil)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().Bool("force", false, "resolve conflicts in favor of the defaults")
+	return cmd
+}
+
+// flowYAML renders a decoded YAML value on one line, as in a flow-style
+// document; nil renders empty.
+func flowYAML(v any) string {
+	if v == nil {
+		return ""
+	}
+	var n yaml.Node
+	if err := n.Encode(v); err != nil {
+		return fmt.Sprint(v)
+	}
+	setFlow(&n)
+	out, err := yaml.Marshal(&n)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func setFlow(n *yaml.Node) {
+	n.Style |= yaml.FlowStyle
+	for _, c := range n.Content {
+		setFlow(c)
+	}
+}
+
+// strategyDir returns the directory strategy discovery starts from.
+func strategyDir(cmd *cobra.Command) string {
+	if dir, _ := cmd.Flags().GetString("dir"); dir != "" {
+		return dir
+	}
+	return "."
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	agentops "github.com/gh-xj/agentops"
+	"github.com/gh-xj/agentops/dal"
+	"github.com/gh-xj/agentops/watch"
+	"github.com/spf13/cobra"
+)
+
+func newWatchCmd(fs dal.FileSystem, exec dal.Executor) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "watch",
+		Short: "Reload the strategy and fire hooks as strategy and case files change",
+		Long: "Poll .agentops/ and the cases directory until interrupted, reloading the\n" +
+			"strategy when it changes and firing on-case-transition, on-case-close and\n" +
+			"on-worker-complete hooks for changes made outside agentops commands.\n" +
+			"Every event is logged to stdout as one JSON object per line.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			interval, _ := cmd.Flags().GetDuration("interval")
+			if interval <= 0 {
+				return agentops.NewCLIError(agentops.ExitUsage, "invalid_option", "--interval must be positive", nil)
+			}
+			w, err := watch.New(fs, exec, strategyDir(cmd), cmd.OutOrStdout())
+			if err != nil {
+				return agentops.NewCLIError(agentops.ExitStrategyMissing, "strategy_missing", "cannot load strategy", err)
+			}
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			return w.Run(ctx, interval)
+		},
+	}
+	cmd.Flags().Duration("interval", 2*time.Second, "how often to poll for changes")
+	return cmd
+}
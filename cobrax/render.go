@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"strconv"
 	"strings"
 	"text/tabwriter"
 
@@ -56,10 +57,14 @@ func renderJSON(w io.Writer, records []resource.Record, schema resource.Resource
 
 // renderJQ outputs records as JSON filtered through a jq expression.
 func renderJQ(w io.Writer, records []resource.Record, schema resource.ResourceSchema, jqExpr string) error {
-	env := buildEnvelope(records, schema, nil)
+	return RenderJQ(w, buildEnvelope(records, schema, nil), jqExpr)
+}
 
+// RenderJQ outputs v as JSON filtered through a jq expression, one result per
+// line. Commands with their own JSON shape use it to honour --jq.
+func RenderJQ(w io.Writer, v any, jqExpr string) error {
 	// Marshal to generic interface for gojq
-	raw, err := json.Marshal(env)
+	raw, err := json.Marshal(v)
 	if err != nil {
 		return fmt.Errorf("marshal for jq: %w", err)
 	}
@@ -192,6 +197,9 @@ func RenderDoctorChecks(w io.Writer, checks []resource.DoctorCheck, jsonMode boo
 			tag = "[ERR] "
 		}
 		fmt.Fprintf(tw, "%s\t%s:\t%s\n", tag, c.Name, c.Message)
+		if c.Fix != "" {
+			fmt.Fprintf(tw, "\t\tfix: %s\n", c.Fix)
+		}
 	}
 	return tw.Flush()
 }
@@ -215,17 +223,121 @@ func RenderPruneResults(w io.Writer, results []resource.PruneResult, jsonMode bo
 			fmt.Fprintf(w, "removed %s (%s)\n", r.Name, r.Path)
 		case "would_remove":
 			fmt.Fprintf(w, "would remove %s (%s)\n", r.Name, r.Path)
+		case "archived":
+			fmt.Fprintf(w, "archived %s (%s)\n", r.Name, r.Path)
+		case "would_archive":
+			fmt.Fprintf(w, "would archive %s (%s)\n", r.Name, r.Path)
 		case "skipped":
 			fmt.Fprintf(w, "skipped %s: %s\n", r.Name, r.Reason)
 		}
 	}
 
 	if !confirmed {
-		fmt.Fprintln(w, "\ndry-run: pass --confirm to actually remove")
+		fmt.Fprintln(w, "\ndry-run: pass --confirm to apply")
+	}
+	return nil
+}
+
+// RenderAssessment renders a risk assessment as JSON or as the level followed
+// by the rules that fired and the escalation actions.
+func RenderAssessment(w io.Writer, a *resource.Assessment, jsonMode bool) error {
+	if jsonMode {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+		return enc.Encode(a)
+	}
+
+	fmt.Fprintf(w, "%s: risk %s (score %d)\n", a.ID, a.Level, a.Score)
+	if len(a.Rules) == 0 {
+		fmt.Fprintln(w, "no rules fired")
+	} else {
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "SCORE\tRULE\tMATCHED")
+		for _, r := range a.Rules {
+			fmt.Fprintf(tw, "%+d\t%s\t%s\n", r.Score, r.Name, strings.Join(r.Matched, ", "))
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	if len(a.Actions) > 0 {
+		fmt.Fprintf(w, "escalation: %s\n", strings.Join(a.Actions, ", "))
+	}
+	return nil
+}
+
+// RenderRoute renders a routing decision as JSON or as the chosen type and
+// workers, followed by the decision trace when explain is set.
+func RenderRoute(w io.Writer, r *resource.Route, jsonMode, explain bool) error {
+	if jsonMode {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+		return enc.Encode(r)
+	}
+
+	risk := r.Risk
+	if risk == "" {
+		risk = "unassessed"
+	}
+	fmt.Fprintf(w, "%s: type %s, risk %s\n", r.ID, r.Type, risk)
+	if len(r.Workers) == 0 {
+		fmt.Fprintln(w, "workers: none")
+	} else {
+		fmt.Fprintf(w, "workers: %s\n", strings.Join(r.Workers, ", "))
+	}
+	if explain {
+		fmt.Fprintln(w, "trace:")
+		for i, step := range r.Trace {
+			fmt.Fprintf(w, "  %d. %s\n", i+1, step)
+		}
 	}
 	return nil
 }
 
+// Graph output formats.
+const (
+	GraphDOT     = "dot"
+	GraphMermaid = "mermaid"
+)
+
+// RenderGraph renders a link graph as Graphviz DOT, a Mermaid flowchart, or
+// JSON when format is "json". Edges point from the linking record to its
+// target and are labelled with the relation.
+func RenderGraph(w io.Writer, g *resource.Graph, name, format string) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(g)
+	case GraphMermaid:
+		// Mermaid IDs cannot contain every character a record ID can, so
+		// nodes are numbered and labelled with their ID.
+		ids := make(map[string]string, len(g.Nodes))
+		fmt.Fprintln(w, "flowchart LR")
+		for i, n := range g.Nodes {
+			ids[n.ID] = fmt.Sprintf("n%d", i)
+			fmt.Fprintf(w, "  %s[\"%s<br/>%s\"]\n", ids[n.ID], n.ID, n.Status)
+		}
+		for _, e := range g.Edges {
+			fmt.Fprintf(w, "  %s -->|%s| %s\n", ids[e.From], e.Relation, ids[e.To])
+		}
+		return nil
+	default:
+		fmt.Fprintf(w, "digraph %s {\n", strconv.Quote(name))
+		fmt.Fprintln(w, "  rankdir=LR;")
+		for _, n := range g.Nodes {
+			fmt.Fprintf(w, "  %s [label=%s];\n", strconv.Quote(n.ID), strconv.Quote(n.ID+"\n"+n.Status))
+		}
+		for _, e := range g.Edges {
+			fmt.Fprintf(w, "  %s -> %s [label=%s];\n", strconv.Quote(e.From), strconv.Quote(e.To), strconv.Quote(e.Relation))
+		}
+		fmt.Fprintln(w, "}")
+		return nil
+	}
+}
+
 // buildEnvelope constructs a JSON envelope from records.
 func buildEnvelope(records []resource.Record, schema resource.ResourceSchema, fields []string) Envelope {
 	data := make([]map[string]any, 0, len(records))
@@ -261,14 +373,16 @@ func filterFields(src map[string]any, fields []string) map[string]any {
 
 // fieldNames returns the list of field names to display.
 // If fields is non-empty, only those are returned (preserving order).
-// Otherwise, all schema fields are used.
+// Otherwise, all schema fields that are not hidden are used.
 func fieldNames(schema resource.ResourceSchema, fields []string) []string {
 	if len(fields) > 0 {
 		return fields
 	}
-	names := make([]string, len(schema.Fields))
-	for i, f := range schema.Fields {
-		names[i] = f.Name
+	names := make([]string, 0, len(schema.Fields))
+	for _, f := range schema.Fields {
+		if !f.Hidden {
+			names = append(names, f.Name)
+		}
 	}
 	return names
 }
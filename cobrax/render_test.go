@@ -135,6 +135,29 @@ func TestRenderTable(t *testing.T) {
 	}
 }
 
+func TestRenderTableHiddenFields(t *testing.T) {
+	schema := testSchema()
+	schema.Fields = append(schema.Fields, resource.FieldDef{Name: "parent", Type: "string", Hidden: true})
+	records := testRecords()
+	records[0].Fields["parent"] = "w-000"
+
+	var buf bytes.Buffer
+	if err := RenderRecords(&buf, records, schema, OutputAuto, nil, ""); err != nil {
+		t.Fatalf("RenderRecords table: %v", err)
+	}
+	if strings.Contains(buf.String(), "PARENT") {
+		t.Errorf("hidden field should not be a default column:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := RenderRecords(&buf, records, schema, OutputAuto, []string{"id", "parent"}, ""); err != nil {
+		t.Fatalf("RenderRecords table: %v", err)
+	}
+	if !strings.Contains(buf.String(), "PARENT") || !strings.Contains(buf.String(), "w-000") {
+		t.Errorf("hidden field should show when selected:\n%s", buf.String())
+	}
+}
+
 func TestRenderTSV(t *testing.T) {
 	var buf bytes.Buffer
 	records := testRecords()
@@ -248,3 +271,14 @@ func TestRenderEmptyRecords(t *testing.T) {
 		t.Fatal("expected OK=true for empty records")
 	}
 }
+
+func TestRenderRouteJSONKeepsArrows(t *testing.T) {
+	var buf bytes.Buffer
+	route := &resource.Route{ID: "x-1", Type: "bug", Workers: []string{}, Trace: []string{"no cue matched -> default type bug"}}
+	if err := RenderRoute(&buf, route, true, false); err != nil {
+		t.Fatalf("RenderRoute: %v", err)
+	}
+	if !strings.Contains(buf.String(), `"no cue matched -> default type bug"`) {
+		t.Errorf("trace escaped in JSON output:\n%s", buf.String())
+	}
+}
@@ -1,7 +1,9 @@
 package cobrax
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 
@@ -17,8 +19,18 @@ import (
 //   - If Deleter: remove
 //   - If Syncer: sync
 //   - If Transitioner: transition
+//   - If Claimer: claim, release
+//   - If Historian: history
+//   - If Reconciler: reconcile
+//   - If SectionEditor: section get|set|append
+//   - If Linker: link, unlink
+//   - If Grapher: graph
+//   - If Assessor: assess
+//   - If Router: route
 //   - If Doctor: doctor
+//   - If Archiver: archive
 //   - If Pruner: prune
+//   - If Reindexer: reindex
 func GenerateResourceCommands(reg *resource.Registry, root *cobra.Command, ctx *agentops.AppContext) {
 	for _, res := range reg.All() {
 		schema := res.Schema()
@@ -52,23 +64,75 @@ func GenerateResourceCommands(reg *resource.Registry, root *cobra.Command, ctx *
 			nounCmd.AddCommand(makeTransitionCmd(tr, schema, ctx))
 		}
 
+		// Optional: claim, release
+		if cl, ok := res.(resource.Claimer); ok {
+			nounCmd.AddCommand(makeClaimCmd(cl, schema, ctx))
+			nounCmd.AddCommand(makeReleaseCmd(cl, schema, ctx))
+		}
+
+		// Optional: history
+		if h, ok := res.(resource.Historian); ok {
+			nounCmd.AddCommand(makeHistoryCmd(h, schema, ctx))
+		}
+
+		// Optional: reconcile
+		if rc, ok := res.(resource.Reconciler); ok {
+			nounCmd.AddCommand(makeReconcileCmd(rc, schema, ctx))
+		}
+
+		// Optional: section get|set|append
+		if se, ok := res.(resource.SectionEditor); ok {
+			nounCmd.AddCommand(makeSectionCmd(se, schema, ctx))
+		}
+
+		// Optional: link, unlink
+		if l, ok := res.(resource.Linker); ok {
+			nounCmd.AddCommand(makeLinkCmd(l, schema, ctx, false))
+			nounCmd.AddCommand(makeLinkCmd(l, schema, ctx, true))
+		}
+
+		// Optional: graph
+		if g, ok := res.(resource.Grapher); ok {
+			nounCmd.AddCommand(makeGraphCmd(g, schema, ctx))
+		}
+
+		// Optional: assess
+		if as, ok := res.(resource.Assessor); ok {
+			nounCmd.AddCommand(makeAssessCmd(as, schema, ctx))
+		}
+
+		// Optional: route
+		if rt, ok := res.(resource.Router); ok {
+			nounCmd.AddCommand(makeRouteCmd(rt, schema, ctx))
+		}
+
 		// Optional: doctor
 		if doc, ok := res.(resource.Doctor); ok {
 			nounCmd.AddCommand(makeDoctorCmd(doc, schema, ctx))
 		}
 
+		// Optional: archive
+		if ar, ok := res.(resource.Archiver); ok {
+			nounCmd.AddCommand(makeArchiveCmd(ar, schema, ctx))
+		}
+
 		// Optional: prune
 		if pr, ok := res.(resource.Pruner); ok {
 			nounCmd.AddCommand(makePruneCmd(pr, schema, ctx))
 		}
 
+		// Optional: reindex
+		if ri, ok := res.(resource.Reindexer); ok {
+			nounCmd.AddCommand(makeReindexCmd(ri, schema, ctx))
+		}
+
 		root.AddCommand(nounCmd)
 	}
 }
 
-// resolveOutputMode reads --json and --jq flags from the command and returns
+// ResolveOutputMode reads --json and --jq flags from the command and returns
 // the appropriate output mode, field list, and jq expression.
-func resolveOutputMode(cmd *cobra.Command) (OutputMode, []string, string) {
+func ResolveOutputMode(cmd *cobra.Command) (OutputMode, []string, string) {
 	jsonFields, _ := cmd.Flags().GetString("json")
 	jqExpr, _ := cmd.Flags().GetString("jq")
 
@@ -99,48 +163,136 @@ func parseFieldList(s string) []string {
 }
 
 func makeCreateCmd(res resource.Resource, schema resource.ResourceSchema, ctx *agentops.AppContext) *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "create <slug>",
 		Short: fmt.Sprintf("Create a new %s", schema.Kind),
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			record, err := res.Create(ctx, args[0], nil)
+			opts, err := createOptions(cmd, schema)
+			if err != nil {
+				return err
+			}
+			record, err := res.Create(ctx, args[0], opts)
 			if err != nil {
 				return err
 			}
-			mode, fields, jqExpr := resolveOutputMode(cmd)
+			mode, fields, jqExpr := ResolveOutputMode(cmd)
 			records := []resource.Record{*record}
 			return RenderRecords(cmd.OutOrStdout(), records, schema, mode, fields, jqExpr)
 		},
 	}
+	for _, opt := range schema.CreateOptions {
+		if opt.Repeatable {
+			cmd.Flags().StringArray(opt.Name, nil, opt.Description+" (key=value, repeatable)")
+		} else {
+			cmd.Flags().String(opt.Name, "", opt.Description)
+		}
+	}
+	return cmd
+}
+
+// createOptions collects the schema's create options from flags. It returns
+// nil when none are set.
+func createOptions(cmd *cobra.Command, schema resource.ResourceSchema) (map[string]string, error) {
+	opts := map[string]string{}
+	for _, opt := range schema.CreateOptions {
+		if !opt.Repeatable {
+			if v, _ := cmd.Flags().GetString(opt.Name); v != "" {
+				opts[opt.Name] = v
+			}
+			continue
+		}
+		pairs, _ := cmd.Flags().GetStringArray(opt.Name)
+		for _, pair := range pairs {
+			key, value, ok := strings.Cut(pair, "=")
+			if !ok || strings.TrimSpace(key) == "" {
+				return nil, agentops.NewCLIError(agentops.ExitUsage, "invalid_option", fmt.Sprintf("--%s %q: want key=value", opt.Name, pair), nil)
+			}
+			opts[opt.Name+"."+strings.TrimSpace(key)] = value
+		}
+	}
+	for _, opt := range schema.CreateOptions {
+		if opt.Required && opts[opt.Name] == "" {
+			return nil, agentops.NewCLIError(agentops.ExitUsage, "missing_option", fmt.Sprintf("--%s is required", opt.Name), nil)
+		}
+	}
+	if len(opts) == 0 {
+		return nil, nil
+	}
+	return opts, nil
 }
 
 func makeListCmd(res resource.Resource, schema resource.ResourceSchema, ctx *agentops.AppContext) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "list",
 		Short: fmt.Sprintf("List %s resources", schema.Kind),
+		Long: `List resources matching every --filter expression.
+
+A filter is <field><op><value>. Operators: = != > >= < <= (ordered
+comparisons are numeric when both sides are numbers) and ~ (substring).
+Examples: type=pr, created>=20260101, claimed_by!=none. A field filtered
+more than once must match every condition, so --filter created>=20260101
+--filter created<=20270101 selects a range.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			filter := resource.Filter{}
-			if status, _ := cmd.Flags().GetString("status"); status != "" {
-				filter["status"] = status
+			exprs, _ := cmd.Flags().GetStringArray("filter")
+			filter, err := resource.ParseFilter(exprs)
+			if err != nil {
+				return agentops.NewCLIError(agentops.ExitUsage, "invalid_filter", "bad --filter", err)
 			}
-			if slot, _ := cmd.Flags().GetString("slot"); slot != "" {
-				filter["slot"] = slot
+			for _, key := range []string{"status", "slot"} {
+				if v, _ := cmd.Flags().GetString(key); v != "" {
+					filter = append(filter, resource.Equals(key, v))
+				}
+			}
+			if q, _ := cmd.Flags().GetString(resource.GrepKey); q != "" {
+				filter = append(filter, resource.Grep(q))
+			}
+
+			sortKey, _ := cmd.Flags().GetString("sort")
+			if sortKey != "" && !hasField(schema, strings.TrimPrefix(sortKey, "-")) {
+				return agentops.NewCLIError(agentops.ExitUsage, "invalid_sort", fmt.Sprintf("unknown sort field %q for %s", strings.TrimPrefix(sortKey, "-"), schema.Kind), nil)
+			}
+			limit, _ := cmd.Flags().GetInt("limit")
+			if limit < 0 {
+				return agentops.NewCLIError(agentops.ExitUsage, "invalid_limit", "--limit must not be negative", nil)
 			}
 
 			records, err := res.List(ctx, filter)
 			if err != nil {
 				return err
 			}
-			mode, fields, jqExpr := resolveOutputMode(cmd)
+			if sortKey != "" {
+				resource.SortRecords(records, sortKey)
+			}
+			if limit > 0 && len(records) > limit {
+				records = records[:limit]
+			}
+			mode, fields, jqExpr := ResolveOutputMode(cmd)
 			return RenderRecords(cmd.OutOrStdout(), records, schema, mode, fields, jqExpr)
 		},
 	}
+	cmd.Flags().StringArray("filter", nil, "filter expression <field><op><value> (repeatable)")
 	cmd.Flags().String("status", "", "filter by status")
 	cmd.Flags().String("slot", "", "filter by slot")
+	cmd.Flags().String(resource.GrepKey, "", "case-insensitive full-text search")
+	cmd.Flags().String("sort", "", "sort by field; prefix with - for descending")
+	cmd.Flags().Int("limit", 0, "maximum number of results (0 for all)")
 	return cmd
 }
 
+// hasField reports whether name is "id" or a field of schema.
+func hasField(schema resource.ResourceSchema, name string) bool {
+	if name == "id" {
+		return true
+	}
+	for _, f := range schema.Fields {
+		if f.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
 func makeGetCmd(res resource.Resource, schema resource.ResourceSchema, ctx *agentops.AppContext) *cobra.Command {
 	return &cobra.Command{
 		Use:   "get <id>",
@@ -151,7 +303,7 @@ func makeGetCmd(res resource.Resource, schema resource.ResourceSchema, ctx *agen
 			if err != nil {
 				return err
 			}
-			mode, fields, jqExpr := resolveOutputMode(cmd)
+			mode, fields, jqExpr := ResolveOutputMode(cmd)
 			records := []resource.Record{*record}
 			return RenderRecords(cmd.OutOrStdout(), records, schema, mode, fields, jqExpr)
 		},
@@ -207,20 +359,285 @@ func makeSyncCmd(s resource.Syncer, schema resource.ResourceSchema, ctx *agentop
 }
 
 func makeTransitionCmd(tr resource.Transitioner, schema resource.ResourceSchema, ctx *agentops.AppContext) *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "transition <id> <action>",
 		Short: fmt.Sprintf("Transition a %s to a new state", schema.Kind),
 		Args:  cobra.ExactArgs(2),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			record, err := tr.Transition(ctx, args[0], args[1])
+			var opts []resource.TransitionOption
+			if reason, _ := cmd.Flags().GetString("reason"); reason != "" {
+				opts = append(opts, resource.WithReason(reason))
+			}
+			record, err := tr.Transition(ctx, args[0], args[1], opts...)
+			if err != nil {
+				return err
+			}
+			mode, fields, jqExpr := ResolveOutputMode(cmd)
+			records := []resource.Record{*record}
+			return RenderRecords(cmd.OutOrStdout(), records, schema, mode, fields, jqExpr)
+		},
+	}
+	cmd.Flags().String("reason", "", "why the transition is made (recorded in history)")
+	return cmd
+}
+
+func makeHistoryCmd(h resource.Historian, schema resource.ResourceSchema, ctx *agentops.AppContext) *cobra.Command {
+	return &cobra.Command{
+		Use:   "history <id>",
+		Short: fmt.Sprintf("Show the audit history of a %s", schema.Kind),
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			records, err := h.History(ctx, args[0])
+			if err != nil {
+				return err
+			}
+			mode, fields, jqExpr := ResolveOutputMode(cmd)
+			return RenderRecords(cmd.OutOrStdout(), records, h.HistorySchema(), mode, fields, jqExpr)
+		},
+	}
+}
+
+func makeReconcileCmd(rc resource.Reconciler, schema resource.ResourceSchema, ctx *agentops.AppContext) *cobra.Command {
+	return &cobra.Command{
+		Use:   "reconcile <id>",
+		Short: fmt.Sprintf("Merge worker sidecars into a %s", schema.Kind),
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			records, err := rc.Reconcile(ctx, args[0])
+			if err != nil {
+				return err
+			}
+			mode, fields, jqExpr := ResolveOutputMode(cmd)
+			return RenderRecords(cmd.OutOrStdout(), records, rc.ReconcileSchema(), mode, fields, jqExpr)
+		},
+	}
+}
+
+func makeSectionCmd(se resource.SectionEditor, schema resource.ResourceSchema, ctx *agentops.AppContext) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "section",
+		Short: fmt.Sprintf("Read and edit the body sections of a %s", schema.Kind),
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "get <id> <section>",
+		Short: "Print the content of a section",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			content, err := se.GetSection(ctx, args[0], args[1])
+			if err != nil {
+				return err
+			}
+			if content != "" {
+				fmt.Fprintln(cmd.OutOrStdout(), content)
+			}
+			return nil
+		},
+	})
+
+	write := func(use, short string, fn func(id, heading, content string) (*resource.Record, error)) *cobra.Command {
+		c := &cobra.Command{
+			Use:   use + " <id> <section>",
+			Short: short,
+			Long:  short + ". Content is read from --content, or from stdin when the flag is not given.",
+			Args:  cobra.ExactArgs(2),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				content, err := sectionInput(cmd)
+				if err != nil {
+					return err
+				}
+				record, err := fn(args[0], args[1], content)
+				if err != nil {
+					return err
+				}
+				mode, fields, jqExpr := ResolveOutputMode(cmd)
+				return RenderRecords(cmd.OutOrStdout(), []resource.Record{*record}, schema, mode, fields, jqExpr)
+			},
+		}
+		c.Flags().String("content", "", "section content (default: read stdin)")
+		return c
+	}
+	cmd.AddCommand(write("set", "Replace the content of a section, creating it if missing", func(id, heading, content string) (*resource.Record, error) {
+		return se.SetSection(ctx, id, heading, content)
+	}))
+	cmd.AddCommand(write("append", "Append to a section, creating it if missing", func(id, heading, content string) (*resource.Record, error) {
+		return se.AppendSection(ctx, id, heading, content)
+	}))
+	return cmd
+}
+
+// sectionInput returns the --content flag, or stdin when the flag is unset.
+func sectionInput(cmd *cobra.Command) (string, error) {
+	if cmd.Flags().Changed("content") {
+		content, _ := cmd.Flags().GetString("content")
+		return content, nil
+	}
+	data, err := io.ReadAll(cmd.InOrStdin())
+	if err != nil {
+		return "", fmt.Errorf("read stdin: %w", err)
+	}
+	return string(data), nil
+}
+
+func makeClaimCmd(cl resource.Claimer, schema resource.ResourceSchema, ctx *agentops.AppContext) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "claim <id>",
+		Short: fmt.Sprintf("Claim a %s for the current slot", schema.Kind),
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			force, _ := cmd.Flags().GetBool("force")
+			record, err := cl.Claim(ctx, args[0], force)
 			if err != nil {
 				return err
 			}
-			mode, fields, jqExpr := resolveOutputMode(cmd)
+			mode, fields, jqExpr := ResolveOutputMode(cmd)
 			records := []resource.Record{*record}
 			return RenderRecords(cmd.OutOrStdout(), records, schema, mode, fields, jqExpr)
 		},
 	}
+	cmd.Flags().Bool("force", false, "take over a claim held by another slot")
+	return cmd
+}
+
+func makeReleaseCmd(cl resource.Claimer, schema resource.ResourceSchema, ctx *agentops.AppContext) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "release <id>",
+		Short: fmt.Sprintf("Release the current slot's claim on a %s", schema.Kind),
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			force, _ := cmd.Flags().GetBool("force")
+			record, err := cl.Release(ctx, args[0], force)
+			if err != nil {
+				return err
+			}
+			mode, fields, jqExpr := ResolveOutputMode(cmd)
+			records := []resource.Record{*record}
+			return RenderRecords(cmd.OutOrStdout(), records, schema, mode, fields, jqExpr)
+		},
+	}
+	cmd.Flags().Bool("force", false, "release a claim held by another slot")
+	return cmd
+}
+
+// makeLinkCmd builds "link", or "unlink" when remove is set, with one
+// repeatable --<relation> flag per relation the resource supports.
+func makeLinkCmd(l resource.Linker, schema resource.ResourceSchema, ctx *agentops.AppContext, remove bool) *cobra.Command {
+	use, short, apply := "link", fmt.Sprintf("Link a %s to others", schema.Kind), l.Link
+	if remove {
+		use, short, apply = "unlink", fmt.Sprintf("Remove links from a %s", schema.Kind), l.Unlink
+	}
+	relations := l.Relations()
+	flags := make([]string, len(relations))
+	for i, rel := range relations {
+		flags[i] = "--" + strings.ReplaceAll(rel, "_", "-")
+	}
+
+	cmd := &cobra.Command{
+		Use:   use + " <id>",
+		Short: short,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var record *resource.Record
+			for i, rel := range relations {
+				targets, _ := cmd.Flags().GetStringArray(strings.TrimPrefix(flags[i], "--"))
+				for _, target := range targets {
+					rec, err := apply(ctx, args[0], rel, target)
+					if err != nil {
+						return err
+					}
+					record = rec
+				}
+			}
+			if record == nil {
+				return agentops.NewCLIError(agentops.ExitUsage, "missing_link",
+					fmt.Sprintf("give at least one of %s", strings.Join(flags, ", ")), nil)
+			}
+			mode, fields, jqExpr := ResolveOutputMode(cmd)
+			return RenderRecords(cmd.OutOrStdout(), []resource.Record{*record}, schema, mode, fields, jqExpr)
+		},
+	}
+	for i, rel := range relations {
+		cmd.Flags().StringArray(strings.TrimPrefix(flags[i], "--"), nil, fmt.Sprintf("%s target id (repeatable)", rel))
+	}
+	return cmd
+}
+
+func makeGraphCmd(g resource.Grapher, schema resource.ResourceSchema, ctx *agentops.AppContext) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "graph [id]",
+		Short: fmt.Sprintf("Print the link graph of %s resources", schema.Kind),
+		Long:  fmt.Sprintf("Print the link graph of %s resources as DOT or Mermaid. With an id, only the records connected to it are shown.", schema.Kind),
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, _ := cmd.Flags().GetString("format")
+			if format != GraphDOT && format != GraphMermaid {
+				return agentops.NewCLIError(agentops.ExitUsage, "invalid_format",
+					fmt.Sprintf("unknown graph format %q (want %s or %s)", format, GraphDOT, GraphMermaid), nil)
+			}
+			id := ""
+			if len(args) == 1 {
+				id = args[0]
+			}
+			graph, err := g.Graph(ctx, id)
+			if err != nil {
+				return err
+			}
+			jsonFields, _ := cmd.Flags().GetString("json")
+			jqExpr, _ := cmd.Flags().GetString("jq")
+			if jqExpr != "" {
+				return RenderJQ(cmd.OutOrStdout(), graph, jqExpr)
+			}
+			if jsonFields != "" {
+				format = "json"
+			}
+			return RenderGraph(cmd.OutOrStdout(), graph, schema.Kind, format)
+		},
+	}
+	cmd.Flags().String("format", GraphDOT, "output format: dot or mermaid")
+	return cmd
+}
+
+func makeAssessCmd(as resource.Assessor, schema resource.ResourceSchema, ctx *agentops.AppContext) *cobra.Command {
+	return &cobra.Command{
+		Use:   "assess <id>",
+		Short: fmt.Sprintf("Score the risk of a %s and explain which rules fired", schema.Kind),
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			a, err := as.Assess(ctx, args[0])
+			if err != nil {
+				return err
+			}
+			jsonFields, _ := cmd.Flags().GetString("json")
+			jqExpr, _ := cmd.Flags().GetString("jq")
+			if jqExpr != "" {
+				return RenderJQ(cmd.OutOrStdout(), a, jqExpr)
+			}
+			return RenderAssessment(cmd.OutOrStdout(), a, jsonFields != "")
+		},
+	}
+}
+
+func makeRouteCmd(rt resource.Router, schema resource.ResourceSchema, ctx *agentops.AppContext) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "route <id>",
+		Short: fmt.Sprintf("Classify a %s and select its workers", schema.Kind),
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			route, err := rt.Route(ctx, args[0])
+			if err != nil {
+				return err
+			}
+			explain, _ := cmd.Flags().GetBool("explain")
+			jsonFields, _ := cmd.Flags().GetString("json")
+			jqExpr, _ := cmd.Flags().GetString("jq")
+			if jqExpr != "" {
+				return RenderJQ(cmd.OutOrStdout(), route, jqExpr)
+			}
+			return RenderRoute(cmd.OutOrStdout(), route, jsonFields != "", explain)
+		},
+	}
+	cmd.Flags().Bool("explain", false, "show the rules considered for the decision")
+	return cmd
 }
 
 func makeDoctorCmd(doc resource.Doctor, schema resource.ResourceSchema, ctx *agentops.AppContext) *cobra.Command {
@@ -241,6 +658,22 @@ func makeDoctorCmd(doc resource.Doctor, schema resource.ResourceSchema, ctx *age
 	}
 }
 
+func makeArchiveCmd(ar resource.Archiver, schema resource.ResourceSchema, ctx *agentops.AppContext) *cobra.Command {
+	return &cobra.Command{
+		Use:   "archive <id>",
+		Short: fmt.Sprintf("Move a finished %s into the archive", schema.Kind),
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			record, err := ar.Archive(ctx, args[0])
+			if err != nil {
+				return err
+			}
+			mode, fields, jqExpr := ResolveOutputMode(cmd)
+			return RenderRecords(cmd.OutOrStdout(), []resource.Record{*record}, schema, mode, fields, jqExpr)
+		},
+	}
+}
+
 func makePruneCmd(pr resource.Pruner, schema resource.ResourceSchema, ctx *agentops.AppContext) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "prune",
@@ -258,10 +691,37 @@ func makePruneCmd(pr resource.Pruner, schema resource.ResourceSchema, ctx *agent
 			return RenderPruneResults(cmd.OutOrStdout(), results, jsonMode, confirm)
 		},
 	}
-	cmd.Flags().Bool("confirm", false, "actually remove (dry-run by default)")
+	cmd.Flags().Bool("confirm", false, "apply the changes (dry-run by default)")
 	return cmd
 }
 
+func makeReindexCmd(ri resource.Reindexer, schema resource.ResourceSchema, ctx *agentops.AppContext) *cobra.Command {
+	return &cobra.Command{
+		Use:   "reindex",
+		Short: fmt.Sprintf("Rebuild the %s index from source files", schema.Kind),
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			n, err := ri.Reindex(ctx)
+			if err != nil {
+				return err
+			}
+			result := map[string]int{"reindexed": n}
+			jsonFields, _ := cmd.Flags().GetString("json")
+			jqExpr, _ := cmd.Flags().GetString("jq")
+			if jqExpr != "" {
+				return RenderJQ(cmd.OutOrStdout(), result, jqExpr)
+			}
+			if jsonFields != "" {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(result)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "reindexed %d %s records\n", n, schema.Kind)
+			return nil
+		},
+	}
+}
+
 // BuildRoot creates a root command with global flags and auto-generated resource commands.
 func BuildRoot(spec RootSpec, reg *resource.Registry, ctx *agentops.AppContext) *cobra.Command {
 	root := &cobra.Command{
@@ -1,6 +1,9 @@
 package cobrax
 
 import (
+	"bytes"
+	"slices"
+	"strings"
 	"testing"
 
 	agentops "github.com/gh-xj/agentops"
@@ -57,9 +60,12 @@ func (m *mockDeleterResource) Delete(ctx *agentops.AppContext, id string) error
 	return nil
 }
 
-// mockFullResource implements Resource + Validator + Deleter + Syncer + Transitioner.
+// mockFullResource implements Resource + Validator + Deleter + Syncer + Transitioner + Claimer + Historian + Reconciler + SectionEditor + Archiver + Linker + Grapher + Assessor + Router + Reindexer.
 type mockFullResource struct {
 	mockResource
+	sections map[string]string
+	links    []string
+	reason   string // of the last transition
 }
 
 func (m *mockFullResource) Schema() resource.ResourceSchema {
@@ -81,7 +87,8 @@ func (m *mockFullResource) Sync(ctx *agentops.AppContext, id string) error {
 	return nil
 }
 
-func (m *mockFullResource) Transition(ctx *agentops.AppContext, id string, action string) (*resource.Record, error) {
+func (m *mockFullResource) Transition(ctx *agentops.AppContext, id string, action string, opts ...resource.TransitionOption) (*resource.Record, error) {
+	m.reason = resource.ApplyTransitionOptions(opts...).Reason
 	return &resource.Record{
 		Kind:   "full",
 		ID:     id,
@@ -89,6 +96,95 @@ func (m *mockFullResource) Transition(ctx *agentops.AppContext, id string, actio
 	}, nil
 }
 
+func (m *mockFullResource) Claim(ctx *agentops.AppContext, id string, force bool) (*resource.Record, error) {
+	return &resource.Record{Kind: "full", ID: id, Fields: map[string]any{"id": id}}, nil
+}
+
+func (m *mockFullResource) Release(ctx *agentops.AppContext, id string, force bool) (*resource.Record, error) {
+	return &resource.Record{Kind: "full", ID: id, Fields: map[string]any{"id": id}}, nil
+}
+
+func (m *mockFullResource) History(ctx *agentops.AppContext, id string) ([]resource.Record, error) {
+	return []resource.Record{{Kind: "full_history", ID: id + "#1", Fields: map[string]any{"action": "start", "reason": m.reason}}}, nil
+}
+
+func (m *mockFullResource) HistorySchema() resource.ResourceSchema {
+	return resource.ResourceSchema{Kind: "full_history", Fields: []resource.FieldDef{{Name: "action"}, {Name: "reason"}}}
+}
+
+func (m *mockFullResource) Reconcile(ctx *agentops.AppContext, id string) ([]resource.Record, error) {
+	return []resource.Record{{Kind: "full_reconcile", ID: id + "#w", Fields: map[string]any{"worker": "w", "result": "merged"}}}, nil
+}
+
+func (m *mockFullResource) ReconcileSchema() resource.ResourceSchema {
+	return resource.ResourceSchema{Kind: "full_reconcile", Fields: []resource.FieldDef{{Name: "worker"}, {Name: "result"}}}
+}
+
+func (m *mockFullResource) Archive(ctx *agentops.AppContext, id string) (*resource.Record, error) {
+	return &resource.Record{Kind: "full", ID: id, Fields: map[string]any{"id": id}}, nil
+}
+
+func (m *mockFullResource) GetSection(ctx *agentops.AppContext, id, heading string) (string, error) {
+	return m.sections[heading], nil
+}
+
+func (m *mockFullResource) SetSection(ctx *agentops.AppContext, id, heading, content string) (*resource.Record, error) {
+	if m.sections == nil {
+		m.sections = map[string]string{}
+	}
+	m.sections[heading] = content
+	return &resource.Record{Kind: "full", ID: id, Fields: map[string]any{"id": id}}, nil
+}
+
+func (m *mockFullResource) AppendSection(ctx *agentops.AppContext, id, heading, content string) (*resource.Record, error) {
+	return m.SetSection(ctx, id, heading, m.sections[heading]+content)
+}
+
+func (m *mockFullResource) Relations() []string {
+	return []string{"blocked_by", "parent"}
+}
+
+func (m *mockFullResource) Link(ctx *agentops.AppContext, id, relation, target string) (*resource.Record, error) {
+	m.links = append(m.links, id+" "+relation+" "+target)
+	return &resource.Record{Kind: "full", ID: id, Fields: map[string]any{"id": id}}, nil
+}
+
+func (m *mockFullResource) Unlink(ctx *agentops.AppContext, id, relation, target string) (*resource.Record, error) {
+	m.links = append(m.links, id+" -"+relation+" "+target)
+	return &resource.Record{Kind: "full", ID: id, Fields: map[string]any{"id": id}}, nil
+}
+
+func (m *mockFullResource) Graph(ctx *agentops.AppContext, id string) (*resource.Graph, error) {
+	return &resource.Graph{
+		Nodes: []resource.GraphNode{{ID: "a", Status: "active"}, {ID: "b", Status: "inactive"}},
+		Edges: []resource.GraphEdge{{From: "a", To: "b", Relation: "blocked_by"}},
+	}, nil
+}
+
+func (m *mockFullResource) Assess(ctx *agentops.AppContext, id string) (*resource.Assessment, error) {
+	return &resource.Assessment{
+		ID:      id,
+		Score:   7,
+		Level:   "high",
+		Rules:   []resource.AssessedRule{{Name: "auth", Score: 5, Matched: []string{"path auth/login.go (auth/**)"}}, {Name: "security", Score: 2, Matched: []string{"label security"}}},
+		Actions: []string{"require-review"},
+	}, nil
+}
+
+func (m *mockFullResource) Route(ctx *agentops.AppContext, id string) (*resource.Route, error) {
+	return &resource.Route{
+		ID:      id,
+		Type:    "bug",
+		Risk:    "high",
+		Workers: []string{"triage", "review"},
+		Trace:   []string{"cue crash: matched (title matches \"panic\") -> type bug", "override hot -> workers triage, review"},
+	}, nil
+}
+
+func (m *mockFullResource) Reindex(ctx *agentops.AppContext) (int, error) {
+	return 3, nil
+}
+
 // mockDoctorPrunerResource implements Resource + Doctor + Pruner.
 type mockDoctorPrunerResource struct {
 	mockResource
@@ -170,8 +266,8 @@ func TestGenerateOptionalCommands(t *testing.T) {
 			t.Fatal("expected 'mock remove' subcommand to exist for Deleter")
 		}
 
-		// Should NOT have "validate", "sync", "transition"
-		for _, verb := range []string{"validate", "sync", "transition"} {
+		// Should NOT have "validate", "sync", "transition", "claim", "release", "history"
+		for _, verb := range []string{"validate", "sync", "transition", "claim", "release", "history", "reconcile", "section", "archive", "link", "unlink", "graph", "assess", "route", "reindex"} {
 			cmd := findSubCommand(root, "mock", verb)
 			if cmd != nil {
 				t.Fatalf("expected 'mock %s' subcommand NOT to exist", verb)
@@ -209,7 +305,7 @@ func TestGenerateOptionalCommands(t *testing.T) {
 		GenerateResourceCommands(reg, root, ctx)
 
 		// Should have all commands
-		for _, verb := range []string{"create", "list", "get", "validate", "remove", "sync", "transition"} {
+		for _, verb := range []string{"create", "list", "get", "validate", "remove", "sync", "transition", "claim", "release", "history", "reconcile", "section", "archive", "link", "unlink", "graph", "assess", "route", "reindex"} {
 			cmd := findSubCommand(root, "full", verb)
 			if cmd == nil {
 				t.Fatalf("expected 'full %s' subcommand to exist", verb)
@@ -306,3 +402,341 @@ func TestExecuteRootReturnsExitCode(t *testing.T) {
 		t.Fatalf("expected exit code %d for unknown command, got %d", agentops.ExitUsage, code)
 	}
 }
+
+func TestTransitionReasonAndHistory(t *testing.T) {
+	reg := resource.NewRegistry()
+	reg.Register(&mockFullResource{})
+
+	root := &cobra.Command{Use: "test"}
+	root.PersistentFlags().String("json", "", "JSON field selection")
+	root.PersistentFlags().String("jq", "", "jq expression")
+	ctx := agentops.NewAppContext(nil)
+	GenerateResourceCommands(reg, root, ctx)
+
+	var out bytes.Buffer
+	root.SetOut(&out)
+	root.SetArgs([]string{"full", "transition", "x", "start", "--reason", "flaky test"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("transition: %v", err)
+	}
+	if _, ok := ctx.Values["reason"]; ok {
+		t.Error("the reason should be passed to Transition, not through the context")
+	}
+
+	out.Reset()
+	root.SetArgs([]string{"full", "history", "x"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("history: %v", err)
+	}
+	if !strings.Contains(out.String(), "ACTION") || !strings.Contains(out.String(), "flaky test") {
+		t.Errorf("history output missing history schema columns:\n%s", out.String())
+	}
+}
+
+// mockListResource returns several records and applies the filter generically.
+type mockListResource struct {
+	mockResource
+	filter resource.Filter
+}
+
+func (m *mockListResource) List(ctx *agentops.AppContext, filter resource.Filter) ([]resource.Record, error) {
+	m.filter = filter
+	var out []resource.Record
+	for i, name := range []string{"beta", "alpha", "gamma"} {
+		rec := resource.Record{Kind: "mock", ID: name, Fields: map[string]any{"id": name, "name": name, "status": []string{"active", "done"}[i%2]}}
+		if filter.Match(rec, resource.RecordText(rec)) {
+			out = append(out, rec)
+		}
+	}
+	return out, nil
+}
+
+func TestListFilterSortLimit(t *testing.T) {
+	res := &mockListResource{}
+	reg := resource.NewRegistry()
+	reg.Register(res)
+
+	root := &cobra.Command{Use: "test", SilenceErrors: true, SilenceUsage: true}
+	root.PersistentFlags().String("json", "", "JSON field selection")
+	root.PersistentFlags().String("jq", "", "jq expression")
+	GenerateResourceCommands(reg, root, agentops.NewAppContext(nil))
+
+	var out bytes.Buffer
+	root.SetOut(&out)
+	root.SetArgs([]string{"mock", "list", "--filter", "status!=done", "--grep", "A", "--sort", "-name", "--limit", "1", "--jq", "[.data[].id]"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if got := strings.TrimSpace(out.String()); got != `["gamma"]` {
+		t.Errorf("output = %s, want [\"gamma\"]", got)
+	}
+	if want := (resource.Filter{{Field: "status", Op: "!=", Value: "done"}, resource.Grep("A")}); !slices.Equal(res.filter, want) {
+		t.Errorf("filter = %v", res.filter)
+	}
+
+	for _, args := range [][]string{
+		{"mock", "list", "--filter", "status"},
+		{"mock", "list", "--sort", "nope"},
+		{"mock", "list", "--limit", "-1"},
+	} {
+		root.SetArgs(args)
+		err := root.Execute()
+		if code := agentops.ResolveExitCode(err); code != agentops.ExitUsage {
+			t.Errorf("%v: exit code = %d (%v), want %d", args, code, err, agentops.ExitUsage)
+		}
+	}
+}
+
+func TestSectionCommands(t *testing.T) {
+	res := &mockFullResource{}
+	reg := resource.NewRegistry()
+	reg.Register(res)
+
+	root := &cobra.Command{Use: "test"}
+	root.PersistentFlags().String("json", "", "JSON field selection")
+	root.PersistentFlags().String("jq", "", "jq expression")
+	GenerateResourceCommands(reg, root, agentops.NewAppContext(nil))
+
+	var out bytes.Buffer
+	root.SetOut(&out)
+	root.SetIn(strings.NewReader("from stdin\n"))
+	root.SetArgs([]string{"full", "section", "set", "x", "Findings"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("section set: %v", err)
+	}
+	root.SetArgs([]string{"full", "section", "append", "x", "Findings", "--content", "from flag"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("section append: %v", err)
+	}
+
+	out.Reset()
+	root.SetArgs([]string{"full", "section", "get", "x", "Findings"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("section get: %v", err)
+	}
+	if got := out.String(); got != "from stdin\nfrom flag\n" {
+		t.Errorf("section get = %q", got)
+	}
+}
+
+// mockCreateResource declares create options and records the opts it receives.
+type mockCreateResource struct {
+	mockResource
+	opts map[string]string
+}
+
+func (m *mockCreateResource) Schema() resource.ResourceSchema {
+	s := m.mockResource.Schema()
+	s.CreateOptions = []resource.ArgDef{
+		{Name: "type", Description: "kind of thing"},
+		{Name: "set", Description: "variable", Repeatable: true},
+	}
+	return s
+}
+
+func (m *mockCreateResource) Create(ctx *agentops.AppContext, slug string, opts map[string]string) (*resource.Record, error) {
+	m.opts = opts
+	return m.mockResource.Create(ctx, slug, opts)
+}
+
+func TestCreateOptions(t *testing.T) {
+	res := &mockCreateResource{}
+	reg := resource.NewRegistry()
+	reg.Register(res)
+
+	root := &cobra.Command{Use: "test", SilenceErrors: true, SilenceUsage: true}
+	root.PersistentFlags().String("json", "", "JSON field selection")
+	root.PersistentFlags().String("jq", "", "jq expression")
+	GenerateResourceCommands(reg, root, agentops.NewAppContext(nil))
+	root.SetOut(&bytes.Buffer{})
+
+	root.SetArgs([]string{"mock", "create", "x", "--type", "incident", "--set", "severity=sev1", "--set", "owner=a=b"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	want := map[string]string{"type": "incident", "set.severity": "sev1", "set.owner": "a=b"}
+	if len(res.opts) != len(want) {
+		t.Errorf("opts = %v, want %v", res.opts, want)
+	}
+	for k, v := range want {
+		if res.opts[k] != v {
+			t.Errorf("opts[%q] = %q, want %q", k, res.opts[k], v)
+		}
+	}
+
+	root.SetArgs([]string{"mock", "create", "x", "--set", "novalue"})
+	err := root.Execute()
+	if code := agentops.ResolveExitCode(err); code != agentops.ExitUsage {
+		t.Errorf("exit code = %d (%v), want %d", code, err, agentops.ExitUsage)
+	}
+}
+
+func TestLinkAndGraphCommands(t *testing.T) {
+	res := &mockFullResource{}
+	var out bytes.Buffer
+	// Flag values persist across Execute calls, so each run gets a fresh tree.
+	newRoot := func() *cobra.Command {
+		reg := resource.NewRegistry()
+		reg.Register(res)
+		root := &cobra.Command{Use: "test", SilenceErrors: true, SilenceUsage: true}
+		root.PersistentFlags().String("json", "", "JSON field selection")
+		root.PersistentFlags().String("jq", "", "jq expression")
+		GenerateResourceCommands(reg, root, agentops.NewAppContext(nil))
+		root.SetOut(&out)
+		return root
+	}
+
+	for _, args := range [][]string{
+		{"full", "link", "x", "--blocked-by", "y", "--blocked-by", "z", "--parent", "p"},
+		{"full", "unlink", "x", "--blocked-by", "y"},
+	} {
+		root := newRoot()
+		root.SetArgs(args)
+		if err := root.Execute(); err != nil {
+			t.Fatalf("%v: %v", args, err)
+		}
+	}
+	if got := strings.Join(res.links, "; "); got != "x blocked_by y; x blocked_by z; x parent p; x -blocked_by y" {
+		t.Errorf("links = %s", got)
+	}
+
+	root := newRoot()
+	root.SetArgs([]string{"full", "link", "x"})
+	if code := agentops.ResolveExitCode(root.Execute()); code != agentops.ExitUsage {
+		t.Errorf("link without relations: exit code = %d, want %d", code, agentops.ExitUsage)
+	}
+
+	for format, want := range map[string][]string{
+		"dot":     {`digraph "full" {`, `"a" [label="a\nactive"];`, `"a" -> "b" [label="blocked_by"];`},
+		"mermaid": {"flowchart LR", `n0["a<br/>active"]`, "n0 -->|blocked_by| n1"},
+	} {
+		out.Reset()
+		root := newRoot()
+		root.SetArgs([]string{"full", "graph", "--format", format})
+		if err := root.Execute(); err != nil {
+			t.Fatalf("graph --format %s: %v", format, err)
+		}
+		for _, line := range want {
+			if !strings.Contains(out.String(), line) {
+				t.Errorf("graph --format %s missing %q:\n%s", format, line, out.String())
+			}
+		}
+	}
+
+	root = newRoot()
+	root.SetArgs([]string{"full", "graph", "--format", "svg"})
+	if code := agentops.ResolveExitCode(root.Execute()); code != agentops.ExitUsage {
+		t.Errorf("graph --format svg: exit code = %d, want %d", code, agentops.ExitUsage)
+	}
+
+	out.Reset()
+	root = newRoot()
+	root.SetArgs([]string{"full", "graph", "--jq", "[.edges[].relation]"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("graph --jq: %v", err)
+	}
+	if got := out.String(); got != "[\"blocked_by\"]\n" {
+		t.Errorf("graph --jq output = %q", got)
+	}
+}
+
+func TestReindexCommand(t *testing.T) {
+	reg := resource.NewRegistry()
+	reg.Register(&mockFullResource{})
+	root := &cobra.Command{Use: "test", SilenceErrors: true, SilenceUsage: true}
+	root.PersistentFlags().String("json", "", "JSON field selection")
+	root.PersistentFlags().String("jq", "", "jq expression")
+	GenerateResourceCommands(reg, root, agentops.NewAppContext(nil))
+	var out bytes.Buffer
+	root.SetOut(&out)
+
+	root.SetArgs([]string{"full", "reindex"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("reindex: %v", err)
+	}
+	if got := out.String(); got != "reindexed 3 full records\n" {
+		t.Errorf("output = %q", got)
+	}
+
+	out.Reset()
+	root.SetArgs([]string{"full", "reindex", "--jq", ".reindexed"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("reindex --jq: %v", err)
+	}
+	if got := out.String(); got != "3\n" {
+		t.Errorf("jq output = %q", got)
+	}
+}
+
+func TestAssessCommand(t *testing.T) {
+	reg := resource.NewRegistry()
+	reg.Register(&mockFullResource{})
+	root := &cobra.Command{Use: "test", SilenceErrors: true, SilenceUsage: true}
+	root.PersistentFlags().String("json", "", "JSON field selection")
+	root.PersistentFlags().String("jq", "", "jq expression")
+	GenerateResourceCommands(reg, root, agentops.NewAppContext(nil))
+	var out bytes.Buffer
+	root.SetOut(&out)
+
+	root.SetArgs([]string{"full", "assess", "x-1"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("assess: %v", err)
+	}
+	for _, want := range []string{"x-1: risk high (score 7)", "+5", "auth", "path auth/login.go (auth/**)", "label security", "escalation: require-review"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+
+	out.Reset()
+	root.SetArgs([]string{"full", "assess", "x-1", "--jq", "[.rules[].name]"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("assess --jq: %v", err)
+	}
+	if got := out.String(); got != "[\"auth\",\"security\"]\n" {
+		t.Errorf("jq output = %q", got)
+	}
+}
+
+func TestRouteCommand(t *testing.T) {
+	newRoot := func() (*cobra.Command, *bytes.Buffer) {
+		reg := resource.NewRegistry()
+		reg.Register(&mockFullResource{})
+		root := &cobra.Command{Use: "test", SilenceErrors: true, SilenceUsage: true}
+		root.PersistentFlags().String("json", "", "JSON field selection")
+		root.PersistentFlags().String("jq", "", "jq expression")
+		GenerateResourceCommands(reg, root, agentops.NewAppContext(nil))
+		var out bytes.Buffer
+		root.SetOut(&out)
+		return root, &out
+	}
+
+	root, out := newRoot()
+	root.SetArgs([]string{"full", "route", "x-1"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("route: %v", err)
+	}
+	if got := out.String(); got != "x-1: type bug, risk high\nworkers: triage, review\n" {
+		t.Errorf("output = %q", got)
+	}
+
+	root, out = newRoot()
+	root.SetArgs([]string{"full", "route", "x-1", "--explain"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("route --explain: %v", err)
+	}
+	for _, want := range []string{"trace:", "1. cue crash: matched", "2. override hot"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("explain output missing %q:\n%s", want, out.String())
+		}
+	}
+
+	root, out = newRoot()
+	root.SetArgs([]string{"full", "route", "x-1", "--jq", ".workers"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("route --jq: %v", err)
+	}
+	if got := out.String(); got != "[\"triage\",\"review\"]\n" {
+		t.Errorf("jq output = %q", got)
+	}
+}
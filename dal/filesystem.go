@@ -29,6 +29,38 @@ func (f *FileSystemImpl) WriteFile(path string, data []byte, perm int) error {
 	return os.WriteFile(path, data, os.FileMode(perm))
 }
 
+// AppendFile writes data to the end of the file at path in a single write,
+// creating the file if needed. Concurrent appends do not overwrite each other.
+func (f *FileSystemImpl) AppendFile(path string, data []byte, perm int) error {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, os.FileMode(perm))
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// CreateExclusive creates an empty file at path, failing with an error
+// matching os.ErrExist when one is already there.
+func (f *FileSystemImpl) CreateExclusive(path string, perm int) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, os.FileMode(perm))
+	if err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+func (f *FileSystemImpl) Rename(oldPath, newPath string) error {
+	return os.Rename(oldPath, newPath)
+}
+
+func (f *FileSystemImpl) Remove(path string) error {
+	return os.Remove(path)
+}
+
 func (f *FileSystemImpl) ReadDir(path string) ([]DirEntry, error) {
 	entries, err := os.ReadDir(path)
 	if err != nil {
@@ -41,6 +73,10 @@ func (f *FileSystemImpl) ReadDir(path string) ([]DirEntry, error) {
 	return result, nil
 }
 
+func (f *FileSystemImpl) RemoveAll(path string) error {
+	return os.RemoveAll(path)
+}
+
 func (f *FileSystemImpl) BaseName(path string) string {
 	base := filepath.Base(path)
 	ext := filepath.Ext(base)
@@ -1,6 +1,7 @@
 package dal
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
@@ -59,6 +60,48 @@ func TestFileSystemImpl_ReadWriteFile(t *testing.T) {
 	}
 }
 
+func TestFileSystemImpl_CreateExclusiveRenameRemove(t *testing.T) {
+	fs := NewFileSystem()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "lock")
+	if err := fs.CreateExclusive(path, 0600); err != nil {
+		t.Fatalf("CreateExclusive error: %v", err)
+	}
+	if err := fs.CreateExclusive(path, 0600); !errors.Is(err, os.ErrExist) {
+		t.Errorf("second CreateExclusive error = %v, want os.ErrExist", err)
+	}
+	moved := filepath.Join(dir, "moved")
+	if err := fs.Rename(path, moved); err != nil {
+		t.Fatalf("Rename error: %v", err)
+	}
+	if fs.Exists(path) || !fs.Exists(moved) {
+		t.Error("Rename did not move the file")
+	}
+	if err := fs.Remove(moved); err != nil {
+		t.Fatalf("Remove error: %v", err)
+	}
+	if fs.Exists(moved) {
+		t.Error("Remove left the file")
+	}
+}
+
+func TestFileSystemImpl_AppendFile(t *testing.T) {
+	fs := NewFileSystem()
+	path := filepath.Join(t.TempDir(), "log.jsonl")
+	for _, line := range []string{"one\n", "two\n"} {
+		if err := fs.AppendFile(path, []byte(line), 0644); err != nil {
+			t.Fatalf("AppendFile error: %v", err)
+		}
+	}
+	got, err := fs.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile error: %v", err)
+	}
+	if string(got) != "one\ntwo\n" {
+		t.Errorf("ReadFile = %q, want both lines", got)
+	}
+}
+
 func TestFileSystemImpl_ReadDir(t *testing.T) {
 	fs := NewFileSystem()
 	dir := t.TempDir()
@@ -8,7 +8,12 @@ type FileSystem interface {
 	EnsureDir(dir string) error
 	ReadFile(path string) ([]byte, error)
 	WriteFile(path string, data []byte, perm int) error
+	AppendFile(path string, data []byte, perm int) error
+	CreateExclusive(path string, perm int) error
+	Rename(oldPath, newPath string) error
+	Remove(path string) error
 	ReadDir(path string) ([]DirEntry, error)
+	RemoveAll(path string) error
 	BaseName(path string) string
 }
 
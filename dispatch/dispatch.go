@@ -0,0 +1,259 @@
+// Package dispatch runs the case lifecycle phases described in
+// protocol/lifecycle.md as a single dispatch cycle.
+package dispatch
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	agentops "github.com/gh-xj/agentops"
+	"github.com/gh-xj/agentops/budget"
+	"github.com/gh-xj/agentops/dal"
+	"github.com/gh-xj/agentops/hooks"
+	"github.com/gh-xj/agentops/resource"
+	caseresource "github.com/gh-xj/agentops/resource/case"
+	workerresource "github.com/gh-xj/agentops/resource/worker"
+	"github.com/gh-xj/agentops/strategy"
+)
+
+// Phase result statuses.
+const (
+	StatusOK      = "ok"
+	StatusSkipped = "skipped"
+	StatusFailed  = "failed"
+)
+
+// blockedStatus is the protocol status a case enters when a phase fails.
+const blockedStatus = "blocked"
+
+// Phase is one step of the dispatch lifecycle.
+type Phase struct {
+	Name string
+	// StrategyFile is the .agentops/ entry the phase is driven by. When set
+	// and absent on disk, the phase is skipped.
+	StrategyFile string
+	// Run performs the phase and returns its detail. A skipPhase error
+	// records the phase as skipped rather than failed.
+	Run func(*Run) (string, error)
+}
+
+// skipPhase is returned by a phase that cannot do its work in this cycle
+// without that being a failure. Its text is the phase detail.
+type skipPhase string
+
+func (s skipPhase) Error() string { return string(s) }
+
+// Run carries state between the phases of one dispatch cycle.
+type Run struct {
+	Ctx    *agentops.AppContext
+	Target string // case ID or slug passed to Dispatch
+	Slot   string
+	CaseID string
+	Record *resource.Record
+	// Risk is the assessment made by the assess-risk phase.
+	Risk *resource.Assessment
+	// Workers are the selected workers in execution order.
+	Workers []workerresource.Worker
+
+	started time.Time
+	pending []string // log entries produced before the case was known
+}
+
+// CaseDir returns the directory of the case being dispatched.
+func (r *Run) CaseDir() string {
+	if r.Record == nil {
+		return ""
+	}
+	return filepath.Dir(r.Record.RawPath)
+}
+
+// PhaseResult is the recorded outcome of one phase.
+type PhaseResult struct {
+	Phase  string `json:"phase"`
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Report summarizes a dispatch cycle.
+type Report struct {
+	OK     bool          `json:"ok"`
+	CaseID string        `json:"case_id"`
+	Slot   string        `json:"slot"`
+	Phases []PhaseResult `json:"phases"`
+}
+
+// Records converts the phase results to generic records for rendering.
+func (r *Report) Records() []resource.Record {
+	records := make([]resource.Record, 0, len(r.Phases))
+	for _, p := range r.Phases {
+		records = append(records, resource.Record{
+			Kind: "phase",
+			ID:   p.Phase,
+			Fields: map[string]any{
+				"phase":  p.Phase,
+				"status": p.Status,
+				"detail": p.Detail,
+			},
+		})
+	}
+	return records
+}
+
+// Dispatcher executes lifecycle phases against the case resource.
+type Dispatcher struct {
+	fs      dal.FileSystem
+	exec    dal.Executor
+	strat   *strategy.Strategy
+	cases   *caseresource.CaseResource
+	workers *workerresource.WorkerResource
+	hooks   *hooks.Engine
+	phases  []Phase
+}
+
+// New creates a Dispatcher with the default lifecycle phases.
+func New(fs dal.FileSystem, exec dal.Executor, strat *strategy.Strategy, cases *caseresource.CaseResource) *Dispatcher {
+	d := &Dispatcher{
+		fs:      fs,
+		exec:    exec,
+		strat:   strat,
+		cases:   cases,
+		workers: workerresource.New(fs, strat),
+	}
+	if strat != nil {
+		d.hooks = hooks.NewEngine(exec, strat.Hooks, strat.Root)
+	}
+	d.phases = d.defaultPhases()
+	return d
+}
+
+// Phases returns the phases in execution order.
+func (d *Dispatcher) Phases() []Phase {
+	return d.phases
+}
+
+// Dispatch runs every phase in order for the case identified by target, which
+// is either an existing case ID or a slug for a new case. Each phase result is
+// logged in the case record. When a phase fails the case is blocked and the
+// remaining phases, including commit, are recorded as skipped. Case writes
+// are committed to the case storage once for the whole cycle.
+func (d *Dispatcher) Dispatch(ctx *agentops.AppContext, target string) (*Report, error) {
+	if d.strat == nil {
+		return nil, agentops.NewCLIError(agentops.ExitStrategyMissing, "strategy_missing", "no .agentops/ found; run agentops init", nil)
+	}
+
+	if _, err := d.hooks.Fire(hooks.Event{Name: hooks.EventPreDispatch, CaseID: target}); err != nil {
+		return nil, err
+	}
+
+	// The writes of the cycle reach the case storage as one change, made by
+	// the commit phase or, when a phase fails, once the case is blocked.
+	resume := d.cases.DeferCommits()
+	defer resume()
+
+	run := &Run{Ctx: ctx, Target: target, started: time.Now()}
+	report := &Report{OK: true}
+	var failed error
+
+	for _, p := range d.phases {
+		if p.Name == PhaseCommit {
+			d.recordSpend(run)
+		}
+		res := PhaseResult{Phase: p.Name}
+		switch {
+		case failed != nil:
+			res.Status = StatusSkipped
+			res.Detail = "previous phase failed"
+		case p.StrategyFile != "" && !d.fs.Exists(filepath.Join(d.strat.Root, ".agentops", p.StrategyFile)):
+			res.Status = StatusSkipped
+			res.Detail = p.StrategyFile + " not found"
+		default:
+			detail, err := p.Run(run)
+			var skip skipPhase
+			if errors.As(err, &skip) {
+				res.Status = StatusSkipped
+				res.Detail = string(skip)
+			} else if err != nil {
+				res.Status = StatusFailed
+				res.Detail = err.Error()
+				failed = fmt.Errorf("dispatch phase %s: %w", p.Name, err)
+			} else {
+				res.Status = StatusOK
+				res.Detail = detail
+			}
+		}
+		report.Phases = append(report.Phases, res)
+
+		// A successful commit cannot record itself without dirtying the tree.
+		if !(p.Name == PhaseCommit && res.Status == StatusOK) {
+			d.logResult(run, res)
+		}
+		if res.Status == StatusFailed {
+			d.block(run, fmt.Sprintf("dispatch phase %s failed", p.Name))
+		}
+	}
+
+	report.CaseID = run.CaseID
+	report.Slot = run.Slot
+	if failed != nil {
+		report.OK = false
+		if err := d.cases.CommitDeferred("dispatch", "dispatch failed: "+run.CaseID); err != nil {
+			run.Ctx.Logger.Warn().Err(err).Str("case", run.CaseID).Msg("commit failed dispatch")
+		}
+		return report, failed
+	}
+	return report, nil
+}
+
+// recordSpend records the wall-clock time of the cycle so far against the
+// case, ahead of the commit that includes it.
+func (d *Dispatcher) recordSpend(run *Run) {
+	if run.CaseID == "" {
+		return
+	}
+	entry := budget.Entry{Source: budget.SourceDispatch, WallClockMS: time.Since(run.started).Milliseconds()}
+	if err := d.cases.RecordSpend(run.Ctx, run.CaseID, entry); err != nil {
+		run.Ctx.Logger.Warn().Err(err).Str("case", run.CaseID).Msg("record dispatch spend")
+	}
+}
+
+// logResult appends a phase result to the case log. The detail is collapsed
+// onto one line so a multiline error cannot split the log bullet.
+func (d *Dispatcher) logResult(run *Run, res PhaseResult) {
+	entry := fmt.Sprintf("dispatch %s: %s", res.Phase, res.Status)
+	if detail := strings.Join(strings.Fields(res.Detail), " "); detail != "" {
+		entry += " (" + detail + ")"
+	}
+	d.logEntry(run, entry)
+}
+
+// logEntry appends an entry to the case log. Entries produced before the case
+// is known are held back and flushed with the first entry after it is.
+func (d *Dispatcher) logEntry(run *Run, entry string) {
+	run.pending = append(run.pending, entry)
+	if run.CaseID == "" {
+		return
+	}
+	for _, e := range run.pending {
+		if err := d.cases.AppendLog(run.Ctx, run.CaseID, e); err != nil {
+			run.Ctx.Logger.Warn().Err(err).Str("case", run.CaseID).Msg("record dispatch phase")
+		}
+	}
+	run.pending = nil
+}
+
+// block moves the case to blocked after a failed phase, if the state machine
+// allows it. reason is recorded in the case history.
+func (d *Dispatcher) block(run *Run, reason string) {
+	if run.CaseID == "" {
+		return
+	}
+	rec, err := d.cases.TransitionTo(run.Ctx, run.CaseID, blockedStatus, resource.WithReason(reason))
+	if err != nil {
+		d.logResult(run, PhaseResult{Phase: "block", Status: StatusFailed, Detail: err.Error()})
+		return
+	}
+	run.Record = rec
+}
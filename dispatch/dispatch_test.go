@@ -0,0 +1,550 @@
+package dispatch
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	agentops "github.com/gh-xj/agentops"
+	"github.com/gh-xj/agentops/budget"
+	"github.com/gh-xj/agentops/dal"
+	caseresource "github.com/gh-xj/agentops/resource/case"
+	"github.com/gh-xj/agentops/strategy"
+)
+
+// setupProject creates a git repo with a bootstrapped in-repo strategy.
+func setupProject(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"git", "init", "-b", "main"},
+		{"git", "config", "user.email", "test@test.com"},
+		{"git", "config", "user.name", "test"},
+	} {
+		cmd := exec.Command(args[0], args[1:]...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("setup %v: %s: %v", args, out, err)
+		}
+	}
+	if err := strategy.Bootstrap(dir); err != nil {
+		t.Fatalf("bootstrap: %v", err)
+	}
+	writeFile(t, filepath.Join(dir, ".agentops", "storage.yaml"), "backend: in-repo\n")
+	return dir
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
+
+func newDispatcher(t *testing.T, dir string) (*Dispatcher, *caseresource.CaseResource) {
+	t.Helper()
+	strat, err := strategy.Discover(dir)
+	if err != nil {
+		t.Fatalf("discover: %v", err)
+	}
+	fs := dal.NewFileSystem()
+	ex := dal.NewExecutor()
+	cases := caseresource.New(fs, ex, strat)
+	return New(fs, ex, strat, cases), cases
+}
+
+func testCtx() *agentops.AppContext {
+	return agentops.NewAppContext(context.Background())
+}
+
+func phaseStatus(report *Report, name string) string {
+	for _, p := range report.Phases {
+		if p.Phase == name {
+			return p.Status
+		}
+	}
+	return ""
+}
+
+func gitOutput(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %s: %v", args, out, err)
+	}
+	return string(out)
+}
+
+func TestPhasesInProtocolOrder(t *testing.T) {
+	d, _ := newDispatcher(t, setupProject(t))
+	want := []string{
+		PhaseDetectSlot, PhaseFindOrCreate, PhaseClassify, PhaseAssessRisk, PhaseSelectWorkers,
+		PhaseExecuteWorkers, PhaseReconcile, PhaseFireHooks, PhaseCommit,
+	}
+	phases := d.Phases()
+	if len(phases) != len(want) {
+		t.Fatalf("got %d phases, want %d", len(phases), len(want))
+	}
+	for i, p := range phases {
+		if p.Name != want[i] {
+			t.Errorf("phase[%d] = %q, want %q", i, p.Name, want[i])
+		}
+	}
+}
+
+func TestDispatchCreatesLogsAndCommits(t *testing.T) {
+	dir := setupProject(t)
+	d, cases := newDispatcher(t, dir)
+	ctx := testCtx()
+
+	report, err := d.Dispatch(ctx, "fix-login")
+	if err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+	if !report.OK || !strings.HasSuffix(report.CaseID, "fix-login") {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if got := phaseStatus(report, PhaseCommit); got != StatusOK {
+		t.Errorf("commit status = %q, want ok", got)
+	}
+	// No workers/ directory in the default strategy.
+	if got := phaseStatus(report, PhaseExecuteWorkers); got != StatusSkipped {
+		t.Errorf("execute-workers status = %q, want skipped", got)
+	}
+
+	rec, err := cases.Get(ctx, report.CaseID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	data, err := os.ReadFile(rec.RawPath)
+	if err != nil {
+		t.Fatalf("read case.md: %v", err)
+	}
+	for _, phase := range []string{PhaseDetectSlot, PhaseFindOrCreate, PhaseFireHooks} {
+		if !strings.Contains(string(data), "dispatch "+phase+":") {
+			t.Errorf("case log missing %s entry:\n%s", phase, data)
+		}
+	}
+
+	subject := gitOutput(t, dir, "log", "-1", "--format=%s")
+	if strings.TrimSpace(subject) != "dispatch: "+report.CaseID {
+		t.Errorf("commit subject = %q", subject)
+	}
+	if status := gitOutput(t, dir, "status", "--porcelain", "--", "cases"); strings.TrimSpace(status) != "" {
+		t.Errorf("expected case directory to be fully committed, got:\n%s", status)
+	}
+
+	// Re-dispatching finds the existing case.
+	again, err := d.Dispatch(ctx, report.CaseID)
+	if err != nil {
+		t.Fatalf("second Dispatch: %v", err)
+	}
+	if again.CaseID != report.CaseID {
+		t.Errorf("second dispatch case = %q, want %q", again.CaseID, report.CaseID)
+	}
+}
+
+func TestDispatchReusesOpenCaseForSlug(t *testing.T) {
+	dir := setupProject(t)
+	d, cases := newDispatcher(t, dir)
+	ctx := testCtx()
+
+	first, err := d.Dispatch(ctx, "fix-login")
+	if err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+	second, err := d.Dispatch(ctx, "fix-login")
+	if err != nil {
+		t.Fatalf("second Dispatch: %v", err)
+	}
+	if second.CaseID != first.CaseID {
+		t.Errorf("second dispatch used %s, want the open case %s", second.CaseID, first.CaseID)
+	}
+	records, err := cases.List(ctx, nil)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(records) != 1 {
+		t.Errorf("dispatching a slug twice made %d cases, want 1", len(records))
+	}
+
+	// Once the case is done, the slug starts a new one, which is then reused.
+	if _, err := cases.Transition(ctx, first.CaseID, "close_no_action"); err != nil {
+		t.Fatal(err)
+	}
+	third, err := d.Dispatch(ctx, "fix-login")
+	if err != nil {
+		t.Fatalf("third Dispatch: %v", err)
+	}
+	if third.CaseID != first.CaseID+"-02" {
+		t.Errorf("dispatch after closing = %s, want a new case %s-02", third.CaseID, first.CaseID)
+	}
+	fourth, err := d.Dispatch(ctx, "fix-login")
+	if err != nil {
+		t.Fatalf("fourth Dispatch: %v", err)
+	}
+	if fourth.CaseID != third.CaseID {
+		t.Errorf("dispatch = %s, want the open case %s", fourth.CaseID, third.CaseID)
+	}
+}
+
+func TestDispatchWithoutCaseRepository(t *testing.T) {
+	dir := setupProject(t)
+	// The default separate-repo storage, in a directory that is not (yet) a
+	// git repository.
+	writeFile(t, filepath.Join(dir, ".agentops", "storage.yaml"), "backend: separate-repo\ncase_repo_path: "+t.TempDir()+"\n")
+	d, cases := newDispatcher(t, dir)
+	ctx := testCtx()
+
+	report, err := d.Dispatch(ctx, "no-repo")
+	if err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+	if got := phaseStatus(report, PhaseCommit); got != StatusSkipped {
+		t.Errorf("commit status = %q, want skipped", got)
+	}
+	rec, err := cases.Get(ctx, report.CaseID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Fields["status"] == blockedStatus {
+		t.Error("a case repository without git should not block the case")
+	}
+}
+
+func TestGitFailureExitCode(t *testing.T) {
+	cmd := exec.Command("git", "status")
+	cmd.Dir = t.TempDir()
+	err := cmd.Run()
+	if err == nil {
+		t.Skip("temporary directory is inside a git repository")
+	}
+	if got := agentops.ResolveExitCode(gitFailed("git status", err)); got != agentops.ExitStorageFailed {
+		t.Errorf("exit code = %d, want %d rather than git's own", got, agentops.ExitStorageFailed)
+	}
+}
+
+// setupCaseRepo turns the project at dir to separate-repo storage in a git
+// case repository that pushes to a bare remote, and returns both paths.
+func setupCaseRepo(t *testing.T, dir string) (repo, remote string) {
+	t.Helper()
+	remote = filepath.Join(t.TempDir(), "cases.git")
+	repo = filepath.Join(t.TempDir(), "cases")
+	gitOutput(t, filepath.Dir(remote), "init", "--bare", "-b", "main", remote)
+	gitOutput(t, filepath.Dir(repo), "init", "-b", "main", repo)
+	for _, args := range [][]string{
+		{"config", "user.email", "test@test.com"},
+		{"config", "user.name", "test"},
+		{"remote", "add", "origin", remote},
+		{"commit", "--allow-empty", "-m", "init"},
+		{"push", "--quiet", "-u", "origin", "main"},
+	} {
+		gitOutput(t, repo, args...)
+	}
+	writeFile(t, filepath.Join(dir, ".agentops", "storage.yaml"), "backend: separate-repo\ncase_repo_path: "+repo+"\n")
+	return repo, remote
+}
+
+func TestDispatchCommitsCaseRepoOnce(t *testing.T) {
+	dir := setupProject(t)
+	repo, remote := setupCaseRepo(t, dir)
+	d, _ := newDispatcher(t, dir)
+
+	report, err := d.Dispatch(testCtx(), "fix-login")
+	if err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+	for _, p := range report.Phases {
+		if p.Phase == PhaseCommit && (p.Status != StatusOK || !strings.HasPrefix(p.Detail, "committed ")) {
+			t.Errorf("commit phase = %+v, want committed", p)
+		}
+	}
+	log := strings.Fields(gitOutput(t, repo, "log", "--format=%s", "--", "cases"))
+	if strings.Join(log, " ") != "dispatch: "+report.CaseID {
+		t.Errorf("case repository commits = %q, want one dispatch commit", log)
+	}
+	if status := gitOutput(t, repo, "status", "--porcelain"); strings.TrimSpace(status) != "" {
+		t.Errorf("case repository should be fully committed, got:\n%s", status)
+	}
+	if local, pushed := gitOutput(t, repo, "rev-parse", "HEAD"), gitOutput(t, remote, "rev-parse", "main"); local != pushed {
+		t.Errorf("dispatch commit not pushed: local %s, remote %s", local, pushed)
+	}
+
+	// A failed cycle still shares the blocked case in one commit.
+	writeFile(t, filepath.Join(dir, ".agentops", "hooks.yaml"), "on_reconcile_done:\n  - run: \"false\"\n    blocking: true\n")
+	d, _ = newDispatcher(t, dir)
+	if _, err := d.Dispatch(testCtx(), report.CaseID); err == nil {
+		t.Fatal("expected dispatch error")
+	}
+	if subject := gitOutput(t, repo, "log", "-1", "--format=%s"); strings.TrimSpace(subject) != "dispatch failed: "+report.CaseID {
+		t.Errorf("last commit = %q", subject)
+	}
+	if n := strings.TrimSpace(gitOutput(t, repo, "rev-list", "--count", "HEAD")); n != "3" {
+		t.Errorf("case repository has %s commits, want init and two dispatches", n)
+	}
+}
+
+func TestDispatchSkipsPhaseWithoutStrategyFile(t *testing.T) {
+	dir := setupProject(t)
+	if err := os.Remove(filepath.Join(dir, ".agentops", "risk.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	d, _ := newDispatcher(t, dir)
+
+	report, err := d.Dispatch(testCtx(), "no-risk")
+	if err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+	if got := phaseStatus(report, PhaseAssessRisk); got != StatusSkipped {
+		t.Errorf("assess-risk status = %q, want skipped", got)
+	}
+	if got := phaseStatus(report, PhaseClassify); got != StatusOK {
+		t.Errorf("classify status = %q, want ok", got)
+	}
+}
+
+func TestDispatchAssessesRisk(t *testing.T) {
+	dir := setupProject(t)
+	writeFile(t, filepath.Join(dir, ".agentops", "risk.yaml"), `thresholds: {medium: 2, high: 4}
+escalation:
+  high: [require-review]
+rules:
+  login:
+    score: 5
+    keywords: [login]
+`)
+	d, cases := newDispatcher(t, dir)
+	ctx := testCtx()
+	rec, err := cases.Create(ctx, "login-outage", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cases.AppendSection(ctx, rec.ID, "Context", "Users cannot login."); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := d.Dispatch(ctx, rec.ID)
+	if err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+	for _, p := range report.Phases {
+		if p.Phase == PhaseAssessRisk && p.Detail != "risk high (score 5: login); escalation: require-review" {
+			t.Errorf("assess-risk detail = %q", p.Detail)
+		}
+	}
+	rec, err = cases.Get(ctx, rec.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Fields["risk"] != "high" {
+		t.Errorf("risk = %v, want high", rec.Fields["risk"])
+	}
+}
+
+func TestDispatchFailureBlocksCase(t *testing.T) {
+	dir := setupProject(t)
+	writeFile(t, filepath.Join(dir, ".agentops", "hooks.yaml"), "on_reconcile_done:\n  - run: \"false\"\n    blocking: true\n")
+	d, cases := newDispatcher(t, dir)
+	ctx := testCtx()
+
+	report, err := d.Dispatch(ctx, "will-fail")
+	if err == nil {
+		t.Fatal("expected dispatch error")
+	}
+	if report == nil || report.OK {
+		t.Fatalf("expected failed report, got %+v", report)
+	}
+	if got := phaseStatus(report, PhaseFireHooks); got != StatusFailed {
+		t.Errorf("fire-hooks status = %q, want failed", got)
+	}
+	if got := phaseStatus(report, PhaseCommit); got != StatusSkipped {
+		t.Errorf("commit status = %q, want skipped", got)
+	}
+
+	rec, err := cases.Get(ctx, report.CaseID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if rec.Fields["status"] != "blocked" {
+		t.Errorf("status = %v, want blocked", rec.Fields["status"])
+	}
+	data, _ := os.ReadFile(rec.RawPath)
+	if !strings.Contains(string(data), "dispatch commit: skipped (previous phase failed)") {
+		t.Errorf("skipped phases should be logged, got:\n%s", data)
+	}
+
+	history, err := cases.History(ctx, report.CaseID)
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+	last := history[len(history)-1].Fields
+	if last["to"] != "blocked" || last["reason"] != "dispatch phase fire-hooks failed" {
+		t.Errorf("last history entry = %v", last)
+	}
+	if _, ok := ctx.Values["reason"]; ok {
+		t.Error("dispatch should not leave a reason in the context")
+	}
+}
+
+func TestDispatchLogsMultilineErrorOnOneLine(t *testing.T) {
+	dir := setupProject(t)
+	d, cases := newDispatcher(t, dir)
+	ctx := testCtx()
+	d.phases = append(d.phases[:2:2], Phase{Name: "boom", Run: func(*Run) (string, error) {
+		return "", errors.New("git commit:\nerror: first\n  second\n")
+	}})
+
+	report, err := d.Dispatch(ctx, "multiline")
+	if err == nil {
+		t.Fatal("expected dispatch error")
+	}
+	rec, err := cases.Get(ctx, report.CaseID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(rec.RawPath)
+	if !strings.Contains(string(data), "dispatch boom: failed (git commit: error: first second)\n") {
+		t.Errorf("failure should be logged on one line, got:\n%s", data)
+	}
+}
+
+func TestDispatchSelectsWorkersInOrder(t *testing.T) {
+	dir := setupProject(t)
+	for name, fm := range map[string]string{
+		"review": "worker-type: review\nsidecar-path: review.md\nrequires: [triage]\n",
+		"triage": "worker-type: triage\nsidecar-path: triage.md\n",
+	} {
+		if err := os.MkdirAll(filepath.Join(dir, ".agentops", "workers", name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		writeFile(t, filepath.Join(dir, ".agentops", "workers", name, "SKILL.md"), "---\n"+fm+"---\n")
+	}
+	d, _ := newDispatcher(t, dir)
+
+	report, err := d.Dispatch(testCtx(), "with-workers")
+	if err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+	for _, p := range report.Phases {
+		if p.Phase == PhaseSelectWorkers && p.Detail != "2 workers: triage, review" {
+			t.Errorf("select-workers detail = %q", p.Detail)
+		}
+		if p.Phase == PhaseExecuteWorkers && (p.Status != StatusSkipped || !strings.HasPrefix(p.Detail, "2 workers not launched: triage, review")) {
+			t.Errorf("execute-workers = %+v, want skipped naming the selected workers", p)
+		}
+	}
+}
+
+func TestDispatchRoutesCase(t *testing.T) {
+	dir := setupProject(t)
+	for name, fm := range map[string]string{
+		"review": "worker-type: review\nsidecar-path: review.md\n",
+		"triage": "worker-type: triage\nsidecar-path: triage.md\n",
+	} {
+		if err := os.MkdirAll(filepath.Join(dir, ".agentops", "workers", name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		writeFile(t, filepath.Join(dir, ".agentops", "workers", name, "SKILL.md"), "---\n"+fm+"---\n")
+	}
+	writeFile(t, filepath.Join(dir, ".agentops", "routing.yaml"), `default_route:
+  by_type:
+    pr: [review]
+cues:
+  pull:
+    type: pr
+    title: "^CASE-.*-pr-"
+`)
+	d, cases := newDispatcher(t, dir)
+	ctx := testCtx()
+
+	report, err := d.Dispatch(ctx, "pr-123")
+	if err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+	details := map[string]string{}
+	for _, p := range report.Phases {
+		details[p.Phase] = p.Detail
+	}
+	if details[PhaseClassify] != "type pr (cue pull)" {
+		t.Errorf("classify detail = %q", details[PhaseClassify])
+	}
+	if details[PhaseSelectWorkers] != "1 workers: review" {
+		t.Errorf("select-workers detail = %q", details[PhaseSelectWorkers])
+	}
+	rec, err := cases.Get(ctx, report.CaseID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Fields["type"] != "pr" {
+		t.Errorf("type = %v, want pr", rec.Fields["type"])
+	}
+}
+
+func TestDispatchStopsAtBudgetLimit(t *testing.T) {
+	dir := setupProject(t)
+	writeFile(t, filepath.Join(dir, ".agentops", "budget.yaml"), "limits:\n  per_case:\n    loop_iterations: 4\n")
+	d, cases := newDispatcher(t, dir)
+	ctx := testCtx()
+
+	report, err := d.Dispatch(ctx, "costly")
+	if err != nil {
+		t.Fatalf("first Dispatch: %v", err)
+	}
+	spent, err := cases.Spend(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(spent) != 1 || spent[0].Source != budget.SourceDispatch || spent[0].Case != report.CaseID {
+		t.Errorf("spend after dispatch = %+v, want one dispatch entry", spent)
+	}
+
+	if err := cases.RecordSpend(ctx, report.CaseID, budget.Entry{Source: budget.SourceLoop, LoopIterations: 4}); err != nil {
+		t.Fatal(err)
+	}
+	report, err = d.Dispatch(ctx, report.CaseID)
+	if code := agentops.ResolveExitCode(err); code != agentops.ExitBudgetExceeded {
+		t.Fatalf("exit code = %d (%v), want %d", code, err, agentops.ExitBudgetExceeded)
+	}
+	if got := phaseStatus(report, PhaseSelectWorkers); got != StatusFailed {
+		t.Errorf("select-workers status = %q, want failed", got)
+	}
+	rec, err := cases.Get(ctx, report.CaseID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Fields["status"] != "blocked" {
+		t.Errorf("status = %v, want blocked", rec.Fields["status"])
+	}
+}
+
+func TestDispatchRejectsInvalidWorkerGraph(t *testing.T) {
+	dir := setupProject(t)
+	workerDir := filepath.Join(dir, ".agentops", "workers", "loop")
+	if err := os.MkdirAll(workerDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(workerDir, "SKILL.md"), "---\nworker-type: review\nsidecar-path: loop.md\nrequires: [loop]\n---\n")
+	d, _ := newDispatcher(t, dir)
+
+	report, err := d.Dispatch(testCtx(), "bad-workers")
+	if err == nil {
+		t.Fatal("expected dispatch error for cyclic workers")
+	}
+	if got := phaseStatus(report, PhaseSelectWorkers); got != StatusFailed {
+		t.Errorf("select-workers status = %q, want failed", got)
+	}
+}
+
+func TestDispatchWithoutStrategy(t *testing.T) {
+	d := New(dal.NewFileSystem(), dal.NewExecutor(), nil, caseresource.New(dal.NewFileSystem(), dal.NewExecutor(), nil))
+	_, err := d.Dispatch(testCtx(), "x")
+	if code := agentops.ResolveExitCode(err); code != agentops.ExitStrategyMissing {
+		t.Errorf("exit code = %d, want %d", code, agentops.ExitStrategyMissing)
+	}
+}
@@ -0,0 +1,275 @@
+package dispatch
+
+import (
+	"fmt"
+	"strings"
+
+	agentops "github.com/gh-xj/agentops"
+	"github.com/gh-xj/agentops/hooks"
+	caseresource "github.com/gh-xj/agentops/resource/case"
+	slotresource "github.com/gh-xj/agentops/resource/slot"
+	"github.com/gh-xj/agentops/routing"
+	"github.com/gh-xj/agentops/strategy"
+)
+
+// Phase names from protocol/lifecycle.md, in execution order.
+const (
+	PhaseDetectSlot     = "detect-slot"
+	PhaseFindOrCreate   = "find-or-create"
+	PhaseClassify       = "classify"
+	PhaseAssessRisk     = "assess-risk"
+	PhaseSelectWorkers  = "select-workers"
+	PhaseExecuteWorkers = "execute-workers"
+	PhaseReconcile      = "reconcile"
+	PhaseFireHooks      = "fire-hooks"
+	PhaseCommit         = "commit"
+)
+
+// defaultPhases wires the lifecycle phases to the dispatcher's implementations.
+func (d *Dispatcher) defaultPhases() []Phase {
+	return []Phase{
+		{Name: PhaseDetectSlot, Run: d.detectSlot},
+		{Name: PhaseFindOrCreate, Run: d.findOrCreate},
+		{Name: PhaseClassify, StrategyFile: "routing.yaml", Run: d.classify},
+		{Name: PhaseAssessRisk, StrategyFile: "risk.yaml", Run: d.assessRisk},
+		{Name: PhaseSelectWorkers, StrategyFile: "budget.yaml", Run: d.selectWorkers},
+		{Name: PhaseExecuteWorkers, StrategyFile: "workers", Run: d.executeWorkers},
+		{Name: PhaseReconcile, StrategyFile: "routing.yaml", Run: d.reconcile},
+		{Name: PhaseFireHooks, StrategyFile: "hooks.yaml", Run: d.fireHooks},
+		{Name: PhaseCommit, Run: d.commit},
+	}
+}
+
+func (d *Dispatcher) detectSlot(run *Run) (string, error) {
+	slot, err := slotresource.DetectSlot(d.fs, d.strat.Root)
+	if err != nil {
+		return "", err
+	}
+	run.Slot = slot
+	if slot == "" {
+		return "no slot", nil
+	}
+	return "slot " + slot, nil
+}
+
+// findOrCreate resolves the target as a case ID, then as the slug of a case
+// that is still open, and creates a case for the slug only when neither
+// matches.
+func (d *Dispatcher) findOrCreate(run *Run) (string, error) {
+	detail := "found "
+	rec, err := d.cases.Get(run.Ctx, run.Target)
+	if err != nil {
+		var open bool
+		if rec, open, err = d.cases.FindOpen(run.Ctx, run.Target); err != nil {
+			return "", err
+		}
+		if !open {
+			var opts map[string]string
+			if run.Slot != "" {
+				opts = map[string]string{"slot": run.Slot}
+			}
+			if rec, err = d.cases.Create(run.Ctx, run.Target, opts); err != nil {
+				return "", err
+			}
+			detail = "created "
+		}
+	}
+	run.Record = rec
+	run.CaseID = rec.ID
+	return detail + rec.ID, nil
+}
+
+// classify assigns the case type from the cues of routing.yaml.
+func (d *Dispatcher) classify(run *Run) (string, error) {
+	c, err := d.cases.Classify(run.Ctx, run.CaseID)
+	if err != nil {
+		return "", err
+	}
+	rec, err := d.cases.Get(run.Ctx, run.CaseID)
+	if err != nil {
+		return "", err
+	}
+	run.Record = rec
+	if c.Cue == "" {
+		return "type " + c.Type, nil
+	}
+	return fmt.Sprintf("type %s (cue %s)", c.Type, c.Cue), nil
+}
+
+// assessRisk scores the case against risk.yaml and records its level. The
+// escalation actions for the level are reported in the phase detail.
+func (d *Dispatcher) assessRisk(run *Run) (string, error) {
+	a, err := d.cases.Assess(run.Ctx, run.CaseID)
+	if err != nil {
+		return "", err
+	}
+	run.Risk = a
+	rec, err := d.cases.Get(run.Ctx, run.CaseID)
+	if err != nil {
+		return "", err
+	}
+	run.Record = rec
+
+	detail := fmt.Sprintf("risk %s (score %d", a.Level, a.Score)
+	if len(a.Rules) > 0 {
+		names := make([]string, 0, len(a.Rules))
+		for _, r := range a.Rules {
+			names = append(names, r.Name)
+		}
+		detail += ": " + strings.Join(names, ", ")
+	}
+	detail += ")"
+	if len(a.Actions) > 0 {
+		detail += "; escalation: " + strings.Join(a.Actions, ", ")
+	}
+	return detail, nil
+}
+
+// selectWorkers stops the cycle when the case or slot has reached a budget
+// limit. Otherwise it loads the validated worker graph in execution order and
+// keeps the workers routing.yaml selects for the case type and risk level.
+func (d *Dispatcher) selectWorkers(run *Run) (string, error) {
+	if err := d.cases.CheckBudget(run.Ctx, run.CaseID); err != nil {
+		return "", err
+	}
+	registered, err := d.workers.Ordered()
+	if err != nil {
+		return "", err
+	}
+	caseType, _ := run.Record.Fields["type"].(string)
+	risk, _ := run.Record.Fields[caseresource.RiskField].(string)
+	workers := routing.SelectWorkers(d.strat.Routing, caseType, risk, registered).Workers
+	run.Workers = workers
+	if len(workers) == 0 {
+		return "no workers selected", nil
+	}
+	names := make([]string, 0, len(workers))
+	for _, w := range workers {
+		names = append(names, w.Name)
+	}
+	return fmt.Sprintf("%d workers: %s", len(workers), strings.Join(names, ", ")), nil
+}
+
+// executeWorkers does not launch workers: they are skills run by an agent,
+// which writes their sidecars for reconcile to pick up. With workers selected
+// the phase is skipped, naming them, so the cycle does not read as if they ran.
+func (d *Dispatcher) executeWorkers(run *Run) (string, error) {
+	if len(run.Workers) == 0 {
+		return "no workers to run", nil
+	}
+	names := make([]string, 0, len(run.Workers))
+	for _, w := range run.Workers {
+		names = append(names, w.Name)
+	}
+	return "", skipPhase(fmt.Sprintf("%d workers not launched: %s; run them and reconcile their sidecars", len(names), strings.Join(names, ", ")))
+}
+
+// reconcile merges worker sidecars into the case and applies an agreed status
+// recommendation. Invalid sidecars are logged on the case.
+func (d *Dispatcher) reconcile(run *Run) (string, error) {
+	results, err := d.cases.Reconcile(run.Ctx, run.CaseID)
+	if err != nil {
+		return "", err
+	}
+	counts := map[string]int{}
+	for _, r := range results {
+		result, _ := r.Fields["result"].(string)
+		counts[result]++
+		if result == caseresource.ReconcileInvalid {
+			d.logEntry(run, fmt.Sprintf("sidecar of worker %v is invalid: %v", r.Fields["worker"], r.Fields["message"]))
+		}
+	}
+	rec, err := d.cases.Get(run.Ctx, run.CaseID)
+	if err != nil {
+		return "", err
+	}
+	run.Record = rec
+	if len(results) == 0 {
+		return "no sidecars to reconcile", nil
+	}
+	return fmt.Sprintf("%d merged, %d unchanged, %d missing, %d invalid",
+		counts[caseresource.ReconcileMerged], counts[caseresource.ReconcileUnchanged],
+		counts[caseresource.ReconcileMissing], counts[caseresource.ReconcileInvalid]), nil
+}
+
+func (d *Dispatcher) fireHooks(run *Run) (string, error) {
+	status, _ := run.Record.Fields["status"].(string)
+	results, err := d.hooks.Fire(hooks.Event{
+		Name:      hooks.EventReconcileDone,
+		CaseID:    run.CaseID,
+		OldStatus: status,
+		NewStatus: status,
+		CasePath:  run.CaseDir(),
+	})
+	if err != nil {
+		return "", err
+	}
+	failures := hooks.Failures(results)
+	for _, f := range failures {
+		reason := strings.Join(strings.Fields(f.Err.Error()), " ")
+		d.logEntry(run, fmt.Sprintf("hook %s `%s` failed: %s", f.Event, f.Command, reason))
+	}
+	return fmt.Sprintf("%d hooks run, %d failed", len(results), len(failures)), nil
+}
+
+// commit records everything the cycle wrote under the case directory in one
+// dispatcher-owned commit in the case repository. In-repo cases are committed
+// here; a separate case repository gets the writes the case storage held back
+// during the cycle, committed and pushed by the storage. A case directory
+// outside any git repository is left as it is, as the storage does.
+func (d *Dispatcher) commit(run *Run) (string, error) {
+	dir := run.CaseDir()
+	if _, err := d.exec.RunInDir(dir, "git", "rev-parse", "--show-toplevel"); err != nil {
+		return "", skipPhase("case directory is not in a git repository")
+	}
+
+	status, err := d.exec.RunInDir(dir, "git", "status", "--porcelain", "--", ".")
+	if err != nil {
+		return "", gitFailed("git status", err)
+	}
+	if strings.TrimSpace(status) == "" {
+		return "nothing to commit", nil
+	}
+
+	subject := "dispatch: " + run.CaseID
+	if d.strat.Storage.Backend == strategy.BackendInRepo {
+		if _, err := d.exec.RunInDir(dir, "git", "add", "-A", "--", "."); err != nil {
+			return "", gitFailed("git add", err)
+		}
+		msg := fmt.Sprintf("%s\n\nslot: %s\nstatus: %v\n", subject, slotOrNone(run.Slot), run.Record.Fields["status"])
+		if _, err := d.exec.RunInDir(dir, "git", "commit", "-m", msg, "--", "."); err != nil {
+			return "", gitFailed("git commit", err)
+		}
+	} else {
+		before := d.revision(dir)
+		if err := d.cases.CommitDeferred("dispatch", subject); err != nil {
+			return "", err
+		}
+		if d.revision(dir) == before {
+			return "nothing to commit", nil
+		}
+	}
+	return "committed " + d.revision(dir), nil
+}
+
+// gitFailed reports a failed git command with ExitStorageFailed, so git's own
+// exit status never becomes the exit code of agentops.
+func gitFailed(command string, err error) error {
+	return agentops.NewCLIError(agentops.ExitStorageFailed, "storage_failed", command+" failed in the case repository", err)
+}
+
+// revision returns the abbreviated HEAD commit of the repository holding dir.
+func (d *Dispatcher) revision(dir string) string {
+	hash, err := d.exec.RunInDir(dir, "git", "rev-parse", "--short", "HEAD")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(hash)
+}
+
+func slotOrNone(slot string) string {
+	if slot == "" {
+		return "none"
+	}
+	return slot
+}
@@ -16,6 +16,11 @@ const (
 	ExitTransitionDenied = 11 // invalid state transition
 	ExitWorkerFailed     = 12 // worker returned error
 	ExitValidationFailed = 13 // case/strategy validation failed
+	ExitClaimConflict    = 14 // case claimed by another slot
+	ExitStorageConflict  = 15 // case changed concurrently in shared storage
+	ExitBudgetExceeded   = 16 // case or slot reached a budget.yaml limit
+	ExitUpgradeConflict  = 17 // strategy upgrade met conflicting local edits
+	ExitStorageFailed    = 18 // a git command on the case repository failed
 )
 
 // ExitCoder describes errors that can provide a process exit code.
@@ -40,6 +40,11 @@ func TestAgentopsExitCodes(t *testing.T) {
 		{"TransitionDenied", ExitTransitionDenied, 11},
 		{"WorkerFailed", ExitWorkerFailed, 12},
 		{"ValidationFailed", ExitValidationFailed, 13},
+		{"ClaimConflict", ExitClaimConflict, 14},
+		{"StorageConflict", ExitStorageConflict, 15},
+		{"BudgetExceeded", ExitBudgetExceeded, 16},
+		{"UpgradeConflict", ExitUpgradeConflict, 17},
+		{"StorageFailed", ExitStorageFailed, 18},
 	}
 	for _, tc := range codes {
 		t.Run(tc.name, func(t *testing.T) {
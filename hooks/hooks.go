@@ -0,0 +1,144 @@
+// Package hooks executes the lifecycle hooks declared in .agentops/hooks.yaml.
+package hooks
+
+import (
+	"fmt"
+	"strings"
+
+	agentops "github.com/gh-xj/agentops"
+	"github.com/gh-xj/agentops/dal"
+	"github.com/gh-xj/agentops/strategy"
+)
+
+// Lifecycle events defined by protocol/hooks.md.
+const (
+	EventPreDispatch    = "pre-dispatch"
+	EventCaseOpen       = "on-case-open"
+	EventCaseTransition = "on-case-transition"
+	EventWorkerComplete = "on-worker-complete"
+	EventReconcileDone  = "on-reconcile-done"
+	EventCaseClose      = "on-case-close"
+)
+
+// Environment variables exported to every hook process.
+const (
+	EnvEvent     = "AGENTOPS_HOOK_EVENT"
+	EnvCaseID    = "AGENTOPS_CASE_ID"
+	EnvOldStatus = "AGENTOPS_CASE_OLD_STATUS"
+	EnvNewStatus = "AGENTOPS_CASE_NEW_STATUS"
+	EnvCasePath  = "AGENTOPS_CASE_PATH"
+	EnvWorker    = "AGENTOPS_WORKER"
+)
+
+// Event describes one lifecycle occurrence that hooks can react to.
+type Event struct {
+	Name      string
+	CaseID    string
+	OldStatus string
+	NewStatus string
+	CasePath  string // case directory
+	Worker    string // worker whose sidecar was written, for on-worker-complete
+}
+
+// Result records the outcome of a single hook invocation.
+type Result struct {
+	Event    string
+	Command  string
+	Blocking bool
+	Output   string
+	Err      error
+}
+
+// Engine runs hooks bound to lifecycle events.
+type Engine struct {
+	exec dal.Executor
+	cfg  strategy.HooksConfig
+	dir  string
+}
+
+// NewEngine creates an Engine that runs hooks from cfg with dir as the working directory.
+func NewEngine(exec dal.Executor, cfg strategy.HooksConfig, dir string) *Engine {
+	return &Engine{exec: exec, cfg: cfg, dir: dir}
+}
+
+// Bindings returns the hooks bound to the named event, in declaration order.
+// post_close hooks run as part of on-case-close, after the explicit ones.
+func (e *Engine) Bindings(event string) []strategy.HookDef {
+	switch event {
+	case EventPreDispatch:
+		return e.cfg.PreDispatch
+	case EventCaseOpen:
+		return e.cfg.OnCaseOpen
+	case EventCaseTransition:
+		return e.cfg.OnCaseTransition
+	case EventWorkerComplete:
+		return e.cfg.OnWorkerComplete
+	case EventReconcileDone:
+		return e.cfg.OnReconcileDone
+	case EventCaseClose:
+		defs := make([]strategy.HookDef, 0, len(e.cfg.OnCaseClose)+len(e.cfg.PostClose))
+		defs = append(defs, e.cfg.OnCaseClose...)
+		return append(defs, e.cfg.PostClose...)
+	}
+	return nil
+}
+
+// Fire runs every hook bound to ev.Name and returns one Result per hook run.
+// Non-blocking failures are reported in the results only. The first blocking
+// failure stops execution and is returned as a CLIError with
+// ExitTransitionDenied.
+func (e *Engine) Fire(ev Event) ([]Result, error) {
+	var results []Result
+	for _, def := range e.Bindings(ev.Name) {
+		if strings.TrimSpace(def.Run) == "" {
+			continue
+		}
+		out, err := e.run(ev, def.Run)
+		res := Result{
+			Event:    ev.Name,
+			Command:  def.Run,
+			Blocking: def.Blocking,
+			Output:   strings.TrimSpace(out),
+			Err:      err,
+		}
+		results = append(results, res)
+		if err != nil && def.Blocking {
+			return results, agentops.NewCLIError(
+				agentops.ExitTransitionDenied,
+				"hook_failed",
+				fmt.Sprintf("blocking %s hook %q failed", ev.Name, def.Run),
+				err,
+			)
+		}
+	}
+	return results, nil
+}
+
+// Failures returns the results that ended in error.
+func Failures(results []Result) []Result {
+	var failed []Result
+	for _, r := range results {
+		if r.Err != nil {
+			failed = append(failed, r)
+		}
+	}
+	return failed
+}
+
+// run executes one hook command through sh with the event exported as env vars.
+func (e *Engine) run(ev Event, command string) (string, error) {
+	args := append(eventEnv(ev), "sh", "-c", command)
+	return e.exec.RunInDir(e.dir, "env", args...)
+}
+
+// eventEnv renders the event as KEY=VALUE pairs for env(1).
+func eventEnv(ev Event) []string {
+	return []string{
+		EnvEvent + "=" + ev.Name,
+		EnvCaseID + "=" + ev.CaseID,
+		EnvOldStatus + "=" + ev.OldStatus,
+		EnvNewStatus + "=" + ev.NewStatus,
+		EnvCasePath + "=" + ev.CasePath,
+		EnvWorker + "=" + ev.Worker,
+	}
+}
@@ -0,0 +1,115 @@
+package hooks
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	agentops "github.com/gh-xj/agentops"
+	"github.com/gh-xj/agentops/dal"
+	"github.com/gh-xj/agentops/strategy"
+)
+
+func TestFireExportsEventEnv(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "env.txt")
+	cfg := strategy.HooksConfig{
+		OnCaseTransition: []strategy.HookDef{
+			{Run: `printf '%s|%s|%s|%s|%s' "$AGENTOPS_HOOK_EVENT" "$AGENTOPS_CASE_ID" "$AGENTOPS_CASE_OLD_STATUS" "$AGENTOPS_CASE_NEW_STATUS" "$AGENTOPS_CASE_PATH" > ` + out},
+		},
+	}
+	engine := NewEngine(dal.NewExecutor(), cfg, dir)
+
+	results, err := engine.Fire(Event{
+		Name:      EventCaseTransition,
+		CaseID:    "CASE-20260101-x",
+		OldStatus: "open",
+		NewStatus: "in_progress",
+		CasePath:  "/tmp/cases/CASE-20260101-x",
+	})
+	if err != nil {
+		t.Fatalf("Fire: %v", err)
+	}
+	if len(results) != 1 || results[0].Err != nil {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("read env output: %v", err)
+	}
+	want := "on-case-transition|CASE-20260101-x|open|in_progress|/tmp/cases/CASE-20260101-x"
+	if string(data) != want {
+		t.Errorf("env = %q, want %q", string(data), want)
+	}
+}
+
+func TestFireNonBlockingFailureContinues(t *testing.T) {
+	cfg := strategy.HooksConfig{
+		OnCaseOpen: []strategy.HookDef{
+			{Run: "exit 3"},
+			{Run: "echo second"},
+		},
+	}
+	engine := NewEngine(dal.NewExecutor(), cfg, t.TempDir())
+
+	results, err := engine.Fire(Event{Name: EventCaseOpen})
+	if err != nil {
+		t.Fatalf("non-blocking failure should not return error, got %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	if results[1].Output != "second" {
+		t.Errorf("second hook output = %q, want %q", results[1].Output, "second")
+	}
+	if failed := Failures(results); len(failed) != 1 {
+		t.Errorf("expected 1 failure, got %d", len(failed))
+	}
+}
+
+func TestFireBlockingFailureStops(t *testing.T) {
+	cfg := strategy.HooksConfig{
+		OnCaseTransition: []strategy.HookDef{
+			{Run: "exit 1", Blocking: true},
+			{Run: "echo never"},
+		},
+	}
+	engine := NewEngine(dal.NewExecutor(), cfg, t.TempDir())
+
+	results, err := engine.Fire(Event{Name: EventCaseTransition})
+	if err == nil {
+		t.Fatal("expected error from blocking hook")
+	}
+	if len(results) != 1 {
+		t.Errorf("expected execution to stop after blocking failure, got %d results", len(results))
+	}
+	var cliErr *agentops.CLIError
+	if !errors.As(err, &cliErr) {
+		t.Fatalf("expected *CLIError, got %T", err)
+	}
+	if cliErr.ExitCode() != agentops.ExitTransitionDenied {
+		t.Errorf("exit code = %d, want %d", cliErr.ExitCode(), agentops.ExitTransitionDenied)
+	}
+}
+
+func TestBindingsCaseCloseIncludesPostClose(t *testing.T) {
+	cfg := strategy.HooksConfig{
+		OnCaseClose: []strategy.HookDef{{Run: "a"}},
+		PostClose:   []strategy.HookDef{{Run: "b"}},
+	}
+	engine := NewEngine(dal.NewExecutor(), cfg, "")
+
+	var got []string
+	for _, def := range engine.Bindings(EventCaseClose) {
+		got = append(got, def.Run)
+	}
+	if strings.Join(got, ",") != "a,b" {
+		t.Errorf("Bindings(on-case-close) = %v, want [a b]", got)
+	}
+	if defs := engine.Bindings("unknown"); defs != nil {
+		t.Errorf("expected no bindings for unknown event, got %v", defs)
+	}
+}
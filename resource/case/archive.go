@@ -0,0 +1,426 @@
+package caseresource
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	agentops "github.com/gh-xj/agentops"
+	"github.com/gh-xj/agentops/resource"
+	"github.com/gh-xj/agentops/strategy"
+)
+
+// archiveDir holds archived cases under the cases root, dated by completion:
+// archive/YYYY/MM/CASE-* (or CASE-*.tar.gz).
+const archiveDir = "archive"
+
+// manifestFile indexes archived cases so they can still be resolved by ID.
+const manifestFile = "manifest.jsonl"
+
+var (
+	_ resource.Pruner   = (*CaseResource)(nil)
+	_ resource.Archiver = (*CaseResource)(nil)
+)
+
+// ArchiveEntry is one line of archive/manifest.jsonl.
+type ArchiveEntry struct {
+	ID          string         `json:"id"`
+	Path        string         `json:"path"` // relative to the cases root
+	Format      string         `json:"format"`
+	CompletedAt string         `json:"completed_at"`
+	ArchivedAt  string         `json:"archived_at"`
+	Fields      map[string]any `json:"fields"`
+}
+
+// Archive moves a completed case into the archive, regardless of its age.
+func (cr *CaseResource) Archive(ctx *agentops.AppContext, id string) (*resource.Record, error) {
+	if cr.strat == nil {
+		return nil, fmt.Errorf("no strategy loaded")
+	}
+	if err := cr.store.Sync(); err != nil {
+		return nil, err
+	}
+	loc, err := cr.locate(id)
+	if err != nil {
+		return nil, err
+	}
+	fm, err := cr.readFrontmatter(loc)
+	if err != nil {
+		return nil, err
+	}
+	if cat := cr.sm.CategoryForStatus(fm.Status); cat != "completed" {
+		return nil, fmt.Errorf("case %q is %s; only completed cases can be archived", id, fm.Status)
+	}
+	completedAt, err := cr.statusChangedAt(loc)
+	if err != nil {
+		return nil, err
+	}
+	entry, err := cr.archiveCase(ctx, loc, fm, completedAt)
+	if err != nil {
+		return nil, err
+	}
+	return cr.recordFromArchive(entry)
+}
+
+// Prune archives cases that have been completed for longer than the
+// retention in storage.yaml. Dry-run by default (confirm=false).
+func (cr *CaseResource) Prune(ctx *agentops.AppContext, confirm bool) ([]resource.PruneResult, error) {
+	if cr.strat == nil {
+		return nil, fmt.Errorf("no strategy loaded")
+	}
+	retention := cr.strat.Storage.Retention
+	age, err := retention.Age()
+	if err != nil {
+		return nil, err
+	}
+	if age == 0 {
+		return nil, agentops.NewCLIError(agentops.ExitUsage, "no_retention", "no retention configured: set retention.completed_after in storage.yaml", nil)
+	}
+
+	if confirm {
+		if err := cr.store.Sync(); err != nil {
+			return nil, err
+		}
+	}
+	casesRoot, err := cr.casesDir()
+	if err != nil {
+		return nil, err
+	}
+	locs, err := cr.scanCases(casesRoot)
+	if errors.Is(err, fs.ErrNotExist) {
+		// A missing cases directory holds nothing to prune.
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("scan cases: %w", err)
+	}
+
+	cutoff := time.Now().Add(-age)
+	var results []resource.PruneResult
+	for _, loc := range locs {
+		fm, err := cr.readFrontmatter(loc)
+		if err != nil || cr.sm.CategoryForStatus(fm.Status) != "completed" {
+			continue
+		}
+		result := resource.PruneResult{Name: loc.ID, Path: loc.Dir}
+		completedAt, err := cr.statusChangedAt(loc)
+		switch {
+		case err != nil:
+			result.Action, result.Reason = "skipped", err.Error()
+		case completedAt.After(cutoff):
+			result.Action = "skipped"
+			result.Reason = fmt.Sprintf("completed %s, within retention of %s", completedAt.Format("2006-01-02"), retention.CompletedAfter)
+		case !confirm:
+			result.Action = "would_archive"
+			result.Reason = fmt.Sprintf("completed %s", completedAt.Format("2006-01-02"))
+		default:
+			entry, err := cr.archiveCase(ctx, loc, fm, completedAt)
+			if err != nil {
+				result.Action, result.Reason = "skipped", err.Error()
+				break
+			}
+			result.Action = "archived"
+			result.Path = filepath.Join(casesRoot, entry.Path)
+			result.Reason = fmt.Sprintf("completed %s", completedAt.Format("2006-01-02"))
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// readFrontmatter parses the frontmatter of the case at loc.
+func (cr *CaseResource) readFrontmatter(loc CaseLocation) (Frontmatter, error) {
+	data, err := cr.fs.ReadFile(filepath.Join(loc.Dir, "case.md"))
+	if err != nil {
+		return Frontmatter{}, fmt.Errorf("read case.md: %w", err)
+	}
+	fm, _, err := ParseFrontmatter(string(data))
+	if err != nil {
+		return Frontmatter{}, fmt.Errorf("parse frontmatter: %w", err)
+	}
+	return fm, nil
+}
+
+// statusChangedAt returns when the case last changed status, from its history,
+// falling back to the modification time of case.md.
+func (cr *CaseResource) statusChangedAt(loc CaseLocation) (time.Time, error) {
+	entries, err := cr.readHistory(loc.Dir)
+	if err != nil {
+		return time.Time{}, err
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].From == entries[i].To {
+			continue
+		}
+		if t, err := time.Parse(time.RFC3339, entries[i].Timestamp); err == nil {
+			return t, nil
+		}
+	}
+	info, err := os.Stat(filepath.Join(loc.Dir, "case.md"))
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
+
+// archiveCase moves the case at loc into the dated archive tree in the
+// configured format and records it in the manifest. The case lock is held
+// throughout, so no write lands in the case while it moves; writers waiting
+// on the lock fail once the case directory is gone.
+func (cr *CaseResource) archiveCase(ctx *agentops.AppContext, loc CaseLocation, fm Frontmatter, completedAt time.Time) (ArchiveEntry, error) {
+	unlock, err := cr.lockCase(loc.Dir)
+	if err != nil {
+		return ArchiveEntry{}, fmt.Errorf("archive case %q: %w", loc.ID, err)
+	}
+	defer unlock()
+
+	// Re-read the case: it may have been reopened since it was selected.
+	fm, err = cr.readFrontmatter(loc)
+	if err != nil {
+		return ArchiveEntry{}, err
+	}
+	if cat := cr.sm.CategoryForStatus(fm.Status); cat != "completed" {
+		return ArchiveEntry{}, fmt.Errorf("case %q is %s; only completed cases can be archived", loc.ID, fm.Status)
+	}
+
+	casesRoot, err := cr.casesDir()
+	if err != nil {
+		return ArchiveEntry{}, err
+	}
+	format := cr.strat.Storage.Retention.Format
+	if format == "" {
+		format = strategy.ArchiveFormatDir
+	}
+
+	rel := filepath.Join(archiveDir, completedAt.UTC().Format("2006"), completedAt.UTC().Format("01"), loc.ID)
+	if format == strategy.ArchiveFormatTarGz {
+		rel += ".tar.gz"
+	}
+	dest := filepath.Join(casesRoot, rel)
+	if cr.fs.Exists(dest) {
+		return ArchiveEntry{}, fmt.Errorf("archive case %q: %s already exists", loc.ID, dest)
+	}
+	if err := cr.fs.EnsureDir(filepath.Dir(dest)); err != nil {
+		return ArchiveEntry{}, fmt.Errorf("ensure %s: %w", filepath.Dir(rel), err)
+	}
+
+	// The archive is recorded in the case's own history once it has moved,
+	// so a failed move leaves no archive entry behind. A tarball carries the
+	// entry in its copy of the history.
+	history := cr.newHistoryEntry(ctx, "archive", fm.Status, fm.Status)
+	if format == strategy.ArchiveFormatTarGz {
+		line, err := json.Marshal(history)
+		if err != nil {
+			return ArchiveEntry{}, fmt.Errorf("encode %s entry: %w", historyFile, err)
+		}
+		if err := writeTarGz(loc.Dir, loc.ID, dest, map[string][]byte{historyFile: append(line, '\n')}); err != nil {
+			return ArchiveEntry{}, fmt.Errorf("archive case %q: %w", loc.ID, err)
+		}
+		if err := cr.fs.RemoveAll(loc.Dir); err != nil {
+			return ArchiveEntry{}, fmt.Errorf("remove archived case %q: %w", loc.ID, err)
+		}
+	} else {
+		if err := cr.fs.Rename(loc.Dir, dest); err != nil {
+			return ArchiveEntry{}, fmt.Errorf("archive case %q: %w", loc.ID, err)
+		}
+		// The lock moved with the case; the archived copy must not keep it.
+		cr.movedLock(dest)()
+		if err := cr.appendHistory(dest, history); err != nil {
+			return ArchiveEntry{}, err
+		}
+	}
+
+	entry := ArchiveEntry{
+		ID:          loc.ID,
+		Path:        rel,
+		Format:      format,
+		CompletedAt: completedAt.UTC().Format(time.RFC3339),
+		ArchivedAt:  time.Now().UTC().Format(time.RFC3339),
+		Fields:      cr.recordFromFrontmatter(loc.ID, "", fm).Fields,
+	}
+	if err := cr.appendManifest(casesRoot, entry); err != nil {
+		return ArchiveEntry{}, err
+	}
+	if err := cr.commit(loc.ID, dest, history); err != nil {
+		return ArchiveEntry{}, err
+	}
+	return entry, nil
+}
+
+// writeTarGz writes the contents of dir to a gzip-compressed tarball at dest,
+// rooted at name/. Each entry of appends is added to the end of the file of
+// that name in dir, or written as a new file when dir has none. The tarball
+// is written to a temporary file first so a failed write leaves no partial
+// archive.
+func writeTarGz(dir, name, dest string, appends map[string][]byte) error {
+	tmp := dest + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	written := make(map[string]bool, len(appends))
+	walkErr := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Name() == caseLockFile {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		hdr, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		hdr.Name = filepath.ToSlash(filepath.Join(name, rel))
+		if info.IsDir() {
+			hdr.Name += "/"
+		}
+		extra := appends[filepath.ToSlash(rel)]
+		if info.Mode().IsRegular() {
+			hdr.Size += int64(len(extra))
+			written[filepath.ToSlash(rel)] = true
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		src, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+		if _, err := io.Copy(tw, src); err != nil {
+			return err
+		}
+		_, err = tw.Write(extra)
+		return err
+	})
+	if walkErr == nil {
+		walkErr = writeTarFiles(tw, name, appends, written)
+	}
+	for _, closeErr := range []error{walkErr, tw.Close(), gz.Close(), f.Close()} {
+		if closeErr != nil {
+			return closeErr
+		}
+	}
+	return os.Rename(tmp, dest)
+}
+
+// writeTarFiles adds the files of files not marked in skip to tw under name/,
+// in name order.
+func writeTarFiles(tw *tar.Writer, name string, files map[string][]byte, skip map[string]bool) error {
+	rels := make([]string, 0, len(files))
+	for rel := range files {
+		if !skip[rel] {
+			rels = append(rels, rel)
+		}
+	}
+	sort.Strings(rels)
+	for _, rel := range rels {
+		hdr := &tar.Header{
+			Name:    path.Join(name, rel),
+			Mode:    0o644,
+			Size:    int64(len(files[rel])),
+			ModTime: time.Now(),
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := tw.Write(files[rel]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readTarGzFile returns the file name from the gzip-compressed tarball at
+// path, or nil when the tarball has no such file.
+func readTarGzFile(path, name string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", filepath.Base(path), err)
+	}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", filepath.Base(path), err)
+		}
+		if hdr.Name == name {
+			return io.ReadAll(tr)
+		}
+	}
+}
+
+// appendManifest appends entry to the archive manifest.
+func (cr *CaseResource) appendManifest(casesRoot string, entry ArchiveEntry) error {
+	return cr.appendJSONL(filepath.Join(casesRoot, archiveDir, manifestFile), entry)
+}
+
+// archived looks up id in the archive manifest.
+func (cr *CaseResource) archived(id string) (ArchiveEntry, bool) {
+	casesRoot, err := cr.casesDir()
+	if err != nil {
+		return ArchiveEntry{}, false
+	}
+	data, err := cr.fs.ReadFile(filepath.Join(casesRoot, archiveDir, manifestFile))
+	if err != nil {
+		return ArchiveEntry{}, false
+	}
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for scanner.Scan() {
+		var e ArchiveEntry
+		if json.Unmarshal([]byte(scanner.Text()), &e) == nil && e.ID == id {
+			return e, true
+		}
+	}
+	return ArchiveEntry{}, false
+}
+
+// recordFromArchive builds the record of an archived case from its manifest
+// entry. RawPath points at the archived directory or tarball.
+func (cr *CaseResource) recordFromArchive(e ArchiveEntry) (*resource.Record, error) {
+	casesRoot, err := cr.casesDir()
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]any, len(e.Fields)+1)
+	for k, v := range e.Fields {
+		fields[k] = v
+	}
+	fields["id"] = e.ID
+	return &resource.Record{
+		Kind:    "case",
+		ID:      e.ID,
+		Fields:  fields,
+		RawPath: filepath.Join(casesRoot, e.Path),
+	}, nil
+}
@@ -0,0 +1,238 @@
+package caseresource
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gh-xj/agentops/dal"
+	"github.com/gh-xj/agentops/resource"
+	"github.com/gh-xj/agentops/strategy"
+)
+
+// setupRetentionProject bootstraps a project whose storage.yaml sets retention.
+func setupRetentionProject(t *testing.T, retention string) (string, *CaseResource) {
+	t.Helper()
+	root, _ := setupTestProject(t)
+	storage := "backend: in-repo\nretention:\n" + retention
+	if err := os.WriteFile(filepath.Join(root, ".agentops", "storage.yaml"), []byte(storage), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	strat, err := strategy.Discover(root)
+	if err != nil {
+		t.Fatalf("discover: %v", err)
+	}
+	return root, New(dal.NewFileSystem(), dal.NewExecutor(), strat)
+}
+
+// completeCase creates a case and closes it, backdating the closing
+// transition in history.jsonl by age.
+func completeCase(t *testing.T, cr *CaseResource, slug string, age time.Duration) *resource.Record {
+	t.Helper()
+	ctx := testCtx()
+	created, err := cr.Create(ctx, slug, nil)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if _, err := cr.Transition(ctx, created.ID, "close_no_action"); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	rec, _ := cr.Get(ctx, created.ID)
+	historyPath := filepath.Join(filepath.Dir(rec.RawPath), historyFile)
+	data, _ := os.ReadFile(historyPath)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	stamp := time.Now().UTC().Add(-age).Format(time.RFC3339)
+	lines[len(lines)-1] = `{"timestamp":"` + stamp + `","action":"close_no_action","from":"open","to":"closed_no_action","actor":"t","slot":""}`
+	if err := os.WriteFile(historyPath, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return rec
+}
+
+func TestCaseResourcePruneArchivesByRetention(t *testing.T) {
+	root, cr := setupRetentionProject(t, "  completed_after: 30d\n")
+	ctx := testCtx()
+
+	old := completeCase(t, cr, "old-case", 45*24*time.Hour)
+	recent := completeCase(t, cr, "recent-case", 2*24*time.Hour)
+	active, err := cr.Create(ctx, "active-case", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Dry-run by default.
+	results, err := cr.Prune(ctx, false)
+	if err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	actions := map[string]string{}
+	for _, r := range results {
+		actions[r.Name] = r.Action
+	}
+	if actions[old.ID] != "would_archive" || actions[recent.ID] != "skipped" {
+		t.Errorf("dry-run actions = %v", actions)
+	}
+	if _, listed := actions[active.ID]; listed {
+		t.Errorf("active case should not be considered: %v", actions)
+	}
+	if _, err := os.Stat(old.RawPath); err != nil {
+		t.Fatalf("dry-run moved the case: %v", err)
+	}
+
+	results, err = cr.Prune(ctx, true)
+	if err != nil {
+		t.Fatalf("Prune confirm: %v", err)
+	}
+	month := time.Now().UTC().Add(-45 * 24 * time.Hour).Format("2006/01")
+	archived := filepath.Join(root, "cases", archiveDir, filepath.FromSlash(month), old.ID, "case.md")
+	if _, err := os.Stat(archived); err != nil {
+		t.Fatalf("archived case.md missing: %v (results %+v)", err, results)
+	}
+	history, err := os.ReadFile(filepath.Join(filepath.Dir(archived), historyFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(history)), "\n"); !strings.Contains(lines[len(lines)-1], `"action":"archive"`) {
+		t.Errorf("archived history does not end with the archive entry:\n%s", history)
+	}
+
+	// Archived cases drop out of List but still resolve with Get.
+	records, _ := cr.List(ctx, nil)
+	for _, r := range records {
+		if r.ID == old.ID {
+			t.Error("archived case should not be listed")
+		}
+	}
+	got, err := cr.Get(ctx, old.ID)
+	if err != nil {
+		t.Fatalf("Get archived: %v", err)
+	}
+	if got.Fields["status"] != "closed_no_action" || got.RawPath != filepath.Dir(archived) {
+		t.Errorf("archived record = %+v", got)
+	}
+	if _, err := cr.Transition(ctx, old.ID, "start"); err == nil || !strings.Contains(err.Error(), "is archived") {
+		t.Errorf("transition of archived case: err = %v", err)
+	}
+}
+
+func TestCaseResourceArchiveTarGz(t *testing.T) {
+	root, cr := setupRetentionProject(t, "  format: tar.gz\n")
+	ctx := testCtx()
+	done := completeCase(t, cr, "bundle-me", time.Hour)
+
+	rec, err := cr.Archive(ctx, done.ID)
+	if err != nil {
+		t.Fatalf("Archive: %v", err)
+	}
+	if !strings.HasSuffix(rec.RawPath, done.ID+".tar.gz") {
+		t.Errorf("RawPath = %s", rec.RawPath)
+	}
+	if _, err := os.Stat(filepath.Dir(done.RawPath)); !os.IsNotExist(err) {
+		t.Errorf("case directory should be removed, stat err = %v", err)
+	}
+
+	f, err := os.Open(rec.RawPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			break
+		}
+		names = append(names, hdr.Name)
+	}
+	joined := strings.Join(names, ",")
+	for _, want := range []string{done.ID + "/case.md", done.ID + "/" + historyFile} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("tarball missing %s: %v", want, names)
+		}
+	}
+
+	history, err := readTarGzFile(rec.RawPath, done.ID+"/"+historyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(history)), "\n"); !strings.Contains(lines[len(lines)-1], `"action":"archive"`) {
+		t.Errorf("archived history does not end with the archive entry:\n%s", history)
+	}
+
+	if _, err := cr.Get(ctx, done.ID); err != nil {
+		t.Errorf("Get archived: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "cases", archiveDir, manifestFile)); err != nil {
+		t.Errorf("manifest missing: %v", err)
+	}
+}
+
+func TestCaseResourceArchiveRejectsActiveCase(t *testing.T) {
+	_, cr := setupRetentionProject(t, "  completed_after: 1d\n")
+	ctx := testCtx()
+	created, err := cr.Create(ctx, "still-open", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cr.Archive(ctx, created.ID); err == nil {
+		t.Error("archiving an open case should fail")
+	}
+}
+
+func TestCaseResourceArchiveWaitsForCaseLock(t *testing.T) {
+	_, cr := setupRetentionProject(t, "  completed_after: 1d\n")
+	rec := completeCase(t, cr, "locked-case", 48*time.Hour)
+	unlock, err := cr.lockCase(filepath.Dir(rec.RawPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := cr.Archive(testCtx(), rec.ID)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		t.Fatalf("archive should wait for the case lock, got %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	unlock()
+	if err := <-done; err != nil {
+		t.Fatalf("Archive: %v", err)
+	}
+
+	archived, err := cr.Get(testCtx(), rec.ID)
+	if err != nil {
+		t.Fatalf("Get archived: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(archived.RawPath, caseLockFile)); !os.IsNotExist(err) {
+		t.Errorf("archived case should not keep the lock file: %v", err)
+	}
+}
+
+func TestCaseResourcePruneReportsScanError(t *testing.T) {
+	root, cr := setupRetentionProject(t, "  completed_after: 1d\n")
+	casesRoot := filepath.Join(root, "cases")
+	if err := os.RemoveAll(casesRoot); err != nil {
+		t.Fatal(err)
+	}
+	if results, err := cr.Prune(testCtx(), false); err != nil || len(results) != 0 {
+		t.Errorf("missing cases directory: got %v, %v", results, err)
+	}
+	if err := os.WriteFile(casesRoot, []byte("not a directory"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cr.Prune(testCtx(), false); err == nil {
+		t.Error("unreadable cases directory: want error")
+	}
+}
@@ -0,0 +1,59 @@
+package caseresource
+
+import (
+	"fmt"
+
+	agentops "github.com/gh-xj/agentops"
+	"github.com/gh-xj/agentops/resource"
+	"github.com/gh-xj/agentops/risk"
+)
+
+// Frontmatter keys read and written by risk assessment.
+const (
+	RiskField   = "risk"   // assessed level: low, medium or high
+	PathsField  = "paths"  // paths the case touches
+	LabelsField = "labels" // free-form labels
+)
+
+var _ resource.Assessor = (*CaseResource)(nil)
+
+// Assess scores the case against the rules of risk.yaml from its type, the
+// paths and labels in its frontmatter and the words of its body. The level
+// is written to the risk frontmatter field; the rules that fired and the
+// escalation actions for the level are returned.
+func (cr *CaseResource) Assess(ctx *agentops.AppContext, id string) (*resource.Assessment, error) {
+	if cr.strat == nil {
+		return nil, fmt.Errorf("no strategy loaded")
+	}
+	var a risk.Assessment
+	_, err := cr.updateCase(ctx, id, "assess", func(fm *Frontmatter, body string) (string, error) {
+		var err error
+		a, err = risk.Assess(cr.strat.Risk, risk.Subject{
+			Type:   fm.Type,
+			Paths:  fm.Strings(PathsField),
+			Labels: fm.Strings(LabelsField),
+			Body:   body,
+		})
+		if err != nil {
+			return "", err
+		}
+		fm.SetString(RiskField, a.Level)
+		return body, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Empty slices rather than nil, so the JSON output always carries arrays.
+	out := &resource.Assessment{
+		ID:      id,
+		Score:   a.Score,
+		Level:   a.Level,
+		Rules:   make([]resource.AssessedRule, 0, len(a.Hits)),
+		Actions: append([]string{}, a.Actions...),
+	}
+	for _, h := range a.Hits {
+		out.Rules = append(out.Rules, resource.AssessedRule{Name: h.Rule, Score: h.Score, Matched: h.Matched})
+	}
+	return out, nil
+}
@@ -0,0 +1,116 @@
+package caseresource
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gh-xj/agentops/dal"
+	"github.com/gh-xj/agentops/strategy"
+)
+
+func TestCaseResourceAssess(t *testing.T) {
+	root, _ := setupTestProject(t)
+	riskYAML := `thresholds: {medium: 3, high: 6}
+escalation:
+  high: [require-review]
+rules:
+  auth:
+    score: 4
+    paths: ["internal/auth/**"]
+  security:
+    score: 2
+    labels: [security]
+  secrets:
+    score: 1
+    keywords: [password]
+  incident:
+    score: 5
+    types: [incident]
+`
+	if err := os.WriteFile(filepath.Join(root, ".agentops", "risk.yaml"), []byte(riskYAML), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	strat, err := strategy.Discover(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cr := New(dal.NewFileSystem(), dal.NewExecutor(), strat)
+	ctx := testCtx()
+	id := createCases(t, cr, "login-leak")[0]
+
+	rec, err := cr.Get(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(rec.RawPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := strings.Replace(string(data), "status: open", "status: open\nlabels: [security]\npaths: [internal/auth/login.go, README.md]", 1)
+	content += "\nThe password is logged on failure.\n"
+	if err := os.WriteFile(rec.RawPath, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := cr.Assess(ctx, id)
+	if err != nil {
+		t.Fatalf("Assess: %v", err)
+	}
+	var names []string
+	for _, r := range a.Rules {
+		names = append(names, r.Name)
+	}
+	if a.Score != 7 || a.Level != "high" || !reflect.DeepEqual(names, []string{"auth", "secrets", "security"}) {
+		t.Errorf("assessment = %+v, want score 7, level high from auth, secrets and security", a)
+	}
+	if !reflect.DeepEqual(a.Actions, []string{"require-review"}) {
+		t.Errorf("actions = %v", a.Actions)
+	}
+
+	rec, err = cr.Get(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Fields[RiskField] != "high" {
+		t.Errorf("risk field = %v, want high", rec.Fields[RiskField])
+	}
+	after, err := os.ReadFile(rec.RawPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(after), "The password is logged on failure.") {
+		t.Errorf("body lost after assess:\n%s", after)
+	}
+}
+
+func TestCaseResourceAssessEmptyArrays(t *testing.T) {
+	root, _ := setupTestProject(t)
+	if err := os.WriteFile(filepath.Join(root, ".agentops", "risk.yaml"), []byte("thresholds: {high: 6}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	strat, err := strategy.Discover(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cr := New(dal.NewFileSystem(), dal.NewExecutor(), strat)
+	ctx := testCtx()
+	id := createCases(t, cr, "quiet")[0]
+
+	a, err := cr.Assess(ctx, id)
+	if err != nil {
+		t.Fatalf("Assess: %v", err)
+	}
+	data, err := json.Marshal(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"rules":[]`, `"actions":[]`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("assessment JSON missing %s: %s", want, data)
+		}
+	}
+}
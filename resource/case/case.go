@@ -1,6 +1,7 @@
 package caseresource
 
 import (
+	"errors"
 	"fmt"
 	"path/filepath"
 	"regexp"
@@ -9,18 +10,27 @@ import (
 
 	agentops "github.com/gh-xj/agentops"
 	"github.com/gh-xj/agentops/dal"
+	"github.com/gh-xj/agentops/hooks"
 	"github.com/gh-xj/agentops/resource"
 	"github.com/gh-xj/agentops/strategy"
 )
 
 var slugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
 
-// CaseResource implements the Resource, Validator, and Transitioner interfaces.
+// CaseResource implements the Resource, Validator, Transitioner, Claimer,
+// Historian, Reconciler, SectionEditor, Archiver, Pruner, Assessor, Router,
+// Doctor and Reindexer interfaces. Queries go through a CaseStore; writes always go to markdown.
 type CaseResource struct {
 	fs    dal.FileSystem
 	exec  dal.Executor
 	strat *strategy.Strategy
 	sm    *StateMachine
+	hooks *hooks.Engine
+	store Storage
+	cases CaseStore
+
+	deferred int     // depth of DeferCommits calls in effect
+	pending  *Change // writes held back while deferred
 }
 
 // Compile-time interface checks.
@@ -39,32 +49,19 @@ func New(fs dal.FileSystem, exec dal.Executor, strat *strategy.Strategy) *CaseRe
 	}
 	if strat != nil {
 		cr.sm = NewStateMachine(strat.Transitions)
+		cr.hooks = hooks.NewEngine(exec, strat.Hooks, strat.Root)
+		cr.store = NewStorage(fs, exec, strat)
+		cr.cases = newCaseStore(cr)
 	}
 	return cr
 }
 
-// casesDir resolves the cases directory based on storage backend configuration.
+// casesDir resolves the cases directory of the configured storage backend.
 func (cr *CaseResource) casesDir() (string, error) {
 	if cr.strat == nil {
 		return "", fmt.Errorf("no strategy loaded")
 	}
-
-	switch cr.strat.Storage.Backend {
-	case "in-repo":
-		return filepath.Join(cr.strat.Root, "cases"), nil
-	default:
-		// separate-repo (default)
-		caseRepoPath := cr.strat.Storage.CaseRepoPath
-		if caseRepoPath == "" {
-			base := filepath.Base(cr.strat.Root)
-			caseRepoPath = filepath.Join("..", base+"-cases")
-		}
-		// Resolve relative to the project root.
-		if !filepath.IsAbs(caseRepoPath) {
-			caseRepoPath = filepath.Join(cr.strat.Root, caseRepoPath)
-		}
-		return filepath.Join(caseRepoPath, "cases"), nil
-	}
+	return cr.store.Root(), nil
 }
 
 // Schema returns the resource schema for cases.
@@ -74,19 +71,34 @@ func (cr *CaseResource) Schema() resource.ResourceSchema {
 		statuses = cr.sm.AllStatuses()
 	}
 
+	fields := []resource.FieldDef{
+		{Name: "id", Type: "string", Required: true},
+		{Name: "type", Type: "string", Required: true},
+		{Name: "status", Type: "string", Required: true},
+		{Name: "claimed_by", Type: "string", Required: false},
+		{Name: "created", Type: "string", Required: true},
+		{Name: LinkBlockedBy, Type: "list", Hidden: true},
+		{Name: LinkRelatesTo, Type: "list", Hidden: true},
+		{Name: LinkParent, Type: "string", Hidden: true},
+		{Name: RiskField, Type: "string", Hidden: true},
+	}
+	for _, f := range cr.templateFields() {
+		if !hasField(fields, f.Name) {
+			fields = append(fields, f)
+		}
+	}
+
 	return resource.ResourceSchema{
-		Kind: "case",
-		Fields: []resource.FieldDef{
-			{Name: "id", Type: "string", Required: true},
-			{Name: "type", Type: "string", Required: true},
-			{Name: "status", Type: "string", Required: true},
-			{Name: "claimed_by", Type: "string", Required: false},
-			{Name: "created", Type: "string", Required: true},
-		},
+		Kind:     "case",
+		Fields:   fields,
 		Statuses: statuses,
 		CreateArgs: []resource.ArgDef{
 			{Name: "slug", Description: "URL-safe case identifier", Required: true},
 		},
+		CreateOptions: []resource.ArgDef{
+			{Name: "type", Description: "case type; selects .agentops/templates/<type>.md when present"},
+			{Name: "set", Description: "template variable", Repeatable: true},
+		},
 		Description: "A case record tracking an operational task through its lifecycle.",
 	}
 }
@@ -100,6 +112,14 @@ func (cr *CaseResource) Create(ctx *agentops.AppContext, slug string, opts map[s
 	if err := validateSlug(slug); err != nil {
 		return nil, err
 	}
+	caseType := opts["type"]
+	if caseType != "" && !slugPattern.MatchString(caseType) {
+		return nil, fmt.Errorf("invalid type %q: must match ^[a-z0-9][a-z0-9-]*$", caseType)
+	}
+	// Pull first so the ID does not collide with a case another slot made.
+	if err := cr.store.Sync(); err != nil {
+		return nil, err
+	}
 
 	casesRoot, err := cr.casesDir()
 	if err != nil {
@@ -110,24 +130,57 @@ func (cr *CaseResource) Create(ctx *agentops.AppContext, slug string, opts map[s
 		return nil, fmt.Errorf("ensure cases dir: %w", err)
 	}
 
+	parentDir := casesRoot
+	if cr.grouped() {
+		slot := opts["slot"]
+		if slot == "" {
+			if slot, err = cr.currentSlot(ctx); err != nil {
+				return nil, err
+			}
+		}
+		if slot == "" {
+			slot = defaultSlotDir
+		} else if !slugPattern.MatchString(slot) {
+			return nil, fmt.Errorf("invalid slot %q: must match ^[a-z0-9][a-z0-9-]*$", slot)
+		}
+		parentDir = filepath.Join(casesRoot, cr.groupFor(cr.sm.Initial()), slot)
+	}
+
 	dateStr := time.Now().Format("20060102")
 	baseName := fmt.Sprintf("CASE-%s-%s", dateStr, slug)
 	dirName := baseName
-	caseDir := filepath.Join(casesRoot, dirName)
+	caseDir := filepath.Join(parentDir, dirName)
 
-	// Handle collision with -02, -03 suffix.
+	// Handle collision with -02, -03 suffix. IDs are unique across groups.
 	suffix := 2
-	for cr.fs.Exists(caseDir) {
+	for cr.fs.Exists(caseDir) || cr.exists(dirName) {
 		dirName = fmt.Sprintf("%s-%02d", baseName, suffix)
-		caseDir = filepath.Join(casesRoot, dirName)
+		caseDir = filepath.Join(parentDir, dirName)
 		suffix++
 	}
 
+	// Render the template before creating anything, so a missing variable
+	// leaves no half-made case behind.
+	tplText := cr.caseTemplate(caseType)
+	if tplText != "" {
+		vars, err := cr.templateVars(ctx, slug, dirName, dateStr, caseType, opts)
+		if err != nil {
+			return nil, err
+		}
+		name := "schema.md"
+		if _, ok := cr.strat.Templates[caseType]; ok && caseType != "" {
+			name = "templates/" + caseType + ".md"
+		}
+		if tplText, err = renderTemplate(name, tplText, vars); err != nil {
+			return nil, err
+		}
+	}
+
 	if err := cr.fs.EnsureDir(caseDir); err != nil {
 		return nil, fmt.Errorf("create case dir: %w", err)
 	}
 
-	// Build case.md content from strategy's SchemaTemplate if available.
+	// Build case.md content from the rendered template if available.
 	fm := Frontmatter{
 		Type:      "intake",
 		Status:    cr.sm.Initial(),
@@ -135,10 +188,10 @@ func (cr *CaseResource) Create(ctx *agentops.AppContext, slug string, opts map[s
 		Created:   dateStr,
 	}
 
-	var content string
-	if cr.strat.SchemaTemplate != "" {
+	body := "# " + dirName + "\n"
+	if tplText != "" {
 		// Parse template frontmatter and override with runtime values.
-		tplFM, body, err := ParseFrontmatter(cr.strat.SchemaTemplate)
+		tplFM, tplBody, err := ParseFrontmatter(tplText)
 		if err == nil {
 			// Use template values as defaults, override with runtime.
 			if tplFM.Type != "" {
@@ -149,88 +202,118 @@ func (cr *CaseResource) Create(ctx *agentops.AppContext, slug string, opts map[s
 				fm.ClaimedBy = tplFM.ClaimedBy
 			}
 			fm.Created = dateStr
+			// Carry over custom keys, comments and ordering from the template.
+			fm.doc = tplFM.doc
 
 			// Replace title placeholder.
-			body = strings.Replace(body, "# Case Title", "# "+dirName, 1)
-			content = RenderFrontmatter(fm) + body
-		} else {
-			content = RenderFrontmatter(fm) + "# " + dirName + "\n"
+			body = strings.Replace(tplBody, "# Case Title", "# "+dirName, 1)
 		}
-	} else {
-		content = RenderFrontmatter(fm) + "# " + dirName + "\n"
+	}
+	if caseType != "" {
+		fm.Type = caseType
 	}
 
 	caseMDPath := filepath.Join(caseDir, "case.md")
-	if err := cr.fs.WriteFile(caseMDPath, []byte(content), 0o644); err != nil {
+	if err := cr.fs.WriteFile(caseMDPath, []byte(RenderFrontmatter(fm)+body), 0o644); err != nil {
 		return nil, fmt.Errorf("write case.md: %w", err)
 	}
 
+	results, err := cr.hooks.Fire(hooks.Event{
+		Name:      hooks.EventCaseOpen,
+		CaseID:    dirName,
+		NewStatus: fm.Status,
+		CasePath:  caseDir,
+	})
+	if err != nil {
+		// A blocking on-case-open hook vetoes the case entirely.
+		if rmErr := cr.fs.RemoveAll(caseDir); rmErr != nil {
+			return nil, errors.Join(err, fmt.Errorf("remove vetoed case %s: %w", dirName, rmErr))
+		}
+		return nil, err
+	}
+	if len(hooks.Failures(results)) > 0 {
+		body = appendHookFailures(body, results)
+		if err := cr.fs.WriteFile(caseMDPath, []byte(RenderFrontmatter(fm)+body), 0o644); err != nil {
+			return nil, fmt.Errorf("write case.md: %w", err)
+		}
+	}
+
+	entry := cr.newHistoryEntry(ctx, "create", "", fm.Status)
+	if err := cr.appendHistory(caseDir, entry); err != nil {
+		return nil, err
+	}
+	if err := cr.commit(dirName, caseMDPath, entry); err != nil {
+		return nil, err
+	}
+
 	return cr.recordFromFrontmatter(dirName, caseMDPath, fm), nil
 }
 
-// List walks the cases directory and returns matching records.
+// List queries the case store and returns matching records. The
+// resource.GrepKey search runs over the case body, read from case.md.
 func (cr *CaseResource) List(ctx *agentops.AppContext, filter resource.Filter) ([]resource.Record, error) {
 	if cr.strat == nil {
 		return nil, fmt.Errorf("no strategy loaded")
 	}
 
-	casesRoot, err := cr.casesDir()
+	cases, err := cr.cases.Cases()
 	if err != nil {
 		return nil, err
 	}
 
-	entries, err := cr.fs.ReadDir(casesRoot)
-	if err != nil {
-		// If directory doesn't exist, return empty list.
-		return nil, nil
-	}
-
-	// Pre-compute status filter if specified.
+	// status and slot equality filters have case-specific meanings: a status
+	// may name a category, and a slot matches the claim or the storage slot.
+	// Every other condition is matched generically against the record.
+	fields := filter
 	var statusFilter map[string]bool
-	if statusVal, ok := filter["status"]; ok && statusVal != "" {
-		statusFilter, err = cr.sm.ExpandStatusFilter(statusVal)
+	if c, ok := equality(filter, "status"); ok {
+		statusFilter, err = cr.sm.ExpandStatusFilter(c)
 		if err != nil {
 			return nil, err
 		}
+		fields = fields.Without("status")
 	}
 
-	slotFilter := ""
-	if slot, ok := filter["slot"]; ok {
-		slotFilter = slot
+	slotFilter, hasSlot := equality(filter, "slot")
+	if hasSlot {
+		fields = fields.Without("slot")
 	}
 
+	_, grep := filter.GrepQuery()
 	var records []resource.Record
-	for _, entry := range entries {
-		if !entry.IsDir || !strings.HasPrefix(entry.Name, "CASE-") {
-			continue
-		}
-
-		caseMDPath := filepath.Join(casesRoot, entry.Name, "case.md")
-		data, err := cr.fs.ReadFile(caseMDPath)
-		if err != nil {
+	for _, c := range cases {
+		fm := c.Frontmatter
+		if statusFilter != nil && !statusFilter[fm.Status] {
 			continue
 		}
-
-		fm, _, err := ParseFrontmatter(string(data))
-		if err != nil {
+		if hasSlot && fm.ClaimedBy != slotFilter && c.Slot != slotFilter {
 			continue
 		}
 
-		// Apply filters.
-		if statusFilter != nil && !statusFilter[fm.Status] {
-			continue
+		caseMDPath := filepath.Join(c.Dir, "case.md")
+		var body string
+		if grep {
+			data, err := cr.fs.ReadFile(caseMDPath)
+			if err != nil {
+				continue
+			}
+			if _, body, err = ParseFrontmatter(string(data)); err != nil {
+				continue
+			}
 		}
-		if slotFilter != "" && fm.ClaimedBy != slotFilter {
+		rec := cr.recordFromFrontmatter(c.ID, caseMDPath, fm)
+		if !fields.Match(*rec, body) {
 			continue
 		}
 
-		records = append(records, *cr.recordFromFrontmatter(entry.Name, caseMDPath, fm))
+		records = append(records, *rec)
 	}
 
 	return records, nil
 }
 
-// Get retrieves a case record by its ID.
+// Get retrieves a case record by its ID. Archived cases are resolved from the
+// archive manifest.
 func (cr *CaseResource) Get(ctx *agentops.AppContext, id string) (*resource.Record, error) {
 	if cr.strat == nil {
 		return nil, fmt.Errorf("no strategy loaded")
@@ -238,6 +321,9 @@ func (cr *CaseResource) Get(ctx *agentops.AppContext, id string) (*resource.Reco
 
 	caseMDPath, err := cr.findCaseMD(id)
 	if err != nil {
+		if e, ok := cr.archived(id); ok {
+			return cr.recordFromArchive(e)
+		}
 		return nil, err
 	}
 
@@ -314,16 +400,30 @@ func (cr *CaseResource) Validate(ctx *agentops.AppContext, id string) (*agentops
 	return report, nil
 }
 
-// Transition applies a state machine action to a case and returns the updated record.
-func (cr *CaseResource) Transition(ctx *agentops.AppContext, id string, action string) (*resource.Record, error) {
+// Transition applies a state machine action to a case and returns the updated
+// record. A reason given with resource.WithReason is recorded in its history.
+func (cr *CaseResource) Transition(ctx *agentops.AppContext, id string, action string, opts ...resource.TransitionOption) (*resource.Record, error) {
 	if cr.strat == nil {
 		return nil, fmt.Errorf("no strategy loaded")
 	}
 
-	caseMDPath, err := cr.findCaseMD(id)
+	// Pull first so the transition applies to the latest shared status.
+	if err := cr.store.Sync(); err != nil {
+		return nil, err
+	}
+	// The lock is held until the case has moved, so no write lands in the
+	// directory it is leaving.
+	loc, unlock, err := cr.lockLocated(id)
 	if err != nil {
 		return nil, err
 	}
+	locked := true
+	defer func() {
+		if locked {
+			unlock()
+		}
+	}()
+	caseMDPath := filepath.Join(loc.Dir, "case.md")
 
 	data, err := cr.fs.ReadFile(caseMDPath)
 	if err != nil {
@@ -335,62 +435,196 @@ func (cr *CaseResource) Transition(ctx *agentops.AppContext, id string, action s
 		return nil, fmt.Errorf("parse frontmatter: %w", err)
 	}
 
-	oldCategory := cr.sm.CategoryForStatus(fm.Status)
+	// A slot must halt on cases another slot owns.
+	if err := cr.checkOwner(ctx, id, fm); err != nil {
+		return nil, err
+	}
+
+	oldStatus := fm.Status
+	oldCategory := cr.sm.CategoryForStatus(oldStatus)
 
-	newStatus, err := cr.sm.Apply(fm.Status, action)
+	subj, err := cr.guardSubject(caseMDPath, fm, body, action)
 	if err != nil {
 		return nil, err
 	}
+	newStatus, err := cr.sm.Apply(subj, action)
+	if err != nil {
+		return nil, err
+	}
+	newCategory := cr.sm.CategoryForStatus(newStatus)
+
+	// Hooks run before the write so a blocking hook can veto the transition.
+	ev := hooks.Event{
+		Name:      hooks.EventCaseTransition,
+		CaseID:    id,
+		OldStatus: oldStatus,
+		NewStatus: newStatus,
+		CasePath:  filepath.Dir(caseMDPath),
+	}
+	results, err := cr.hooks.Fire(ev)
+	if err != nil {
+		return nil, err
+	}
+	if newCategory == "completed" && oldCategory != "completed" {
+		ev.Name = hooks.EventCaseClose
+		closeResults, err := cr.hooks.Fire(ev)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, closeResults...)
+	}
 
 	fm.Status = newStatus
-	newContent := RenderFrontmatter(fm) + body
+	newContent := RenderFrontmatter(fm) + appendHookFailures(body, results)
 
-	if err := cr.fs.WriteFile(caseMDPath, []byte(newContent), 0o644); err != nil {
-		return nil, fmt.Errorf("write case.md: %w", err)
+	if err := cr.writeCaseMD(caseMDPath, []byte(newContent)); err != nil {
+		return nil, err
 	}
 
-	// Check if category changed — for now this is informational.
-	// In separate-repo mode with group subdirs, we would move the directory.
-	// In in-repo mode, cases stay in the same flat directory.
-	newCategory := cr.sm.CategoryForStatus(newStatus)
-	_ = oldCategory
-	_ = newCategory
+	// In the grouped layout a case crossing categories moves between
+	// active/<slot>/ and completed/<slot>/.
+	if oldCategory != newCategory {
+		moved, err := cr.relocate(loc, newStatus)
+		if err != nil {
+			return nil, err
+		}
+		if moved.Dir != loc.Dir {
+			unlock = cr.movedLock(moved.Dir)
+		}
+		caseMDPath = filepath.Join(moved.Dir, "case.md")
+	}
+	unlock()
+	locked = false
+
+	entry := cr.newHistoryEntry(ctx, action, oldStatus, newStatus)
+	entry.Reason = resource.ApplyTransitionOptions(opts...).Reason
+	if err := cr.appendHistory(filepath.Dir(caseMDPath), entry); err != nil {
+		return nil, err
+	}
+	if err := cr.commit(id, caseMDPath, entry); err != nil {
+		return nil, err
+	}
 
 	return cr.recordFromFrontmatter(id, caseMDPath, fm), nil
 }
 
+// TransitionTo moves a case to the target status using whichever action the
+// state machine allows from its current status.
+func (cr *CaseResource) TransitionTo(ctx *agentops.AppContext, id, status string, opts ...resource.TransitionOption) (*resource.Record, error) {
+	if cr.strat == nil {
+		return nil, fmt.Errorf("no strategy loaded")
+	}
+
+	rec, err := cr.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	current, _ := rec.Fields["status"].(string)
+	if current == status {
+		return rec, nil
+	}
+	action, ok := cr.sm.ActionTo(current, status)
+	if !ok {
+		return nil, fmt.Errorf("no action moves case %q from %q to %q", id, current, status)
+	}
+	return cr.Transition(ctx, id, action, opts...)
+}
+
 // findCaseMD locates the case.md file for a given case ID.
 func (cr *CaseResource) findCaseMD(id string) (string, error) {
-	casesRoot, err := cr.casesDir()
+	loc, err := cr.locate(id)
 	if err != nil {
 		return "", err
 	}
+	return filepath.Join(loc.Dir, "case.md"), nil
+}
 
-	// Direct lookup in the flat cases directory.
-	caseMDPath := filepath.Join(casesRoot, id, "case.md")
-	if cr.fs.Exists(caseMDPath) {
-		return caseMDPath, nil
+// exists reports whether a case with the given ID exists in any layout or
+// in the archive.
+func (cr *CaseResource) exists(id string) bool {
+	if _, err := cr.locate(id); err == nil {
+		return true
 	}
+	_, ok := cr.archived(id)
+	return ok
+}
+
+// caseIDPattern splits a case ID into its creation date, slug and optional
+// collision suffix.
+var caseIDPattern = regexp.MustCompile(`^CASE-(\d{8})-(.+?)(-\d{2})?$`)
 
-	return "", fmt.Errorf("case %q not found", id)
+// FindOpen returns the most recent case created for slug that is not yet
+// completed, so a slug names the same case until it is done. A case made
+// with a collision suffix, such as CASE-20260101-slug-02, matches too.
+func (cr *CaseResource) FindOpen(ctx *agentops.AppContext, slug string) (*resource.Record, bool, error) {
+	if cr.strat == nil {
+		return nil, false, fmt.Errorf("no strategy loaded")
+	}
+	cases, err := cr.cases.Cases()
+	if err != nil {
+		return nil, false, err
+	}
+	var found *StoredCase
+	for i, c := range cases {
+		m := caseIDPattern.FindStringSubmatch(c.ID)
+		if m == nil || (m[2] != slug && m[2]+m[3] != slug) {
+			continue
+		}
+		if cr.sm.CategoryForStatus(c.Frontmatter.Status) == "completed" {
+			continue
+		}
+		if found == nil || c.ID > found.ID {
+			found = &cases[i]
+		}
+	}
+	if found == nil {
+		return nil, false, nil
+	}
+	return cr.recordFromFrontmatter(found.ID, filepath.Join(found.Dir, "case.md"), found.Frontmatter), true, nil
 }
 
 // recordFromFrontmatter builds a Record from a case ID and its frontmatter.
+// Custom frontmatter keys are included alongside the core fields.
 func (cr *CaseResource) recordFromFrontmatter(id, rawPath string, fm Frontmatter) *resource.Record {
+	fields := map[string]any{
+		"id":         id,
+		"type":       fm.Type,
+		"status":     fm.Status,
+		"claimed_by": fm.ClaimedBy,
+		"created":    fm.Created,
+	}
+	for k, v := range fm.Extra() {
+		if _, ok := fields[k]; !ok {
+			fields[k] = v
+		}
+	}
 	return &resource.Record{
-		Kind: "case",
-		ID:   id,
-		Fields: map[string]any{
-			"id":         id,
-			"type":       fm.Type,
-			"status":     fm.Status,
-			"claimed_by": fm.ClaimedBy,
-			"created":    fm.Created,
-		},
+		Kind:    "case",
+		ID:      id,
+		Fields:  fields,
 		RawPath: rawPath,
 	}
 }
 
+// hasField reports whether fields declares name.
+func hasField(fields []resource.FieldDef, name string) bool {
+	for _, f := range fields {
+		if f.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// equality returns the value of an equality condition on key, if filter has one.
+func equality(filter resource.Filter, key string) (string, bool) {
+	c, ok := filter.Condition(key)
+	if !ok || c.Op != "=" || c.Value == "" {
+		return "", false
+	}
+	return c.Value, true
+}
+
 // validateSlug checks that a case slug is safe and well-formed.
 func validateSlug(slug string) error {
 	if slug == "" {
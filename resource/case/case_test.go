@@ -6,9 +6,11 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	agentops "github.com/gh-xj/agentops"
 	"github.com/gh-xj/agentops/dal"
+	"github.com/gh-xj/agentops/resource"
 	"github.com/gh-xj/agentops/strategy"
 )
 
@@ -66,6 +68,17 @@ func TestCaseResourceSchema(t *testing.T) {
 			t.Errorf("missing field %q in schema", name)
 		}
 	}
+
+	// Links and risk are opt-in columns, so the default table is unchanged.
+	var columns []string
+	for _, f := range schema.Fields {
+		if !f.Hidden {
+			columns = append(columns, f.Name)
+		}
+	}
+	if got := strings.Join(columns, ","); got != "id,type,status,claimed_by,created" {
+		t.Errorf("default columns = %s", got)
+	}
 }
 
 func TestCaseResourceCreate(t *testing.T) {
@@ -189,7 +202,7 @@ func TestCaseResourceListFilterByStatus(t *testing.T) {
 	}
 
 	// Filter by exact status
-	records, err := cr.List(ctx, map[string]string{"status": "open"})
+	records, err := cr.List(ctx, resource.Filter{resource.Equals("status", "open")})
 	if err != nil {
 		t.Fatalf("List with status filter: %v", err)
 	}
@@ -198,7 +211,7 @@ func TestCaseResourceListFilterByStatus(t *testing.T) {
 	}
 
 	// Filter by status group
-	records, err = cr.List(ctx, map[string]string{"status": "active"})
+	records, err = cr.List(ctx, resource.Filter{resource.Equals("status", "active")})
 	if err != nil {
 		t.Fatalf("List with group filter: %v", err)
 	}
@@ -207,7 +220,7 @@ func TestCaseResourceListFilterByStatus(t *testing.T) {
 	}
 
 	// Filter by non-matching status
-	records, err = cr.List(ctx, map[string]string{"status": "resolved"})
+	records, err = cr.List(ctx, resource.Filter{resource.Equals("status", "resolved")})
 	if err != nil {
 		t.Fatalf("List with resolved filter: %v", err)
 	}
@@ -216,6 +229,54 @@ func TestCaseResourceListFilterByStatus(t *testing.T) {
 	}
 }
 
+func TestCaseResourceListFilterExpressions(t *testing.T) {
+	_, strat := setupTestProject(t)
+	cr := New(dal.NewFileSystem(), dal.NewExecutor(), strat)
+	ctx := testCtx()
+
+	claimed, err := cr.Create(ctx, "claimed-case", nil)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if _, err := cr.Claim(slotCtx("alpha"), claimed.ID, false); err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	free, err := cr.Create(ctx, "free-case", nil)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	data, _ := os.ReadFile(free.RawPath)
+	if err := os.WriteFile(free.RawPath, append(data, []byte("\nThe retry loop is flaky.\n")...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ids := func(filter resource.Filter) string {
+		t.Helper()
+		records, err := cr.List(ctx, filter)
+		if err != nil {
+			t.Fatalf("List(%v): %v", filter, err)
+		}
+		var out []string
+		for _, r := range records {
+			out = append(out, r.ID)
+		}
+		return strings.Join(out, ",")
+	}
+
+	if got := ids(resource.Filter{{Field: "claimed_by", Op: "!=", Value: "none"}}); got != claimed.ID {
+		t.Errorf("claimed_by!=none = %q, want %q", got, claimed.ID)
+	}
+	if got := ids(resource.Filter{{Field: "status", Op: "!=", Value: "open"}}); got != "" {
+		t.Errorf("status!=open = %q, want none", got)
+	}
+	if got := ids(resource.Filter{{Field: "created", Op: ">=", Value: "20000101"}, resource.Grep("FLAKY")}); got != free.ID {
+		t.Errorf("grep = %q, want %q", got, free.ID)
+	}
+	if got := ids(resource.Filter{resource.Equals("type", "nope")}); got != "" {
+		t.Errorf("type=nope = %q, want none", got)
+	}
+}
+
 func TestCaseResourceGet(t *testing.T) {
 	_, strat := setupTestProject(t)
 	fs := dal.NewFileSystem()
@@ -461,7 +522,7 @@ func TestCaseResourceListFilterBySlot(t *testing.T) {
 	}
 
 	// Filter by slot (claimed_by)
-	records, err := cr.List(ctx, map[string]string{"slot": "agent-1"})
+	records, err := cr.List(ctx, resource.Filter{resource.Equals("slot", "agent-1")})
 	if err != nil {
 		t.Fatalf("List with slot filter: %v", err)
 	}
@@ -470,7 +531,7 @@ func TestCaseResourceListFilterBySlot(t *testing.T) {
 	}
 
 	// Filter by different slot
-	records, err = cr.List(ctx, map[string]string{"slot": "other"})
+	records, err = cr.List(ctx, resource.Filter{resource.Equals("slot", "other")})
 	if err != nil {
 		t.Fatalf("List with other slot filter: %v", err)
 	}
@@ -492,3 +553,380 @@ func TestCaseResourceCreateSlugMaxLength(t *testing.T) {
 		t.Fatal("expected error for slug exceeding 128 chars")
 	}
 }
+
+// writeHooks replaces the project's hooks.yaml and reloads the strategy.
+func writeHooks(t *testing.T, root, hooksYAML string) *strategy.Strategy {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(root, ".agentops", "hooks.yaml"), []byte(hooksYAML), 0o644); err != nil {
+		t.Fatalf("write hooks.yaml: %v", err)
+	}
+	strat, err := strategy.Discover(root)
+	if err != nil {
+		t.Fatalf("discover: %v", err)
+	}
+	return strat
+}
+
+func TestCaseResourceTransitionBlockingHookDenies(t *testing.T) {
+	root, _ := setupTestProject(t)
+	strat := writeHooks(t, root, "on_case_transition:\n  - run: \"test \\\"$AGENTOPS_CASE_NEW_STATUS\\\" != in_progress\"\n    blocking: true\n")
+	cr := New(dal.NewFileSystem(), dal.NewExecutor(), strat)
+	ctx := testCtx()
+
+	created, err := cr.Create(ctx, "hook-deny", nil)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	_, err = cr.Transition(ctx, created.ID, "start")
+	if err == nil {
+		t.Fatal("expected blocking hook to deny transition")
+	}
+	if code := agentops.ResolveExitCode(err); code != agentops.ExitTransitionDenied {
+		t.Errorf("exit code = %d, want %d", code, agentops.ExitTransitionDenied)
+	}
+
+	got, err := cr.Get(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Fields["status"] != "open" {
+		t.Errorf("status = %v, want 'open' after denied transition", got.Fields["status"])
+	}
+
+	// block is still allowed because the hook only rejects in_progress.
+	if _, err := cr.Transition(ctx, created.ID, "block"); err != nil {
+		t.Fatalf("Transition block: %v", err)
+	}
+}
+
+func TestCaseResourceNonBlockingHookFailureLogged(t *testing.T) {
+	root, _ := setupTestProject(t)
+	strat := writeHooks(t, root, "on_case_transition:\n  - exit 7\non_case_close:\n  - echo closing\n")
+	cr := New(dal.NewFileSystem(), dal.NewExecutor(), strat)
+	ctx := testCtx()
+
+	created, err := cr.Create(ctx, "hook-log", nil)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if _, err := cr.Transition(ctx, created.ID, "start"); err != nil {
+		t.Fatalf("non-blocking hook failure should not deny transition: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(root, "cases", created.ID, "case.md"))
+	if err != nil {
+		t.Fatalf("read case.md: %v", err)
+	}
+	content := string(data)
+	if !strings.Contains(content, "status: in_progress") {
+		t.Errorf("expected status to be persisted, got:\n%s", content)
+	}
+	if !strings.Contains(content, "## Log") || !strings.Contains(content, "hook on-case-transition `exit 7` failed") {
+		t.Errorf("expected hook failure in case log, got:\n%s", content)
+	}
+}
+
+func TestCaseResourceCreateBlockingHookRemovesCase(t *testing.T) {
+	root, _ := setupTestProject(t)
+	strat := writeHooks(t, root, "on_case_open:\n  - run: \"false\"\n    blocking: true\n")
+	cr := New(dal.NewFileSystem(), dal.NewExecutor(), strat)
+	ctx := testCtx()
+
+	if _, err := cr.Create(ctx, "vetoed", nil); err == nil {
+		t.Fatal("expected blocking on-case-open hook to fail create")
+	}
+	records, err := cr.List(ctx, nil)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(records) != 0 {
+		t.Errorf("expected vetoed case to be removed, found %d records", len(records))
+	}
+}
+
+// noRemoveFS is a file system whose RemoveAll always fails.
+type noRemoveFS struct{ dal.FileSystem }
+
+func (noRemoveFS) RemoveAll(path string) error {
+	return &os.PathError{Op: "remove", Path: path, Err: os.ErrPermission}
+}
+
+// noLockFS is a file system on which no file can be created exclusively.
+type noLockFS struct{ dal.FileSystem }
+
+func (noLockFS) CreateExclusive(path string, perm int) error {
+	return &os.PathError{Op: "open", Path: path, Err: os.ErrPermission}
+}
+
+func TestCaseResourceLocksThroughFileSystem(t *testing.T) {
+	_, strat := setupTestProject(t)
+	created, err := New(dal.NewFileSystem(), dal.NewExecutor(), strat).Create(testCtx(), "unlockable", nil)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	cr := New(noLockFS{dal.NewFileSystem()}, dal.NewExecutor(), strat)
+	if _, err := cr.Transition(testCtx(), created.ID, "start"); err == nil || !strings.Contains(err.Error(), "create case lock") {
+		t.Errorf("Transition error = %v, want a case lock failure", err)
+	}
+}
+
+func TestCaseResourceCreateReportsVetoCleanupError(t *testing.T) {
+	root, _ := setupTestProject(t)
+	strat := writeHooks(t, root, "on_case_open:\n  - run: \"false\"\n    blocking: true\n")
+	cr := New(noRemoveFS{dal.NewFileSystem()}, dal.NewExecutor(), strat)
+
+	_, err := cr.Create(testCtx(), "vetoed", nil)
+	if err == nil {
+		t.Fatal("expected blocking on-case-open hook to fail create")
+	}
+	if msg := err.Error(); !strings.Contains(msg, "on-case-open") || !strings.Contains(msg, "remove vetoed case") {
+		t.Errorf("error should report the hook and the failed cleanup, got: %v", err)
+	}
+}
+
+// setupGroupedProject is setupTestProject with the grouped storage layout.
+func setupGroupedProject(t *testing.T) (string, *strategy.Strategy) {
+	t.Helper()
+	root, _ := setupTestProject(t)
+	storageYAML := filepath.Join(root, ".agentops", "storage.yaml")
+	if err := os.WriteFile(storageYAML, []byte("backend: in-repo\nlayout: grouped\n"), 0o644); err != nil {
+		t.Fatalf("write storage.yaml: %v", err)
+	}
+	strat, err := strategy.Discover(root)
+	if err != nil {
+		t.Fatalf("discover: %v", err)
+	}
+	return root, strat
+}
+
+func TestCaseResourceGroupedLayoutMovesOnTransition(t *testing.T) {
+	root, strat := setupGroupedProject(t)
+	cr := New(dal.NewFileSystem(), dal.NewExecutor(), strat)
+	ctx := testCtx()
+
+	created, err := cr.Create(ctx, "grouped", map[string]string{"slot": "alpha"})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	activeDir := filepath.Join(root, "cases", "active", "alpha", created.ID)
+	if created.RawPath != filepath.Join(activeDir, "case.md") {
+		t.Fatalf("created at %s, want under %s", created.RawPath, activeDir)
+	}
+
+	if _, err := cr.Transition(ctx, created.ID, "start"); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	if _, err := os.Stat(activeDir); err != nil {
+		t.Fatalf("case should stay in active/ within a category: %v", err)
+	}
+
+	resolved, err := cr.Transition(ctx, created.ID, "resolve")
+	if err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	completedDir := filepath.Join(root, "cases", "completed", "alpha", created.ID)
+	if resolved.RawPath != filepath.Join(completedDir, "case.md") {
+		t.Errorf("RawPath = %s, want under %s", resolved.RawPath, completedDir)
+	}
+	if _, err := os.Stat(activeDir); !os.IsNotExist(err) {
+		t.Errorf("active directory should be gone, stat err = %v", err)
+	}
+
+	got, err := cr.Get(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("Get after move: %v", err)
+	}
+	if got.Fields["status"] != "resolved" {
+		t.Errorf("status = %v, want resolved", got.Fields["status"])
+	}
+
+	bySlot, err := cr.List(ctx, resource.Filter{resource.Equals("slot", "alpha")})
+	if err != nil {
+		t.Fatalf("List by slot: %v", err)
+	}
+	if len(bySlot) != 1 || bySlot[0].ID != created.ID {
+		t.Errorf("List slot=alpha = %+v", bySlot)
+	}
+}
+
+func TestCaseResourceGroupedLayoutDefaultSlotAndFlatCases(t *testing.T) {
+	root, strat := setupGroupedProject(t)
+	// A case left over from the flat layout.
+	flatDir := filepath.Join(root, "cases", "CASE-20260101-legacy")
+	if err := os.MkdirAll(flatDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	legacy := "---\ntype: intake\nstatus: open\nclaimed_by: none\ncreated: \"20260101\"\n---\n# legacy\n"
+	if err := os.WriteFile(filepath.Join(flatDir, "case.md"), []byte(legacy), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cr := New(dal.NewFileSystem(), dal.NewExecutor(), strat)
+	ctx := testCtx()
+	created, err := cr.Create(ctx, "unslotted", nil)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if !strings.Contains(created.RawPath, filepath.Join("active", defaultSlotDir)) {
+		t.Errorf("unslotted case created at %s", created.RawPath)
+	}
+
+	inSlot, err := cr.Create(slotCtx("beta"), "from-slot", nil)
+	if err != nil {
+		t.Fatalf("create in slot: %v", err)
+	}
+	if want := filepath.Join(root, "cases", "active", "beta", inSlot.ID, "case.md"); inSlot.RawPath != want {
+		t.Errorf("case created from slot beta at %s, want %s", inSlot.RawPath, want)
+	}
+
+	records, err := cr.List(ctx, nil)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("expected flat and grouped cases, got %d", len(records))
+	}
+
+	// Transitioning a flat case across categories moves it into the grouped layout.
+	moved, err := cr.Transition(ctx, "CASE-20260101-legacy", "close_no_action")
+	if err != nil {
+		t.Fatalf("close_no_action: %v", err)
+	}
+	want := filepath.Join(root, "cases", "completed", defaultSlotDir, "CASE-20260101-legacy", "case.md")
+	if moved.RawPath != want {
+		t.Errorf("RawPath = %s, want %s", moved.RawPath, want)
+	}
+}
+
+func TestCaseResourceFlatLayoutDoesNotMove(t *testing.T) {
+	root, strat := setupTestProject(t)
+	cr := New(dal.NewFileSystem(), dal.NewExecutor(), strat)
+	ctx := testCtx()
+
+	created, err := cr.Create(ctx, "flat", nil)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	resolved, err := cr.Transition(ctx, created.ID, "close_no_action")
+	if err != nil {
+		t.Fatalf("close_no_action: %v", err)
+	}
+	if want := filepath.Join(root, "cases", created.ID, "case.md"); resolved.RawPath != want {
+		t.Errorf("RawPath = %s, want %s", resolved.RawPath, want)
+	}
+}
+
+func TestCaseResourceCustomFrontmatterSurvivesTransition(t *testing.T) {
+	root, _ := setupTestProject(t)
+	template := "---\ntype: intake\nstatus: open\nclaimed_by: none\ncreated: \"YYYY-MM-DD\"\npriority: 3 # 1 is highest\nlinear_ref: \"\"\n---\n# Case Title\n"
+	if err := os.WriteFile(filepath.Join(root, ".agentops", "schema.md"), []byte(template), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	strat, err := strategy.Discover(root)
+	if err != nil {
+		t.Fatalf("discover: %v", err)
+	}
+	cr := New(dal.NewFileSystem(), dal.NewExecutor(), strat)
+	ctx := testCtx()
+
+	var fieldNames []string
+	for _, f := range cr.Schema().Fields {
+		fieldNames = append(fieldNames, f.Name)
+	}
+	if got := strings.Join(fieldNames, ","); got != "id,type,status,claimed_by,created,blocked_by,relates_to,parent,risk,priority,linear_ref" {
+		t.Errorf("schema fields = %s", got)
+	}
+
+	created, err := cr.Create(ctx, "custom-fields", nil)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if created.Fields["priority"] != 3 {
+		t.Errorf("priority = %#v, want 3", created.Fields["priority"])
+	}
+
+	// Hand-edit a custom field, then transition.
+	data, _ := os.ReadFile(created.RawPath)
+	edited := strings.Replace(string(data), `linear_ref: ""`, "linear_ref: ENG-7", 1)
+	if err := os.WriteFile(created.RawPath, []byte(edited), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	moved, err := cr.Transition(ctx, created.ID, "start")
+	if err != nil {
+		t.Fatalf("transition: %v", err)
+	}
+	if moved.Fields["linear_ref"] != "ENG-7" {
+		t.Errorf("linear_ref = %v, want ENG-7", moved.Fields["linear_ref"])
+	}
+	data, _ = os.ReadFile(created.RawPath)
+	if !strings.Contains(string(data), "priority: 3 # 1 is highest\n") {
+		t.Errorf("custom field comment lost:\n%s", data)
+	}
+}
+
+func TestCaseResourceTransitionFollowsMovedCase(t *testing.T) {
+	root, strat := setupGroupedProject(t)
+	cr := New(dal.NewFileSystem(), dal.NewExecutor(), strat)
+	ctx := testCtx()
+
+	created, err := cr.Create(ctx, "moving", map[string]string{"slot": "alpha"})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	oldDir := filepath.Dir(created.RawPath)
+	newDir := filepath.Join(root, "cases", "active", "beta", created.ID)
+
+	// Another writer holds the lock while it moves the case to slot beta.
+	unlock, err := cr.lockCase(oldDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	moved := make(chan error, 1)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		if err := os.MkdirAll(filepath.Dir(newDir), 0o755); err != nil {
+			moved <- err
+			return
+		}
+		err := os.Rename(oldDir, newDir)
+		cr.movedLock(newDir)()
+		moved <- err
+	}()
+	defer unlock()
+
+	rec, err := cr.Transition(ctx, created.ID, "start")
+	if err := <-moved; err != nil {
+		t.Fatalf("move: %v", err)
+	}
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	if rec.RawPath != filepath.Join(newDir, "case.md") {
+		t.Errorf("RawPath = %s, want under %s", rec.RawPath, newDir)
+	}
+	if _, err := os.Stat(filepath.Join(newDir, caseLockFile)); !os.IsNotExist(err) {
+		t.Errorf("case lock left behind, stat err = %v", err)
+	}
+}
+
+func TestCaseResourceRelocateReleasesMovedLock(t *testing.T) {
+	root, strat := setupGroupedProject(t)
+	cr := New(dal.NewFileSystem(), dal.NewExecutor(), strat)
+	ctx := testCtx()
+
+	created, err := cr.Create(ctx, "closing", map[string]string{"slot": "alpha"})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if _, err := cr.Transition(ctx, created.ID, "close_no_action"); err != nil {
+		t.Fatalf("close_no_action: %v", err)
+	}
+	dir := filepath.Join(root, "cases", "completed", "alpha", created.ID)
+	if _, err := os.Stat(filepath.Join(dir, "case.md")); err != nil {
+		t.Fatalf("case not moved: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, caseLockFile)); !os.IsNotExist(err) {
+		t.Errorf("case lock moved and kept, stat err = %v", err)
+	}
+}
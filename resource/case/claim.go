@@ -0,0 +1,133 @@
+package caseresource
+
+import (
+	"fmt"
+
+	agentops "github.com/gh-xj/agentops"
+	"github.com/gh-xj/agentops/resource"
+	slotresource "github.com/gh-xj/agentops/resource/slot"
+)
+
+// unclaimed is the claimed_by value of a case no slot owns.
+const unclaimed = "none"
+
+var _ resource.Claimer = (*CaseResource)(nil)
+
+// currentSlot returns the slot the caller runs in, or "" outside any slot.
+// ctx.Values["slot"] overrides detection from the project root.
+func (cr *CaseResource) currentSlot(ctx *agentops.AppContext) (string, error) {
+	if ctx != nil {
+		if v, ok := ctx.Values["slot"]; ok {
+			if slot, ok := v.(string); ok {
+				return slot, nil
+			}
+		}
+	}
+	return slotresource.DetectSlot(cr.fs, cr.strat.Root)
+}
+
+// owner returns the slot that owns a case, or "" when it is unclaimed.
+func owner(fm Frontmatter) string {
+	if fm.ClaimedBy == unclaimed {
+		return ""
+	}
+	return fm.ClaimedBy
+}
+
+// claimConflict reports that slot cannot act on a case held by another slot.
+func claimConflict(id, holder, slot string) error {
+	return agentops.NewCLIError(agentops.ExitClaimConflict, "claim_conflict",
+		fmt.Sprintf("case %q is claimed by slot %q, not %s; halt or use --force", id, holder, slotLabel(slot)), nil)
+}
+
+// checkOwner refuses to act on a case claimed by a slot other than the caller's.
+func (cr *CaseResource) checkOwner(ctx *agentops.AppContext, id string, fm Frontmatter) error {
+	holder := owner(fm)
+	if holder == "" {
+		return nil
+	}
+	slot, err := cr.currentSlot(ctx)
+	if err != nil {
+		return fmt.Errorf("detect slot: %w", err)
+	}
+	if slot != holder {
+		return claimConflict(id, holder, slot)
+	}
+	return nil
+}
+
+// Claim assigns a case to the caller's slot. Claiming a case held by another
+// slot fails unless force is set, in which case the takeover is logged.
+func (cr *CaseResource) Claim(ctx *agentops.AppContext, id string, force bool) (*resource.Record, error) {
+	if cr.strat == nil {
+		return nil, fmt.Errorf("no strategy loaded")
+	}
+	slot, err := cr.currentSlot(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("detect slot: %w", err)
+	}
+	if slot == "" {
+		return nil, agentops.NewCLIError(agentops.ExitUsage, "no_slot", "case claim must run inside a slot", nil)
+	}
+
+	return cr.updateCase(ctx, id, "claim", func(fm *Frontmatter, body string) (string, error) {
+		holder := owner(*fm)
+		if holder != "" && holder != slot {
+			if !force {
+				return "", claimConflict(id, holder, slot)
+			}
+			body = appendLog(body, fmt.Sprintf("claim forced by slot %s (was %s)", slot, holder))
+		}
+		fm.ClaimedBy = slot
+		return body, nil
+	})
+}
+
+// Release clears the caller's claim on a case. Releasing another slot's claim
+// fails unless force is set, in which case the release is logged.
+func (cr *CaseResource) Release(ctx *agentops.AppContext, id string, force bool) (*resource.Record, error) {
+	if cr.strat == nil {
+		return nil, fmt.Errorf("no strategy loaded")
+	}
+	slot, err := cr.currentSlot(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("detect slot: %w", err)
+	}
+
+	return cr.updateCase(ctx, id, "release", func(fm *Frontmatter, body string) (string, error) {
+		holder := owner(*fm)
+		if holder != "" && holder != slot {
+			if !force {
+				return "", claimConflict(id, holder, slot)
+			}
+			body = appendLog(body, fmt.Sprintf("release forced by %s (was %s)", slotLabel(slot), holder))
+		}
+		fm.ClaimedBy = unclaimed
+		return body, nil
+	})
+}
+
+// slotLabel names a slot in messages, including the caller outside any slot.
+func slotLabel(slot string) string {
+	if slot == "" {
+		return "the main checkout"
+	}
+	return "slot " + slot
+}
+
+// ClaimedCase returns the case claimed by the caller's slot, or "" when the
+// caller runs outside a slot or its slot claims no case or several.
+func (cr *CaseResource) ClaimedCase(ctx *agentops.AppContext) (string, error) {
+	if cr.strat == nil {
+		return "", fmt.Errorf("no strategy loaded")
+	}
+	slot, err := cr.currentSlot(ctx)
+	if err != nil || slot == "" {
+		return "", err
+	}
+	recs, err := cr.List(ctx, resource.Filter{resource.Equals("claimed_by", slot)})
+	if err != nil || len(recs) != 1 {
+		return "", err
+	}
+	return recs[0].ID, nil
+}
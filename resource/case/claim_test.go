@@ -0,0 +1,117 @@
+package caseresource
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	agentops "github.com/gh-xj/agentops"
+	"github.com/gh-xj/agentops/dal"
+)
+
+// slotCtx returns a context that reports the caller as running in slot.
+func slotCtx(slot string) *agentops.AppContext {
+	ctx := testCtx()
+	ctx.Values["slot"] = slot
+	return ctx
+}
+
+func TestCaseResourceClaimAndRelease(t *testing.T) {
+	_, strat := setupTestProject(t)
+	cr := New(dal.NewFileSystem(), dal.NewExecutor(), strat)
+
+	created, err := cr.Create(testCtx(), "claim-me", nil)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	claimed, err := cr.Claim(slotCtx("alpha"), created.ID, false)
+	if err != nil {
+		t.Fatalf("Claim: %v", err)
+	}
+	if claimed.Fields["claimed_by"] != "alpha" {
+		t.Errorf("claimed_by = %v, want alpha", claimed.Fields["claimed_by"])
+	}
+	// Claiming again from the owning slot is a no-op.
+	if _, err := cr.Claim(slotCtx("alpha"), created.ID, false); err != nil {
+		t.Errorf("re-claim by owner: %v", err)
+	}
+
+	released, err := cr.Release(slotCtx("alpha"), created.ID, false)
+	if err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if released.Fields["claimed_by"] != "none" {
+		t.Errorf("claimed_by = %v, want none", released.Fields["claimed_by"])
+	}
+}
+
+func TestCaseResourceClaimConflict(t *testing.T) {
+	_, strat := setupTestProject(t)
+	cr := New(dal.NewFileSystem(), dal.NewExecutor(), strat)
+
+	created, err := cr.Create(testCtx(), "contested", nil)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if _, err := cr.Claim(slotCtx("alpha"), created.ID, false); err != nil {
+		t.Fatalf("Claim alpha: %v", err)
+	}
+
+	for name, op := range map[string]func() error{
+		"claim":   func() error { _, err := cr.Claim(slotCtx("beta"), created.ID, false); return err },
+		"release": func() error { _, err := cr.Release(slotCtx("beta"), created.ID, false); return err },
+		"transition": func() error {
+			_, err := cr.Transition(slotCtx("beta"), created.ID, "start")
+			return err
+		},
+	} {
+		err := op()
+		if code := agentops.ResolveExitCode(err); code != agentops.ExitClaimConflict {
+			t.Errorf("%s by other slot: exit code = %d (%v), want %d", name, code, err, agentops.ExitClaimConflict)
+		}
+	}
+
+	// The owning slot may transition.
+	if _, err := cr.Transition(slotCtx("alpha"), created.ID, "start"); err != nil {
+		t.Fatalf("transition by owner: %v", err)
+	}
+
+	forced, err := cr.Claim(slotCtx("beta"), created.ID, true)
+	if err != nil {
+		t.Fatalf("forced Claim: %v", err)
+	}
+	if forced.Fields["claimed_by"] != "beta" {
+		t.Errorf("claimed_by = %v, want beta", forced.Fields["claimed_by"])
+	}
+	data, err := os.ReadFile(forced.RawPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "claim forced by slot beta (was alpha)") {
+		t.Errorf("forced claim should be logged, got:\n%s", data)
+	}
+
+	if _, err := cr.Release(slotCtx(""), created.ID, true); err != nil {
+		t.Fatalf("forced Release: %v", err)
+	}
+	data, _ = os.ReadFile(forced.RawPath)
+	if !strings.Contains(string(data), "release forced by the main checkout (was beta)") {
+		t.Errorf("forced release should be logged, got:\n%s", data)
+	}
+}
+
+func TestCaseResourceClaimOutsideSlot(t *testing.T) {
+	_, strat := setupTestProject(t)
+	cr := New(dal.NewFileSystem(), dal.NewExecutor(), strat)
+
+	created, err := cr.Create(testCtx(), "no-slot", nil)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	// The temp project root is not a slot copy.
+	_, err = cr.Claim(testCtx(), created.ID, false)
+	if code := agentops.ResolveExitCode(err); code != agentops.ExitUsage {
+		t.Errorf("exit code = %d (%v), want %d", code, err, agentops.ExitUsage)
+	}
+}
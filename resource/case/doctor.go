@@ -0,0 +1,174 @@
+package caseresource
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	agentops "github.com/gh-xj/agentops"
+	"github.com/gh-xj/agentops/resource"
+	slotresource "github.com/gh-xj/agentops/resource/slot"
+)
+
+var _ resource.Doctor = (*CaseResource)(nil)
+
+// Doctor checks the working set for cases that need attention: cases left in
+// a status longer than stale_after in budget.yaml allows, claims held by
+// slots that no longer exist, statuses transitions.yaml does not define, and
+// slugs used by more than one case. Every finding names a command that fixes
+// it.
+func (cr *CaseResource) Doctor(ctx *agentops.AppContext) ([]resource.DoctorCheck, error) {
+	if cr.strat == nil {
+		return nil, fmt.Errorf("no strategy loaded")
+	}
+	staleAfter, err := cr.strat.StaleAfter()
+	if err != nil {
+		return nil, fmt.Errorf("budget.yaml: %w", err)
+	}
+	cases, err := cr.cases.Cases()
+	if err != nil {
+		return nil, err
+	}
+
+	var checks []resource.DoctorCheck
+	slots, err := cr.slotNames(ctx)
+	if err != nil {
+		checks = append(checks, resource.DoctorCheck{
+			Name:     "slots",
+			Status:   "check_error",
+			Message:  fmt.Sprintf("cannot list slots to check claims: %v", err),
+			Severity: "warn",
+		})
+	}
+
+	statuses := cr.sm.AllStatuses()
+	sort.Strings(statuses)
+	known := map[string]bool{}
+	for _, s := range statuses {
+		known[s] = true
+	}
+
+	now := time.Now()
+	bySlug := map[string][]StoredCase{}
+	for _, c := range cases {
+		status := c.Frontmatter.Status
+		caseMDPath := filepath.Join(c.Dir, "case.md")
+
+		if !known[status] {
+			checks = append(checks, resource.DoctorCheck{
+				Name:     c.ID,
+				Status:   "unknown_status",
+				Message:  fmt.Sprintf("status %q is not defined in transitions.yaml", status),
+				Severity: "err",
+				Fix:      fmt.Sprintf("$EDITOR %s  # set status to one of: %s", caseMDPath, strings.Join(statuses, ", ")),
+			})
+		} else if limit := staleAfter[status]; limit > 0 {
+			since, err := cr.statusChangedAt(CaseLocation{ID: c.ID, Dir: c.Dir})
+			if err == nil && now.Sub(since) > limit {
+				checks = append(checks, resource.DoctorCheck{
+					Name:     c.ID,
+					Status:   "stale",
+					Message:  fmt.Sprintf("%s for %s (limit %s)", status, formatAge(now.Sub(since)), formatAge(limit)),
+					Severity: "warn",
+					Fix:      fmt.Sprintf("agentops case transition %s <%s>", c.ID, strings.Join(cr.sm.ActionsFrom(status), "|")),
+				})
+			}
+		}
+
+		if holder := owner(c.Frontmatter); holder != "" && slots != nil && !slots[holder] {
+			checks = append(checks, resource.DoctorCheck{
+				Name:     c.ID,
+				Status:   "orphaned_claim",
+				Message:  fmt.Sprintf("claimed by slot %q, which no longer exists", holder),
+				Severity: "warn",
+				Fix:      fmt.Sprintf("agentops case release %s --force", c.ID),
+			})
+		}
+
+		slug := caseSlug(c.ID)
+		bySlug[slug] = append(bySlug[slug], c)
+	}
+
+	// The oldest case keeps the slug; later ones are reported as duplicates.
+	for _, slug := range sortedKeys(bySlug) {
+		dups := bySlug[slug]
+		if len(dups) < 2 {
+			continue
+		}
+		sort.Slice(dups, func(i, j int) bool { return dups[i].ID < dups[j].ID })
+		for _, c := range dups[1:] {
+			checks = append(checks, resource.DoctorCheck{
+				Name:     c.ID,
+				Status:   "duplicate_slug",
+				Message:  fmt.Sprintf("slug %q is also used by %s", slug, dups[0].ID),
+				Severity: "warn",
+				Fix:      cr.duplicateFix(c, dups[0].ID),
+			})
+		}
+	}
+
+	if len(checks) == 0 {
+		checks = append(checks, resource.DoctorCheck{
+			Name:     "cases",
+			Status:   "ok",
+			Message:  fmt.Sprintf("%d cases checked, no problems found", len(cases)),
+			Severity: "ok",
+		})
+	}
+	return checks, nil
+}
+
+// slotNames returns the slots that currently exist, according to the slot
+// resource.
+func (cr *CaseResource) slotNames(ctx *agentops.AppContext) (map[string]bool, error) {
+	var parent context.Context
+	if ctx != nil {
+		parent = ctx.Context
+	}
+	slotCtx := agentops.NewAppContext(parent)
+	slotCtx.Values["project_dir"] = cr.strat.Root
+	records, err := slotresource.New(cr.fs, cr.exec).List(slotCtx, nil)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]bool, len(records))
+	for _, r := range records {
+		names[r.ID] = true
+	}
+	return names, nil
+}
+
+// duplicateFix closes a duplicate case, or archives it when it is already
+// completed. When no action can close it, it is linked to the original.
+func (cr *CaseResource) duplicateFix(c StoredCase, original string) string {
+	if cr.sm.CategoryForStatus(c.Frontmatter.Status) == "completed" {
+		return fmt.Sprintf("agentops case archive %s", c.ID)
+	}
+	for _, action := range cr.sm.ActionsFrom(c.Frontmatter.Status) {
+		if cr.sm.CategoryForStatus(cr.sm.Target(action)) == "completed" {
+			return fmt.Sprintf("agentops case transition %s %s --reason %q", c.ID, action, "duplicate of "+original)
+		}
+	}
+	return fmt.Sprintf("agentops case link %s --relates-to %s", c.ID, original)
+}
+
+// caseSlug returns the slug part of a CASE-YYYYMMDD-<slug> ID.
+func caseSlug(id string) string {
+	parts := strings.SplitN(id, "-", 3)
+	if len(parts) < 3 {
+		return id
+	}
+	return parts[2]
+}
+
+// formatAge renders d in the <n>d notation of stale_after once it spans two
+// days or more.
+func formatAge(d time.Duration) string {
+	if d >= 48*time.Hour {
+		return fmt.Sprintf("%dd", int(d/(24*time.Hour)))
+	}
+	return d.Round(time.Minute).String()
+}
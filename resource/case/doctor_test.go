@@ -0,0 +1,151 @@
+package caseresource
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gh-xj/agentops/dal"
+	"github.com/gh-xj/agentops/resource"
+	slotresource "github.com/gh-xj/agentops/resource/slot"
+)
+
+// backdateHistory rewrites the timestamp of the last entry in a case's
+// history.jsonl to age ago.
+func backdateHistory(t *testing.T, rec *resource.Record, age time.Duration) {
+	t.Helper()
+	historyPath := filepath.Join(filepath.Dir(rec.RawPath), historyFile)
+	data, err := os.ReadFile(historyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	last := lines[len(lines)-1]
+	start := strings.Index(last, `"timestamp":"`) + len(`"timestamp":"`)
+	end := start + strings.Index(last[start:], `"`)
+	stamp := time.Now().UTC().Add(-age).Format(time.RFC3339)
+	lines[len(lines)-1] = last[:start] + stamp + last[end:]
+	if err := os.WriteFile(historyPath, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// findCheck returns the doctor check of status reported for name.
+func findCheck(checks []resource.DoctorCheck, name, status string) (resource.DoctorCheck, bool) {
+	for _, c := range checks {
+		if c.Name == name && c.Status == status {
+			return c, true
+		}
+	}
+	return resource.DoctorCheck{}, false
+}
+
+func TestCaseResourceDoctor(t *testing.T) {
+	root, strat := setupTestProject(t)
+	cr := New(dal.NewFileSystem(), dal.NewExecutor(), strat)
+	ctx := testCtx()
+
+	// One slot copy exists next to the project; "gone" does not.
+	cfg, err := slotresource.LoadSlotConfig(dal.NewFileSystem(), filepath.Join(root, ".agentops"), root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(filepath.Dir(root), cfg.CopyPrefix+"-alpha", ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	ids := createCases(t, cr, "stale", "fresh", "orphan", "claimed", "weird")
+	stale, fresh, orphan, claimed, weird := ids[0], ids[1], ids[2], ids[3], ids[4]
+	for _, id := range []string{stale, fresh} {
+		if _, err := cr.Transition(ctx, id, "start"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rec, err := cr.Get(ctx, stale)
+	if err != nil {
+		t.Fatal(err)
+	}
+	backdateHistory(t, rec, 10*24*time.Hour)
+
+	if _, err := cr.Claim(slotCtx("gone"), orphan, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cr.Claim(slotCtx("alpha"), claimed, false); err != nil {
+		t.Fatal(err)
+	}
+
+	rec, err = cr.Get(ctx, weird)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(rec.RawPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(rec.RawPath, []byte(strings.Replace(string(data), "status: open", "status: limbo", 1)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// A case from an earlier day reuses the slug "fresh".
+	dupID := "CASE-20000101-fresh"
+	dupDir := filepath.Join(root, "cases", dupID)
+	if err := os.MkdirAll(dupDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dupDir, "case.md"), []byte("---\nid: "+dupID+"\ntype: bug\nstatus: open\nclaimed_by: none\ncreated: 2000-01-01\n---\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	checks, err := cr.Doctor(ctx)
+	if err != nil {
+		t.Fatalf("Doctor: %v", err)
+	}
+
+	want := []struct {
+		name, status, message, fix string
+	}{
+		{stale, "stale", "in_progress for 10d (limit 7d)", "agentops case transition " + stale + " <block|resolve>"},
+		{orphan, "orphaned_claim", `slot "gone"`, "agentops case release " + orphan + " --force"},
+		{weird, "unknown_status", `status "limbo"`, "set status to one of: blocked, closed_no_action, in_progress, open, resolved"},
+		{fresh, "duplicate_slug", "also used by " + dupID, "agentops case transition " + fresh + ` resolve --reason "duplicate of ` + dupID + `"`},
+	}
+	for _, w := range want {
+		c, ok := findCheck(checks, w.name, w.status)
+		if !ok {
+			t.Errorf("no %s check for %s in %+v", w.status, w.name, checks)
+			continue
+		}
+		if !strings.Contains(c.Message, w.message) {
+			t.Errorf("%s message = %q, want it to contain %q", w.status, c.Message, w.message)
+		}
+		if !strings.Contains(c.Fix, w.fix) {
+			t.Errorf("%s fix = %q, want it to contain %q", w.status, c.Fix, w.fix)
+		}
+	}
+	for _, id := range []string{fresh, claimed} {
+		for _, status := range []string{"stale", "orphaned_claim"} {
+			if _, ok := findCheck(checks, id, status); ok {
+				t.Errorf("unexpected %s check for %s", status, id)
+			}
+		}
+	}
+	if _, ok := findCheck(checks, dupID, "duplicate_slug"); ok {
+		t.Error("the oldest case keeps its slug and should not be reported")
+	}
+}
+
+func TestCaseResourceDoctorHealthy(t *testing.T) {
+	_, strat := setupTestProject(t)
+	cr := New(dal.NewFileSystem(), dal.NewExecutor(), strat)
+	createCases(t, cr, "one", "two")
+
+	checks, err := cr.Doctor(testCtx())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(checks) != 1 || checks[0].Severity != "ok" || !strings.Contains(checks[0].Message, "2 cases checked") {
+		t.Errorf("checks = %+v, want a single ok check", checks)
+	}
+}
@@ -1,9 +1,11 @@
 package caseresource
 
 import (
+	"bytes"
 	"fmt"
 	"strings"
 
+	"github.com/gh-xj/agentops/resource"
 	"gopkg.in/yaml.v3"
 )
 
@@ -13,8 +15,15 @@ type Frontmatter struct {
 	Status    string `yaml:"status"`
 	ClaimedBy string `yaml:"claimed_by"`
 	Created   string `yaml:"created"`
+
+	// doc is the parsed YAML document. It is kept so that keys other than
+	// the four above, comments and key order survive a render.
+	doc *yaml.Node
 }
 
+// coreKeys are the frontmatter keys mapped to Frontmatter's typed fields.
+var coreKeys = []string{"type", "status", "claimed_by", "created"}
+
 // ParseFrontmatter extracts YAML frontmatter from case.md content.
 // Returns the parsed frontmatter and the remaining body content.
 func ParseFrontmatter(content string) (Frontmatter, string, error) {
@@ -32,14 +41,36 @@ func ParseFrontmatter(content string) (Frontmatter, string, error) {
 	if strings.HasPrefix(body, "\n") {
 		body = body[1:]
 	}
-	if err := yaml.Unmarshal([]byte(yamlBlock), &fm); err != nil {
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(yamlBlock), &doc); err != nil {
 		return fm, content, fmt.Errorf("parse frontmatter: %w", err)
 	}
+	if len(doc.Content) == 0 {
+		return fm, body, nil
+	}
+	mapping := doc.Content[0]
+	if mapping.Kind != yaml.MappingNode {
+		return fm, content, fmt.Errorf("parse frontmatter: expected a mapping")
+	}
+	if err := mapping.Decode(&fm); err != nil {
+		return fm, content, fmt.Errorf("parse frontmatter: %w", err)
+	}
+	fm.doc = &doc
 	return fm, body, nil
 }
 
 // RenderFrontmatter renders a Frontmatter as a YAML frontmatter block.
+// Frontmatter returned by ParseFrontmatter is rendered from its parsed
+// document with the typed fields updated in place, so other keys, comments
+// and ordering are preserved.
 func RenderFrontmatter(fm Frontmatter) string {
+	if fm.doc != nil {
+		if out, err := fm.renderDoc(); err == nil {
+			return out
+		}
+	}
+
 	var b strings.Builder
 	b.WriteString("---\n")
 	b.WriteString("type: " + fm.Type + "\n")
@@ -50,3 +81,242 @@ func RenderFrontmatter(fm Frontmatter) string {
 	b.WriteString("---\n")
 	return b.String()
 }
+
+// renderDoc encodes a copy of the parsed document with the typed fields set.
+func (fm Frontmatter) renderDoc() (string, error) {
+	doc := cloneNode(fm.doc)
+	mapping := doc.Content[0]
+	setScalar(mapping, "type", fm.Type, 0)
+	setScalar(mapping, "status", fm.Status, 0)
+	setScalar(mapping, "claimed_by", fm.ClaimedBy, 0)
+	// Quote created to prevent YAML date parsing.
+	setScalar(mapping, "created", fm.Created, yaml.DoubleQuotedStyle)
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return "", err
+	}
+	if err := enc.Close(); err != nil {
+		return "", err
+	}
+	return "---\n" + buf.String() + "---\n", nil
+}
+
+// Keys returns the frontmatter keys in document order.
+func (fm Frontmatter) Keys() []string {
+	if fm.doc == nil {
+		return append([]string(nil), coreKeys...)
+	}
+	mapping := fm.doc.Content[0]
+	keys := make([]string, 0, len(mapping.Content)/2)
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		keys = append(keys, mapping.Content[i].Value)
+	}
+	return keys
+}
+
+// Extra returns the frontmatter keys other than the typed fields, decoded to
+// plain Go values. Timestamps are kept as written.
+func (fm Frontmatter) Extra() map[string]any {
+	extra := map[string]any{}
+	if fm.doc == nil {
+		return extra
+	}
+	mapping := fm.doc.Content[0]
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		key := mapping.Content[i].Value
+		if isCoreKey(key) {
+			continue
+		}
+		extra[key] = nodeValue(mapping.Content[i+1])
+	}
+	return extra
+}
+
+// setScalar sets key to a string value in mapping, appending the key when it
+// is missing. style 0 keeps the existing value's quoting.
+func setScalar(mapping *yaml.Node, key, value string, style yaml.Style) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value != key {
+			continue
+		}
+		v := mapping.Content[i+1]
+		if v.Kind != yaml.ScalarNode {
+			*v = yaml.Node{Kind: yaml.ScalarNode}
+		}
+		v.Tag = "!!str"
+		v.Value = value
+		if style != 0 {
+			v.Style = style
+		}
+		return
+	}
+	mapping.Content = append(mapping.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value, Style: style},
+	)
+}
+
+// nodeValue decodes a YAML node to a plain Go value, keeping timestamps as
+// their original text rather than time.Time.
+func nodeValue(n *yaml.Node) any {
+	if n.Kind == yaml.ScalarNode && (n.Tag == "!!timestamp" || n.ShortTag() == "!!timestamp") {
+		return n.Value
+	}
+	var v any
+	if err := n.Decode(&v); err != nil {
+		return n.Value
+	}
+	return v
+}
+
+// cloneNode deep-copies a YAML node tree.
+func cloneNode(n *yaml.Node) *yaml.Node {
+	if n == nil {
+		return nil
+	}
+	c := *n
+	c.Content = make([]*yaml.Node, len(n.Content))
+	for i, child := range n.Content {
+		c.Content[i] = cloneNode(child)
+	}
+	return &c
+}
+
+func isCoreKey(key string) bool {
+	for _, k := range coreKeys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldType names the schema type of a frontmatter value node.
+func fieldType(n *yaml.Node) string {
+	switch n.Kind {
+	case yaml.SequenceNode:
+		return "list"
+	case yaml.MappingNode:
+		return "map"
+	}
+	switch n.ShortTag() {
+	case "!!int":
+		return "int"
+	case "!!float":
+		return "float"
+	case "!!bool":
+		return "bool"
+	}
+	return "string"
+}
+
+// ExtraFields describes the non-core keys of fm in document order, typed by
+// their values.
+func (fm Frontmatter) ExtraFields() []resource.FieldDef {
+	if fm.doc == nil {
+		return nil
+	}
+	mapping := fm.doc.Content[0]
+	var defs []resource.FieldDef
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		key := mapping.Content[i].Value
+		if isCoreKey(key) || key == "id" {
+			continue
+		}
+		defs = append(defs, resource.FieldDef{Name: key, Type: fieldType(mapping.Content[i+1])})
+	}
+	return defs
+}
+
+// Strings returns the value of key as a list: the scalars of a sequence, or a
+// single non-empty scalar. It returns nil when key is unset or empty.
+func (fm Frontmatter) Strings(key string) []string {
+	if fm.doc == nil {
+		return nil
+	}
+	v := lookup(fm.doc.Content[0], key)
+	if v == nil {
+		return nil
+	}
+	switch v.Kind {
+	case yaml.ScalarNode:
+		if s := strings.TrimSpace(v.Value); s != "" && v.ShortTag() != "!!null" {
+			return []string{s}
+		}
+	case yaml.SequenceNode:
+		var out []string
+		for _, item := range v.Content {
+			if s := strings.TrimSpace(item.Value); item.Kind == yaml.ScalarNode && s != "" {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// SetString sets a non-core key to a scalar string. An empty value removes
+// the key.
+func (fm *Frontmatter) SetString(key, value string) {
+	mapping := fm.mapping()
+	if value == "" {
+		removeKey(mapping, key)
+		return
+	}
+	setScalar(mapping, key, value, 0)
+}
+
+// SetStrings sets a non-core key to a flow sequence of values. An empty list
+// removes the key.
+func (fm *Frontmatter) SetStrings(key string, values []string) {
+	mapping := fm.mapping()
+	if len(values) == 0 {
+		removeKey(mapping, key)
+		return
+	}
+	seq := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Style: yaml.FlowStyle}
+	for _, v := range values {
+		seq.Content = append(seq.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v})
+	}
+	if v := lookup(mapping, key); v != nil {
+		*v = *seq
+		return
+	}
+	mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, seq)
+}
+
+// mapping returns the frontmatter mapping node, creating a document holding
+// the typed fields when fm was not parsed from one.
+func (fm *Frontmatter) mapping() *yaml.Node {
+	if fm.doc == nil {
+		mapping := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		for _, k := range coreKeys {
+			setScalar(mapping, k, "", 0)
+		}
+		fm.doc = &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{mapping}}
+	}
+	return fm.doc.Content[0]
+}
+
+// lookup returns the value node of key in mapping, or nil.
+func lookup(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// removeKey deletes key and its value from mapping.
+func removeKey(mapping *yaml.Node, key string) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			mapping.Content = append(mapping.Content[:i], mapping.Content[i+2:]...)
+			return
+		}
+	}
+}
@@ -115,3 +115,94 @@ func TestRenderThenParse(t *testing.T) {
 		t.Errorf("body = %q, want %q", body, "# Body\n")
 	}
 }
+
+func TestRenderPreservesCustomKeysCommentsAndOrder(t *testing.T) {
+	content := "---\n# triage metadata\npriority: 2\ntype: pr\nlinear_ref: ENG-42 # tracker\nstatus: open\nlabels: [infra, flaky]\nclaimed_by: none\ncreated: \"20260101\"\ndue: 2026-02-01\n---\n# Body\n"
+
+	fm, body, err := ParseFrontmatter(content)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	fm.Status = "in_progress"
+	rendered := RenderFrontmatter(fm) + body
+
+	want := "---\n# triage metadata\npriority: 2\ntype: pr\nlinear_ref: ENG-42 # tracker\nstatus: in_progress\nlabels: [infra, flaky]\nclaimed_by: none\ncreated: \"20260101\"\ndue: 2026-02-01\n---\n# Body\n"
+	if rendered != want {
+		t.Errorf("round-trip mismatch:\ngot:\n%s\nwant:\n%s", rendered, want)
+	}
+
+	// Rendering must not mutate the parsed document: a second render gives
+	// the same text, and so does rendering a fresh parse of the output.
+	if again := RenderFrontmatter(fm) + body; again != want {
+		t.Errorf("second render:\ngot:\n%s\nwant:\n%s", again, want)
+	}
+	fresh, freshBody, err := ParseFrontmatter(want)
+	if err != nil {
+		t.Fatalf("parse rendered: %v", err)
+	}
+	if again := RenderFrontmatter(fresh) + freshBody; again != want {
+		t.Errorf("render of a fresh parse:\ngot:\n%s\nwant:\n%s", again, want)
+	}
+}
+
+func TestFrontmatterExtra(t *testing.T) {
+	content := "---\ntype: pr\nstatus: open\nclaimed_by: none\ncreated: \"20260101\"\npriority: 2\nrisk: high\ndue: 2026-02-01\nlabels: [a, b]\n---\n"
+	fm, _, err := ParseFrontmatter(content)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	extra := fm.Extra()
+	if len(extra) != 4 {
+		t.Fatalf("expected 4 extra keys, got %v", extra)
+	}
+	if extra["priority"] != 2 || extra["risk"] != "high" || extra["due"] != "2026-02-01" {
+		t.Errorf("unexpected extra values: %#v", extra)
+	}
+	if labels, ok := extra["labels"].([]any); !ok || len(labels) != 2 {
+		t.Errorf("labels = %#v", extra["labels"])
+	}
+
+	var names []string
+	for _, f := range fm.ExtraFields() {
+		names = append(names, f.Name+":"+f.Type)
+	}
+	if got := strings.Join(names, ","); got != "priority:int,risk:string,due:string,labels:list" {
+		t.Errorf("ExtraFields = %s", got)
+	}
+}
+
+func TestFrontmatterSetStrings(t *testing.T) {
+	fm := Frontmatter{Type: "intake", Status: "open", ClaimedBy: "none", Created: "20260321"}
+	fm.SetStrings("blocked_by", []string{"CASE-a", "CASE-b"})
+	fm.SetString("parent", "CASE-p")
+
+	out := RenderFrontmatter(fm)
+	want := "---\ntype: intake\nstatus: open\nclaimed_by: none\ncreated: \"20260321\"\nblocked_by: [CASE-a, CASE-b]\nparent: CASE-p\n---\n"
+	if out != want {
+		t.Fatalf("render =\n%s\nwant\n%s", out, want)
+	}
+
+	parsed, _, err := ParseFrontmatter(out + "body\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(parsed.Strings("blocked_by"), ","); got != "CASE-a,CASE-b" {
+		t.Errorf("Strings(blocked_by) = %s", got)
+	}
+	if got := parsed.Strings("parent"); len(got) != 1 || got[0] != "CASE-p" {
+		t.Errorf("Strings(parent) = %v", got)
+	}
+
+	// Clear the keys on a fresh parse, so the check does not depend on the
+	// document the assertions above read.
+	cleared, _, err := ParseFrontmatter(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cleared.SetStrings("blocked_by", nil)
+	cleared.SetString("parent", "")
+	want = "---\ntype: intake\nstatus: open\nclaimed_by: none\ncreated: \"20260321\"\n---\n"
+	if got := RenderFrontmatter(cleared); got != want {
+		t.Errorf("empty values should remove their keys:\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,156 @@
+package caseresource
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	workerresource "github.com/gh-xj/agentops/resource/worker"
+	"github.com/gh-xj/agentops/strategy"
+)
+
+// GuardSubject is the parsed case a transition's guards are evaluated against.
+type GuardSubject struct {
+	Status string
+	Fields map[string]string // frontmatter values
+	Body   string            // markdown after the frontmatter
+	// Workers lists blocking workers and whether their sidecar exists. It is
+	// only consulted by the blocking_workers guard.
+	Workers []WorkerSidecar
+	// Blockers lists the blocked_by cases that are still active. It is only
+	// consulted by the blockers guard.
+	Blockers []CaseBlocker
+}
+
+// WorkerSidecar records whether a blocking worker has written its sidecar.
+type WorkerSidecar struct {
+	Name    string
+	Path    string // relative to the case directory
+	Present bool
+}
+
+// CaseBlocker is a blocked_by case that has not finished.
+type CaseBlocker struct {
+	ID     string
+	Status string // its status, or "missing" when it cannot be found
+}
+
+// GuardError lists every unmet guard of a denied transition.
+type GuardError struct {
+	Action string
+	Unmet  []string
+}
+
+func (e *GuardError) Error() string {
+	return fmt.Sprintf("%d unmet guard(s): %s", len(e.Unmet), strings.Join(e.Unmet, "; "))
+}
+
+// evaluateGuards returns a description of each guard subj does not satisfy.
+func evaluateGuards(g strategy.TransitionGuards, subj GuardSubject) []string {
+	var unmet []string
+	for _, field := range g.Fields {
+		if v := strings.TrimSpace(subj.Fields[field]); v == "" || v == unclaimed {
+			unmet = append(unmet, fmt.Sprintf("field %s must be set", field))
+		}
+	}
+	for _, section := range g.Sections {
+		heading := normalizeHeading(section)
+		if !sectionHasContent(subj.Body, heading) {
+			unmet = append(unmet, fmt.Sprintf("section %q must have content", heading))
+		}
+	}
+	if g.BlockingWorkers {
+		for _, w := range subj.Workers {
+			if !w.Present {
+				unmet = append(unmet, fmt.Sprintf("blocking worker %s has not written %s", w.Name, w.Path))
+			}
+		}
+	}
+	if g.Blockers {
+		for _, b := range subj.Blockers {
+			unmet = append(unmet, fmt.Sprintf("blocked by %s (%s)", b.ID, b.Status))
+		}
+	}
+	return unmet
+}
+
+// normalizeHeading turns "Close Criteria" into "## Close Criteria"; headings
+// that already start with '#' are kept as written.
+func normalizeHeading(s string) string {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "#") {
+		return s
+	}
+	return "## " + s
+}
+
+// headingLevel returns the number of leading '#' of a markdown heading line,
+// or 0 when the line is not a heading.
+func headingLevel(line string) int {
+	n := len(line) - len(strings.TrimLeft(line, "#"))
+	if n == 0 || n >= len(line) || line[n] != ' ' {
+		return 0
+	}
+	return n
+}
+
+// sectionHasContent reports whether the section under heading contains any
+// text other than blank lines and HTML comments.
+func sectionHasContent(body, heading string) bool {
+	content, ok := sectionContent(body, heading)
+	if !ok {
+		return false
+	}
+	for _, line := range strings.Split(content, "\n") {
+		text := strings.TrimSpace(line)
+		if text == "" || (strings.HasPrefix(text, "<!--") && strings.HasSuffix(text, "-->")) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// guardSubject builds the subject for evaluating action's guards. Workers are
+// only discovered when the action has a blocking_workers guard.
+func (cr *CaseResource) guardSubject(caseMDPath string, fm Frontmatter, body, action string) (GuardSubject, error) {
+	subj := GuardSubject{
+		Status: fm.Status,
+		Fields: map[string]string{
+			"type":       fm.Type,
+			"status":     fm.Status,
+			"claimed_by": fm.ClaimedBy,
+			"created":    fm.Created,
+		},
+		Body: body,
+	}
+	for k, v := range fm.Extra() {
+		if v != nil {
+			subj.Fields[k] = fmt.Sprint(v)
+		}
+	}
+	guards := cr.sm.Guards(action)
+	if guards.Blockers {
+		subj.Blockers = cr.blockers(fm)
+	}
+	if !guards.BlockingWorkers {
+		return subj, nil
+	}
+
+	workers, err := workerresource.Discover(cr.fs, cr.strat.Root)
+	if err != nil {
+		return subj, fmt.Errorf("discover workers: %w", err)
+	}
+	caseDir := filepath.Dir(caseMDPath)
+	for _, w := range workers {
+		if !w.Blocking {
+			continue
+		}
+		subj.Workers = append(subj.Workers, WorkerSidecar{
+			Name:    w.Name,
+			Path:    w.SidecarPath,
+			Present: w.SidecarPath != "" && cr.fs.Exists(filepath.Join(caseDir, w.SidecarPath)),
+		})
+	}
+	return subj, nil
+}
@@ -0,0 +1,144 @@
+package caseresource
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	agentops "github.com/gh-xj/agentops"
+	"github.com/gh-xj/agentops/dal"
+	"github.com/gh-xj/agentops/strategy"
+)
+
+func TestSectionHasContent(t *testing.T) {
+	body := "# Title\n\n## Findings\n\n<!-- fill in -->\n\n## Close Criteria\n\n- tests pass\n\n### Notes\n\n## Empty\n\n### Sub\n\ntext\n"
+	tests := []struct {
+		heading string
+		want    bool
+	}{
+		{"## Findings", false},
+		{"## Close Criteria", true},
+		{"### Notes", false},
+		// Content under a deeper heading counts toward the section.
+		{"## Empty", true},
+		{"## Missing", false},
+	}
+	for _, tt := range tests {
+		if got := sectionHasContent(body, tt.heading); got != tt.want {
+			t.Errorf("sectionHasContent(%q) = %v, want %v", tt.heading, got, tt.want)
+		}
+	}
+}
+
+func TestEvaluateGuards(t *testing.T) {
+	g := strategy.TransitionGuards{
+		Fields:          []string{"type", "claimed_by"},
+		Sections:        []string{"Close Criteria"},
+		BlockingWorkers: true,
+	}
+	subj := GuardSubject{
+		Status: "in_progress",
+		Fields: map[string]string{"type": "pr", "claimed_by": "none"},
+		Body:   "## Close Criteria\n",
+		Workers: []WorkerSidecar{
+			{Name: "reviewer", Path: "review.md", Present: true},
+			{Name: "verifier", Path: "verify.md"},
+		},
+	}
+	got := strings.Join(evaluateGuards(g, subj), "; ")
+	want := `field claimed_by must be set; section "## Close Criteria" must have content; blocking worker verifier has not written verify.md`
+	if got != want {
+		t.Errorf("unmet = %q\nwant    %q", got, want)
+	}
+}
+
+func TestStateMachineApplyGuardDenial(t *testing.T) {
+	cfg := defaultTransitionsConfig()
+	resolve := cfg.Transitions["resolve"]
+	resolve.Guards = strategy.TransitionGuards{Fields: []string{"claimed_by"}, Sections: []string{"## Close Criteria"}}
+	cfg.Transitions["resolve"] = resolve
+	sm := NewStateMachine(cfg)
+
+	_, err := sm.Apply(GuardSubject{Status: "in_progress", Fields: map[string]string{}}, "resolve")
+	if code := agentops.ResolveExitCode(err); code != agentops.ExitTransitionDenied {
+		t.Fatalf("exit code = %d (%v), want %d", code, err, agentops.ExitTransitionDenied)
+	}
+	var guardErr *GuardError
+	if !errors.As(err, &guardErr) {
+		t.Fatalf("expected *GuardError, got %T", err)
+	}
+	if guardErr.Action != "resolve" || len(guardErr.Unmet) != 2 {
+		t.Errorf("unexpected denial: %+v", guardErr)
+	}
+
+	got, err := sm.Apply(GuardSubject{
+		Status: "in_progress",
+		Fields: map[string]string{"claimed_by": "alpha"},
+		Body:   "## Close Criteria\n\nshipped\n",
+	}, "resolve")
+	if err != nil || got != "resolved" {
+		t.Errorf("Apply with guards met = %q, %v", got, err)
+	}
+}
+
+func TestCaseResourceTransitionBlockingWorkerGuard(t *testing.T) {
+	root, _ := setupTestProject(t)
+	transitions := `categories:
+  active: [open, in_progress]
+  completed: [resolved]
+initial: open
+transitions:
+  start:
+    from: open
+    to: in_progress
+  resolve:
+    from: in_progress
+    to: resolved
+    guards:
+      blocking_workers: true
+`
+	if err := os.WriteFile(filepath.Join(root, ".agentops", "transitions.yaml"), []byte(transitions), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	workerDir := filepath.Join(root, ".agentops", "workers", "reviewer")
+	if err := os.MkdirAll(workerDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	skill := "---\nworker-type: review\nsidecar-path: review.md\nblocking: true\n---\n"
+	if err := os.WriteFile(filepath.Join(workerDir, "SKILL.md"), []byte(skill), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	strat, err := strategy.Discover(root)
+	if err != nil {
+		t.Fatalf("discover: %v", err)
+	}
+
+	cr := New(dal.NewFileSystem(), dal.NewExecutor(), strat)
+	ctx := testCtx()
+	created, err := cr.Create(ctx, "guarded", nil)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if _, err := cr.Transition(ctx, created.ID, "start"); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	_, err = cr.Transition(ctx, created.ID, "resolve")
+	if err == nil || !strings.Contains(err.Error(), "blocking worker reviewer has not written review.md") {
+		t.Fatalf("expected blocking worker denial, got %v", err)
+	}
+	got, _ := cr.Get(ctx, created.ID)
+	if got.Fields["status"] != "in_progress" {
+		t.Errorf("denied transition changed status to %v", got.Fields["status"])
+	}
+
+	sidecar := filepath.Join(filepath.Dir(created.RawPath), "review.md")
+	if err := os.WriteFile(sidecar, []byte("LGTM\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cr.Transition(ctx, created.ID, "resolve"); err != nil {
+		t.Fatalf("resolve after sidecar written: %v", err)
+	}
+}
@@ -0,0 +1,175 @@
+package caseresource
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	agentops "github.com/gh-xj/agentops"
+	"github.com/gh-xj/agentops/dal"
+	"github.com/gh-xj/agentops/resource"
+)
+
+// historyFile is the per-case sidecar recording every status change.
+const historyFile = "history.jsonl"
+
+var _ resource.Historian = (*CaseResource)(nil)
+
+// HistoryEntry is one line of history.jsonl.
+type HistoryEntry struct {
+	Timestamp string `json:"timestamp"`
+	Action    string `json:"action"`
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Actor     string `json:"actor"`
+	Slot      string `json:"slot"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// newHistoryEntry builds an entry for an action taken by the caller in ctx.
+// Transitions set the entry's Reason from their options.
+func (cr *CaseResource) newHistoryEntry(ctx *agentops.AppContext, action, from, to string) HistoryEntry {
+	entry := HistoryEntry{
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Action:    action,
+		From:      from,
+		To:        to,
+		Actor:     actor(ctx),
+	}
+	if slot, err := cr.currentSlot(ctx); err == nil {
+		entry.Slot = slot
+	}
+	return entry
+}
+
+// actor identifies who is acting: ctx.Values["actor"], then $AGENTOPS_ACTOR,
+// then $USER.
+func actor(ctx *agentops.AppContext) string {
+	if ctx != nil {
+		if a, ok := ctx.Values["actor"].(string); ok && a != "" {
+			return a
+		}
+	}
+	if a := os.Getenv("AGENTOPS_ACTOR"); a != "" {
+		return a
+	}
+	return os.Getenv("USER")
+}
+
+// appendHistory appends entry to history.jsonl in caseDir.
+func (cr *CaseResource) appendHistory(caseDir string, entry HistoryEntry) error {
+	return cr.appendJSONL(filepath.Join(caseDir, historyFile), entry)
+}
+
+// appendJSONL appends v as one JSON line to the file at path. The line goes
+// out in a single append, so concurrent writers cannot drop each other's
+// entries.
+func (cr *CaseResource) appendJSONL(path string, v any) error {
+	name := filepath.Base(path)
+	line, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("encode %s entry: %w", name, err)
+	}
+	if err := cr.fs.AppendFile(path, append(line, '\n'), 0o644); err != nil {
+		return fmt.Errorf("append %s: %w", name, err)
+	}
+	return nil
+}
+
+// readHistory parses history.jsonl in caseDir. A missing file is an empty history.
+func (cr *CaseResource) readHistory(caseDir string) ([]HistoryEntry, error) {
+	return ReadHistory(cr.fs, caseDir)
+}
+
+// ReadHistory parses history.jsonl in caseDir. A missing file is an empty
+// history.
+func ReadHistory(fs dal.FileSystem, caseDir string) ([]HistoryEntry, error) {
+	return readJSONL[HistoryEntry](fs, filepath.Join(caseDir, historyFile))
+}
+
+// readJSONL parses the JSON lines of the file at path. A missing file has no
+// lines.
+func readJSONL[T any](fs dal.FileSystem, path string) ([]T, error) {
+	if !fs.Exists(path) {
+		return nil, nil
+	}
+	name := filepath.Base(path)
+	data, err := fs.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", name, err)
+	}
+	return parseJSONL[T](name, data)
+}
+
+// parseJSONL parses the JSON lines of the file name holding data.
+func parseJSONL[T any](name string, data []byte) ([]T, error) {
+	var entries []T
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var e T
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", name, n, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+// HistorySchema describes the records returned by History.
+func (cr *CaseResource) HistorySchema() resource.ResourceSchema {
+	return resource.ResourceSchema{
+		Kind:        "case_history",
+		Description: "Status changes recorded in a case's history.jsonl.",
+		Fields: []resource.FieldDef{
+			{Name: "timestamp", Type: "string", Required: true},
+			{Name: "action", Type: "string", Required: true},
+			{Name: "from", Type: "string"},
+			{Name: "to", Type: "string", Required: true},
+			{Name: "actor", Type: "string"},
+			{Name: "slot", Type: "string"},
+			{Name: "reason", Type: "string"},
+		},
+	}
+}
+
+// History returns the recorded status changes of a case, oldest first.
+func (cr *CaseResource) History(ctx *agentops.AppContext, id string) ([]resource.Record, error) {
+	if cr.strat == nil {
+		return nil, fmt.Errorf("no strategy loaded")
+	}
+	loc, err := cr.locate(id)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := cr.cases.History(id)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]resource.Record, 0, len(entries))
+	for i, e := range entries {
+		records = append(records, resource.Record{
+			Kind: "case_history",
+			ID:   fmt.Sprintf("%s#%d", id, i+1),
+			Fields: map[string]any{
+				"timestamp": e.Timestamp,
+				"action":    e.Action,
+				"from":      e.From,
+				"to":        e.To,
+				"actor":     e.Actor,
+				"slot":      e.Slot,
+				"reason":    e.Reason,
+			},
+			RawPath: filepath.Join(loc.Dir, historyFile),
+		})
+	}
+	return records, nil
+}
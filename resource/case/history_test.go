@@ -0,0 +1,129 @@
+package caseresource
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gh-xj/agentops/dal"
+	"github.com/gh-xj/agentops/resource"
+)
+
+func TestCaseResourceHistoryRecordsTransitions(t *testing.T) {
+	_, strat := setupTestProject(t)
+	cr := New(dal.NewFileSystem(), dal.NewExecutor(), strat)
+
+	ctx := slotCtx("alpha")
+	ctx.Values["actor"] = "tester"
+	created, err := cr.Create(ctx, "audited", nil)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if _, err := cr.Transition(ctx, created.ID, "start"); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	if _, err := cr.Transition(ctx, created.ID, "block", resource.WithReason("waiting on upstream")); err != nil {
+		t.Fatalf("block: %v", err)
+	}
+
+	records, err := cr.History(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("expected 3 history records, got %d", len(records))
+	}
+
+	want := []struct{ action, from, to string }{
+		{"create", "", "open"},
+		{"start", "open", "in_progress"},
+		{"block", "in_progress", "blocked"},
+	}
+	for i, w := range want {
+		f := records[i].Fields
+		if f["action"] != w.action || f["from"] != w.from || f["to"] != w.to {
+			t.Errorf("record %d = %v, want %+v", i, f, w)
+		}
+		if f["actor"] != "tester" || f["slot"] != "alpha" {
+			t.Errorf("record %d actor/slot = %v/%v", i, f["actor"], f["slot"])
+		}
+		if f["timestamp"] == "" {
+			t.Errorf("record %d has no timestamp", i)
+		}
+	}
+	if records[2].Fields["reason"] != "waiting on upstream" {
+		t.Errorf("reason = %v", records[2].Fields["reason"])
+	}
+	if records[1].Fields["reason"] != "" {
+		t.Errorf("unexpected reason on start: %v", records[1].Fields["reason"])
+	}
+}
+
+func TestCaseResourceHistoryFollowsGroupedMove(t *testing.T) {
+	_, strat := setupGroupedProject(t)
+	cr := New(dal.NewFileSystem(), dal.NewExecutor(), strat)
+	ctx := testCtx()
+
+	created, err := cr.Create(ctx, "moving", nil)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	resolved, err := cr.Transition(ctx, created.ID, "close_no_action")
+	if err != nil {
+		t.Fatalf("close_no_action: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(filepath.Dir(resolved.RawPath), historyFile))
+	if err != nil {
+		t.Fatalf("read history: %v", err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 2 {
+		t.Errorf("history has %d lines, want 2:\n%s", lines, data)
+	}
+}
+
+func TestReadHistoryRejectsCorruptLine(t *testing.T) {
+	dir := t.TempDir()
+	content := `{"timestamp":"2026-01-01T00:00:00Z","action":"create","from":"","to":"open","actor":"a","slot":""}` + "\nnot json\n"
+	if err := os.WriteFile(filepath.Join(dir, historyFile), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cr := New(dal.NewFileSystem(), dal.NewExecutor(), nil)
+	if _, err := cr.readHistory(dir); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("readHistory err = %v, want line 2 error", err)
+	}
+}
+
+func TestCaseResourceHistoryConcurrentAppends(t *testing.T) {
+	_, strat := setupTestProject(t)
+	cr := New(dal.NewFileSystem(), dal.NewExecutor(), strat)
+	ctx := testCtx()
+	created, err := cr.Create(ctx, "busy", nil)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	caseDir := filepath.Dir(created.RawPath)
+
+	const writers = 20
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := cr.appendHistory(caseDir, cr.newHistoryEntry(ctx, "note", "open", "open")); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	entries, err := cr.readHistory(caseDir)
+	if err != nil {
+		t.Fatalf("readHistory: %v", err)
+	}
+	if len(entries) != writers+1 {
+		t.Errorf("history has %d entries, want %d", len(entries), writers+1)
+	}
+}
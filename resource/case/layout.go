@@ -0,0 +1,154 @@
+package caseresource
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/gh-xj/agentops/dal"
+	"github.com/gh-xj/agentops/strategy"
+)
+
+// defaultSlotDir holds cases in the grouped layout that belong to no slot.
+const defaultSlotDir = "default"
+
+// defaultGroup is used for statuses that belong to no transitions category.
+const defaultGroup = "active"
+
+// CaseLocation is where a case directory lives under the cases root.
+// Group and Slot are empty for cases stored flat.
+type CaseLocation struct {
+	ID    string
+	Dir   string
+	Group string
+	Slot  string
+}
+
+// grouped reports whether new and transitioned cases use the
+// cases/{group}/{slot}/CASE-* layout.
+func (cr *CaseResource) grouped() bool {
+	return cr.strat != nil && cr.strat.Storage.Layout == strategy.LayoutGrouped
+}
+
+// scanCases returns every case directory under casesRoot.
+func (cr *CaseResource) scanCases(casesRoot string) ([]CaseLocation, error) {
+	return ScanCases(cr.fs, casesRoot)
+}
+
+// ScanCases returns every case directory under casesRoot, skipping the
+// archive. Flat CASE-*
+// directories and {group}/{slot}/CASE-* directories are both found regardless
+// of the configured layout, so a repository can switch layouts in place.
+func ScanCases(fs dal.FileSystem, casesRoot string) ([]CaseLocation, error) {
+	entries, err := fs.ReadDir(casesRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	var locs []CaseLocation
+	for _, entry := range entries {
+		if !entry.IsDir || entry.Name == archiveDir {
+			continue
+		}
+		if strings.HasPrefix(entry.Name, "CASE-") {
+			locs = append(locs, CaseLocation{ID: entry.Name, Dir: filepath.Join(casesRoot, entry.Name)})
+			continue
+		}
+		groupDir := filepath.Join(casesRoot, entry.Name)
+		slots, err := fs.ReadDir(groupDir)
+		if err != nil {
+			continue
+		}
+		for _, slot := range slots {
+			if !slot.IsDir || strings.HasPrefix(slot.Name, "CASE-") {
+				continue
+			}
+			slotDir := filepath.Join(groupDir, slot.Name)
+			cases, err := fs.ReadDir(slotDir)
+			if err != nil {
+				continue
+			}
+			for _, c := range cases {
+				if !c.IsDir || !strings.HasPrefix(c.Name, "CASE-") {
+					continue
+				}
+				locs = append(locs, CaseLocation{
+					ID:    c.Name,
+					Dir:   filepath.Join(slotDir, c.Name),
+					Group: entry.Name,
+					Slot:  slot.Name,
+				})
+			}
+		}
+	}
+	return locs, nil
+}
+
+// locate finds the directory of case id in any layout.
+func (cr *CaseResource) locate(id string) (CaseLocation, error) {
+	casesRoot, err := cr.casesDir()
+	if err != nil {
+		return CaseLocation{}, err
+	}
+
+	// Direct lookup in the flat cases directory.
+	if cr.fs.Exists(filepath.Join(casesRoot, id, "case.md")) {
+		return CaseLocation{ID: id, Dir: filepath.Join(casesRoot, id)}, nil
+	}
+
+	locs, err := cr.scanCases(casesRoot)
+	if err == nil {
+		for _, loc := range locs {
+			if loc.ID == id && loc.Group != "" && cr.fs.Exists(filepath.Join(loc.Dir, "case.md")) {
+				return loc, nil
+			}
+		}
+	}
+	if e, ok := cr.archived(id); ok {
+		return CaseLocation{}, fmt.Errorf("case %q is archived at %s", id, e.Path)
+	}
+	return CaseLocation{}, fmt.Errorf("case %q not found", id)
+}
+
+// groupFor returns the storage group directory for a status.
+func (cr *CaseResource) groupFor(status string) string {
+	if group := cr.sm.CategoryForStatus(status); group != "" {
+		return group
+	}
+	return defaultGroup
+}
+
+// relocate moves a case into the group directory for status, preserving its
+// slot. It is a no-op for the flat layout or when the group is unchanged.
+// The move is a single rename, so the case is never visible in two places;
+// callers hold the case lock, which moves along with the directory.
+func (cr *CaseResource) relocate(loc CaseLocation, status string) (CaseLocation, error) {
+	if !cr.grouped() {
+		return loc, nil
+	}
+	group := cr.groupFor(status)
+	if loc.Group == group {
+		return loc, nil
+	}
+
+	casesRoot, err := cr.casesDir()
+	if err != nil {
+		return loc, err
+	}
+	slot := loc.Slot
+	if slot == "" {
+		slot = defaultSlotDir
+	}
+	parent := filepath.Join(casesRoot, group, slot)
+	if err := cr.fs.EnsureDir(parent); err != nil {
+		return loc, fmt.Errorf("ensure %s/%s: %w", group, slot, err)
+	}
+	dest := filepath.Join(parent, loc.ID)
+	if cr.fs.Exists(dest) {
+		return loc, fmt.Errorf("move case %q: %s already exists", loc.ID, dest)
+	}
+	if err := cr.fs.Rename(loc.Dir, dest); err != nil {
+		return loc, fmt.Errorf("move case %q to %s/%s: %w", loc.ID, group, slot, err)
+	}
+	return CaseLocation{ID: loc.ID, Dir: dest, Group: group, Slot: slot}, nil
+}
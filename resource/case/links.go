@@ -0,0 +1,267 @@
+package caseresource
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	agentops "github.com/gh-xj/agentops"
+	"github.com/gh-xj/agentops/resource"
+)
+
+// Link relations, each stored as a frontmatter key of the linking case.
+const (
+	LinkBlockedBy = "blocked_by" // cases that must finish before this one can proceed
+	LinkRelatesTo = "relates_to" // related cases; no order implied
+	LinkParent    = "parent"     // the case this one is part of
+)
+
+// linkRelations lists the relations in the order they are rendered.
+var linkRelations = []string{LinkBlockedBy, LinkRelatesTo, LinkParent}
+
+var (
+	_ resource.Linker  = (*CaseResource)(nil)
+	_ resource.Grapher = (*CaseResource)(nil)
+)
+
+// caseLinks is the link-relevant state of one case.
+type caseLinks struct {
+	status string
+	links  map[string][]string // relation -> target IDs
+}
+
+// Relations returns the link relations a case may declare.
+func (cr *CaseResource) Relations() []string {
+	return append([]string(nil), linkRelations...)
+}
+
+// Link records that case id has relation to target. target must exist, and
+// blocked_by and parent links may not form a cycle. A case has at most one
+// parent; linking a new one replaces it.
+func (cr *CaseResource) Link(ctx *agentops.AppContext, id, relation, target string) (*resource.Record, error) {
+	if cr.strat == nil {
+		return nil, fmt.Errorf("no strategy loaded")
+	}
+	if err := checkRelation(relation); err != nil {
+		return nil, err
+	}
+	if target == id {
+		return nil, fmt.Errorf("cannot link case %q to itself", id)
+	}
+	if !cr.exists(target) {
+		return nil, fmt.Errorf("case %q not found", target)
+	}
+	if relation != LinkRelatesTo {
+		all, err := cr.allLinks()
+		if err != nil {
+			return nil, err
+		}
+		if path := linkPath(all, relation, target, id); path != nil {
+			return nil, fmt.Errorf("linking %s %s %s would create a cycle: %s", id, relation, target,
+				strings.Join(append([]string{id}, path...), " -> "))
+		}
+	}
+
+	return cr.updateCase(ctx, id, "link", func(fm *Frontmatter, body string) (string, error) {
+		if relation == LinkParent {
+			fm.SetString(relation, target)
+			return body, nil
+		}
+		targets := fm.Strings(relation)
+		for _, t := range targets {
+			if t == target {
+				return body, nil
+			}
+		}
+		fm.SetStrings(relation, append(targets, target))
+		return body, nil
+	})
+}
+
+// Unlink removes a link previously added with Link.
+func (cr *CaseResource) Unlink(ctx *agentops.AppContext, id, relation, target string) (*resource.Record, error) {
+	if cr.strat == nil {
+		return nil, fmt.Errorf("no strategy loaded")
+	}
+	if err := checkRelation(relation); err != nil {
+		return nil, err
+	}
+	return cr.updateCase(ctx, id, "unlink", func(fm *Frontmatter, body string) (string, error) {
+		targets := fm.Strings(relation)
+		kept := make([]string, 0, len(targets))
+		for _, t := range targets {
+			if t != target {
+				kept = append(kept, t)
+			}
+		}
+		if len(kept) == len(targets) {
+			return "", fmt.Errorf("case %q has no %s link to %q", id, relation, target)
+		}
+		if relation == LinkParent {
+			fm.SetString(relation, "")
+		} else {
+			fm.SetStrings(relation, kept)
+		}
+		return body, nil
+	})
+}
+
+// Graph returns the cases linked to one another and their links. With an id,
+// only the cases connected to it, in either direction, are included. Link
+// targets that are archived or missing appear with status "archived" or
+// "missing".
+func (cr *CaseResource) Graph(ctx *agentops.AppContext, id string) (*resource.Graph, error) {
+	if cr.strat == nil {
+		return nil, fmt.Errorf("no strategy loaded")
+	}
+	all, err := cr.allLinks()
+	if err != nil {
+		return nil, err
+	}
+	if id != "" {
+		if _, ok := all[id]; !ok {
+			if _, err := cr.locate(id); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	g := &resource.Graph{}
+	linked := map[string]bool{}
+	for _, from := range sortedKeys(all) {
+		for _, relation := range linkRelations {
+			for _, to := range all[from].links[relation] {
+				g.Edges = append(g.Edges, resource.GraphEdge{From: from, To: to, Relation: relation})
+				linked[from], linked[to] = true, true
+			}
+		}
+	}
+	if id != "" {
+		keep := connected(g.Edges, id)
+		edges := g.Edges[:0]
+		for _, e := range g.Edges {
+			if keep[e.From] {
+				edges = append(edges, e)
+			}
+		}
+		g.Edges = edges
+		linked = keep
+	}
+
+	for _, nodeID := range sortedKeys(linked) {
+		node := resource.GraphNode{ID: nodeID, Status: "missing"}
+		if c, ok := all[nodeID]; ok {
+			node.Status = c.status
+		} else if _, ok := cr.archived(nodeID); ok {
+			node.Status = "archived"
+		}
+		g.Nodes = append(g.Nodes, node)
+	}
+	return g, nil
+}
+
+// allLinks reads the status and links of every case in the working set.
+func (cr *CaseResource) allLinks() (map[string]caseLinks, error) {
+	cases, err := cr.cases.Cases()
+	if err != nil {
+		return nil, err
+	}
+	all := map[string]caseLinks{}
+	for _, sc := range cases {
+		c := caseLinks{status: sc.Frontmatter.Status, links: map[string][]string{}}
+		for _, relation := range linkRelations {
+			c.links[relation] = sc.Frontmatter.Strings(relation)
+		}
+		all[sc.ID] = c
+	}
+	return all, nil
+}
+
+// blockers returns the blocked_by targets of fm that are still active, with
+// their status, or "missing" when the case cannot be found. Archived blockers
+// are finished and never returned.
+func (cr *CaseResource) blockers(fm Frontmatter) []CaseBlocker {
+	var out []CaseBlocker
+	for _, target := range fm.Strings(LinkBlockedBy) {
+		loc, err := cr.locate(target)
+		if err != nil {
+			if _, ok := cr.archived(target); !ok {
+				out = append(out, CaseBlocker{ID: target, Status: "missing"})
+			}
+			continue
+		}
+		tfm, err := cr.readFrontmatter(loc)
+		if err != nil {
+			out = append(out, CaseBlocker{ID: target, Status: "unreadable"})
+			continue
+		}
+		if cr.sm.CategoryForStatus(tfm.Status) == "active" {
+			out = append(out, CaseBlocker{ID: target, Status: tfm.Status})
+		}
+	}
+	return out
+}
+
+// linkPath returns the chain of relation links leading from start to goal,
+// ending with goal, or nil when goal cannot be reached.
+func linkPath(all map[string]caseLinks, relation, start, goal string) []string {
+	seen := map[string]bool{}
+	var walk func(id string) []string
+	walk = func(id string) []string {
+		if id == goal {
+			return []string{id}
+		}
+		if seen[id] {
+			return nil
+		}
+		seen[id] = true
+		for _, next := range all[id].links[relation] {
+			if rest := walk(next); rest != nil {
+				return append([]string{id}, rest...)
+			}
+		}
+		return nil
+	}
+	return walk(start)
+}
+
+// connected returns the IDs reachable from id over edges in either direction.
+func connected(edges []resource.GraphEdge, id string) map[string]bool {
+	adj := map[string][]string{}
+	for _, e := range edges {
+		adj[e.From] = append(adj[e.From], e.To)
+		adj[e.To] = append(adj[e.To], e.From)
+	}
+	seen := map[string]bool{id: true}
+	queue := []string{id}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, next := range adj[cur] {
+			if !seen[next] {
+				seen[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+	return seen
+}
+
+func checkRelation(relation string) error {
+	for _, r := range linkRelations {
+		if r == relation {
+			return nil
+		}
+	}
+	return agentops.NewCLIError(agentops.ExitUsage, "invalid_relation",
+		fmt.Sprintf("unknown link relation %q (want one of %s)", relation, strings.Join(linkRelations, ", ")), nil)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,189 @@
+package caseresource
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	agentops "github.com/gh-xj/agentops"
+	"github.com/gh-xj/agentops/dal"
+)
+
+// createCases creates one case per slug and returns their IDs.
+func createCases(t *testing.T, cr *CaseResource, slugs ...string) []string {
+	t.Helper()
+	ids := make([]string, len(slugs))
+	for i, slug := range slugs {
+		rec, err := cr.Create(testCtx(), slug, nil)
+		if err != nil {
+			t.Fatalf("create %s: %v", slug, err)
+		}
+		ids[i] = rec.ID
+	}
+	return ids
+}
+
+func TestCaseResourceLink(t *testing.T) {
+	_, strat := setupTestProject(t)
+	cr := New(dal.NewFileSystem(), dal.NewExecutor(), strat)
+	ctx := testCtx()
+	ids := createCases(t, cr, "app", "db", "epic", "other-epic")
+	app, db, epic, otherEpic := ids[0], ids[1], ids[2], ids[3]
+
+	if _, err := cr.Link(ctx, app, LinkBlockedBy, db); err != nil {
+		t.Fatalf("link blocked_by: %v", err)
+	}
+	// Linking twice is a no-op.
+	if _, err := cr.Link(ctx, app, LinkBlockedBy, db); err != nil {
+		t.Fatalf("link blocked_by again: %v", err)
+	}
+	if _, err := cr.Link(ctx, app, LinkParent, epic); err != nil {
+		t.Fatalf("link parent: %v", err)
+	}
+	rec, err := cr.Link(ctx, app, LinkParent, otherEpic)
+	if err != nil {
+		t.Fatalf("relink parent: %v", err)
+	}
+	if rec.Fields[LinkParent] != otherEpic {
+		t.Errorf("parent = %v, want %s", rec.Fields[LinkParent], otherEpic)
+	}
+	if got, ok := rec.Fields[LinkBlockedBy].([]any); !ok || len(got) != 1 || got[0] != db {
+		t.Errorf("blocked_by = %#v, want [%s]", rec.Fields[LinkBlockedBy], db)
+	}
+
+	data, err := os.ReadFile(rec.RawPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "blocked_by: ["+db+"]\n") {
+		t.Errorf("case.md does not record blocked_by as a list:\n%s", data)
+	}
+
+	rec, err = cr.Unlink(ctx, app, LinkBlockedBy, db)
+	if err != nil {
+		t.Fatalf("unlink: %v", err)
+	}
+	if _, ok := rec.Fields[LinkBlockedBy]; ok {
+		t.Errorf("blocked_by should be removed once empty, got %v", rec.Fields[LinkBlockedBy])
+	}
+	if _, err := cr.Unlink(ctx, app, LinkBlockedBy, db); err == nil {
+		t.Error("expected error unlinking a missing link")
+	}
+}
+
+func TestCaseResourceLinkRejects(t *testing.T) {
+	_, strat := setupTestProject(t)
+	cr := New(dal.NewFileSystem(), dal.NewExecutor(), strat)
+	ctx := testCtx()
+	ids := createCases(t, cr, "a", "b", "c")
+	a, b, c := ids[0], ids[1], ids[2]
+
+	if _, err := cr.Link(ctx, a, LinkBlockedBy, b); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cr.Link(ctx, b, LinkBlockedBy, c); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name            string
+		id, rel, target string
+		want            string
+	}{
+		{"missing target", a, LinkBlockedBy, "CASE-20000101-nope", "not found"},
+		{"self", a, LinkRelatesTo, a, "itself"},
+		{"cycle", c, LinkBlockedBy, a, c + " -> " + a + " -> " + b + " -> " + c},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := cr.Link(ctx, tt.id, tt.rel, tt.target)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("err = %v, want mention of %q", err, tt.want)
+			}
+		})
+	}
+
+	// relates_to may point back.
+	if _, err := cr.Link(ctx, c, LinkRelatesTo, a); err != nil {
+		t.Errorf("relates_to back-link: %v", err)
+	}
+
+	var cliErr *agentops.CLIError
+	if _, err := cr.Link(ctx, a, "depends_on", b); !errors.As(err, &cliErr) || cliErr.Code != agentops.ExitUsage {
+		t.Errorf("unknown relation: err = %v, want usage CLIError", err)
+	}
+}
+
+func TestCaseResourceGraph(t *testing.T) {
+	_, strat := setupTestProject(t)
+	cr := New(dal.NewFileSystem(), dal.NewExecutor(), strat)
+	ctx := testCtx()
+	ids := createCases(t, cr, "a", "b", "c", "lonely")
+	a, b, c := ids[0], ids[1], ids[2]
+
+	if _, err := cr.Link(ctx, a, LinkBlockedBy, b); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cr.Link(ctx, c, LinkParent, b); err != nil {
+		t.Fatal(err)
+	}
+
+	g, err := cr.Graph(ctx, "")
+	if err != nil {
+		t.Fatalf("Graph: %v", err)
+	}
+	if len(g.Nodes) != 3 || len(g.Edges) != 2 {
+		t.Fatalf("graph = %+v, want 3 nodes (lonely excluded) and 2 edges", g)
+	}
+	if e := g.Edges[0]; e.From != a || e.To != b || e.Relation != LinkBlockedBy {
+		t.Errorf("first edge = %+v", e)
+	}
+	for _, n := range g.Nodes {
+		if n.Status != "open" {
+			t.Errorf("node %s status = %q, want open", n.ID, n.Status)
+		}
+	}
+
+	g, err = cr.Graph(ctx, ids[3])
+	if err != nil {
+		t.Fatalf("Graph(lonely): %v", err)
+	}
+	if len(g.Nodes) != 1 || len(g.Edges) != 0 {
+		t.Errorf("graph of an unlinked case = %+v", g)
+	}
+	if _, err := cr.Graph(ctx, "CASE-20000101-nope"); err == nil {
+		t.Error("expected error graphing a missing case")
+	}
+}
+
+func TestCaseResourceUnblockRequiresFinishedBlockers(t *testing.T) {
+	_, strat := setupTestProject(t)
+	cr := New(dal.NewFileSystem(), dal.NewExecutor(), strat)
+	ctx := testCtx()
+	ids := createCases(t, cr, "app", "db")
+	app, db := ids[0], ids[1]
+
+	if _, err := cr.Link(ctx, app, LinkBlockedBy, db); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cr.Transition(ctx, app, "block"); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := cr.Transition(ctx, app, "unblock")
+	var guardErr *GuardError
+	if !errors.As(err, &guardErr) || !strings.Contains(guardErr.Error(), "blocked by "+db+" (open)") {
+		t.Fatalf("unblock with an open blocker: err = %v", err)
+	}
+	if agentops.ResolveExitCode(err) != agentops.ExitTransitionDenied {
+		t.Errorf("exit code = %d, want %d", agentops.ResolveExitCode(err), agentops.ExitTransitionDenied)
+	}
+
+	if _, err := cr.Transition(ctx, db, "close_no_action"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cr.Transition(ctx, app, "unblock"); err != nil {
+		t.Errorf("unblock after the blocker closed: %v", err)
+	}
+}
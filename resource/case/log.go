@@ -0,0 +1,76 @@
+package caseresource
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	agentops "github.com/gh-xj/agentops"
+	"github.com/gh-xj/agentops/hooks"
+)
+
+// logHeading is the case.md section that collects framework-generated notes.
+const logHeading = "## Log"
+
+// appendLog appends a timestamped entry to the Log section of a case body,
+// creating the section at the end of the body when it does not exist yet.
+func appendLog(body, entry string) string {
+	line := fmt.Sprintf("- %s %s\n", time.Now().UTC().Format(time.RFC3339), entry)
+
+	lines := strings.SplitAfter(body, "\n")
+	start, sectionEnd, ok := findSection(lines, logHeading)
+	if !ok {
+		if body != "" && !strings.HasSuffix(body, "\n") {
+			body += "\n"
+		}
+		return body + "\n" + logHeading + "\n\n" + line
+	}
+
+	// Insert after the last non-blank line of the section.
+	end := start + 1
+	for i := start + 1; i < sectionEnd; i++ {
+		if strings.TrimSpace(lines[i]) != "" {
+			end = i + 1
+		}
+	}
+	if end == start+1 {
+		line = "\n" + line
+	}
+	if end > 0 && !strings.HasSuffix(lines[end-1], "\n") {
+		line = "\n" + line
+	}
+
+	var b strings.Builder
+	for _, l := range lines[:end] {
+		b.WriteString(l)
+	}
+	b.WriteString(line)
+	for _, l := range lines[end:] {
+		b.WriteString(l)
+	}
+	return b.String()
+}
+
+// appendHookFailures logs every non-blocking hook failure into the case body.
+func appendHookFailures(body string, results []hooks.Result) string {
+	for _, r := range hooks.Failures(results) {
+		if r.Blocking {
+			continue
+		}
+		reason := strings.Join(strings.Fields(r.Err.Error()), " ")
+		body = appendLog(body, fmt.Sprintf("hook %s `%s` failed: %s", r.Event, r.Command, reason))
+	}
+	return body
+}
+
+// AppendLog adds a timestamped entry to the Log section of a case.
+func (cr *CaseResource) AppendLog(ctx *agentops.AppContext, id, entry string) error {
+	if cr.strat == nil {
+		return fmt.Errorf("no strategy loaded")
+	}
+
+	_, err := cr.updateCase(ctx, id, "log", func(fm *Frontmatter, body string) (string, error) {
+		return appendLog(body, entry), nil
+	})
+	return err
+}
@@ -0,0 +1,50 @@
+package caseresource
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAppendLogCreatesSection(t *testing.T) {
+	body := "# Title\n\n## Findings\n"
+	got := appendLog(body, "first entry")
+	if !strings.HasPrefix(got, body) {
+		t.Errorf("existing body should be preserved, got:\n%s", got)
+	}
+	if !strings.Contains(got, "\n## Log\n\n- ") || !strings.HasSuffix(got, " first entry\n") {
+		t.Errorf("expected new Log section with entry, got:\n%s", got)
+	}
+}
+
+func TestAppendLogExistingSection(t *testing.T) {
+	body := "# Title\n\n## Log\n\n- old\n\n## Close Criteria\n\ndone\n"
+	got := appendLog(body, "new entry")
+
+	logIdx := strings.Index(got, "- old\n")
+	newIdx := strings.Index(got, "new entry")
+	closeIdx := strings.Index(got, "## Close Criteria")
+	if logIdx < 0 || newIdx < logIdx || newIdx > closeIdx {
+		t.Errorf("entry should follow existing entries inside Log section, got:\n%s", got)
+	}
+	if strings.Count(got, "## Log") != 1 {
+		t.Errorf("expected a single Log section, got:\n%s", got)
+	}
+	if !strings.Contains(got, "new entry\n\n## Close Criteria") {
+		t.Errorf("blank line before next section should be kept, got:\n%s", got)
+	}
+}
+
+func TestAppendLogSkipsFencedHeadings(t *testing.T) {
+	body := "# Title\n\n```\n## Log\n```\n\n## Log\n\n- old\n\n~~~\n## Not a section\n~~~\n\n## Close Criteria\n"
+	got := appendLog(body, "new entry")
+
+	fenceIdx := strings.Index(got, "## Not a section\n~~~\n")
+	newIdx := strings.Index(got, "new entry")
+	closeIdx := strings.Index(got, "## Close Criteria")
+	if fenceIdx < 0 || newIdx < fenceIdx || newIdx > closeIdx {
+		t.Errorf("entry should land at the end of the real Log section, got:\n%s", got)
+	}
+	if !strings.HasPrefix(got, "# Title\n\n```\n## Log\n```\n\n## Log\n") {
+		t.Errorf("fenced heading should be left alone, got:\n%s", got)
+	}
+}
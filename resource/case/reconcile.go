@@ -0,0 +1,271 @@
+package caseresource
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	agentops "github.com/gh-xj/agentops"
+	"github.com/gh-xj/agentops/budget"
+	"github.com/gh-xj/agentops/resource"
+	workerresource "github.com/gh-xj/agentops/resource/worker"
+)
+
+// findingsHeading is the case.md section worker findings are merged into,
+// one "### <worker>" subsection per worker.
+const findingsHeading = "## Findings"
+
+// Per-worker reconcile results.
+const (
+	ReconcileMerged    = "merged"    // findings written to case.md
+	ReconcileUnchanged = "unchanged" // case.md already reflects the sidecar
+	ReconcileMissing   = "missing"   // the worker has not written its sidecar
+	ReconcileInvalid   = "invalid"   // the sidecar failed schema validation
+)
+
+var _ resource.Reconciler = (*CaseResource)(nil)
+
+// sidecarResult is the outcome of reconciling one worker's sidecar.
+type sidecarResult struct {
+	worker         workerresource.Worker
+	result         string
+	sidecar        workerresource.Sidecar
+	recommendation string
+	message        string
+}
+
+// ReconcileSchema describes the records returned by Reconcile.
+func (cr *CaseResource) ReconcileSchema() resource.ResourceSchema {
+	return resource.ResourceSchema{
+		Kind:        "case_reconcile",
+		Description: "Per-worker outcome of merging sidecars into a case.",
+		Fields: []resource.FieldDef{
+			{Name: "worker", Type: "string", Required: true},
+			{Name: "sidecar", Type: "string", Required: true},
+			{Name: "result", Type: "string", Required: true},
+			{Name: "findings", Type: "int"},
+			{Name: "recommendation", Type: "string"},
+			{Name: "message", Type: "string"},
+		},
+	}
+}
+
+// Reconcile reads every registered worker's sidecar in the case directory,
+// validates it, and merges its findings into the Findings section. When the
+// valid sidecars agree on a recommended status that the state machine allows
+// from the current status, the case is transitioned to it. Conflicting,
+// disallowed or guard-denied recommendations are reported but not applied.
+func (cr *CaseResource) Reconcile(ctx *agentops.AppContext, id string) ([]resource.Record, error) {
+	if cr.strat == nil {
+		return nil, fmt.Errorf("no strategy loaded")
+	}
+	loc, err := cr.locate(id)
+	if err != nil {
+		return nil, err
+	}
+	workers, err := workerresource.Discover(cr.fs, cr.strat.Root)
+	if err != nil {
+		return nil, fmt.Errorf("discover workers: %w", err)
+	}
+	if ordered, err := workerresource.ExecutionOrder(workers); err == nil {
+		workers = ordered
+	}
+
+	results := make([]*sidecarResult, 0, len(workers))
+	for _, w := range workers {
+		results = append(results, cr.readSidecar(loc.Dir, w))
+	}
+
+	// The merge, its spend and the recommended transition are one change.
+	err = cr.batch("reconcile", func() error {
+		rec, err := cr.updateCase(ctx, id, "reconcile", func(fm *Frontmatter, body string) (string, error) {
+			return mergeFindings(body, results), nil
+		})
+		if err != nil {
+			return err
+		}
+		if err := cr.RecordSpend(ctx, id, workerSpend(results)...); err != nil {
+			return err
+		}
+		current, _ := rec.Fields["status"].(string)
+		cr.applyRecommendation(ctx, id, current, results)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]resource.Record, 0, len(results))
+	for _, r := range results {
+		records = append(records, resource.Record{
+			Kind: "case_reconcile",
+			ID:   fmt.Sprintf("%s#%s", id, r.worker.Name),
+			Fields: map[string]any{
+				"worker":         r.worker.Name,
+				"sidecar":        r.worker.SidecarPath,
+				"result":         r.result,
+				"findings":       len(r.sidecar.Findings),
+				"recommendation": r.recommendation,
+				"message":        r.message,
+			},
+			RawPath: filepath.Join(loc.Dir, r.worker.SidecarPath),
+		})
+	}
+	return records, nil
+}
+
+// workerSpend counts every newly merged sidecar as one invocation of its
+// worker, with the usage the worker reported.
+func workerSpend(results []*sidecarResult) []budget.Entry {
+	var entries []budget.Entry
+	for _, r := range results {
+		if r.result != ReconcileMerged {
+			continue
+		}
+		entries = append(entries, budget.Entry{
+			Source:            budget.SourceWorker,
+			Worker:            r.worker.Name,
+			WorkerInvocations: 1,
+			WallClockMS:       int64(r.sidecar.Usage.WallClockSeconds) * 1000,
+			Tokens:            r.sidecar.Usage.Tokens,
+		})
+	}
+	return entries
+}
+
+// readSidecar loads and validates one worker's sidecar.
+func (cr *CaseResource) readSidecar(caseDir string, w workerresource.Worker) *sidecarResult {
+	r := &sidecarResult{worker: w}
+	path := filepath.Join(caseDir, w.SidecarPath)
+	if w.SidecarPath == "" || !cr.fs.Exists(path) {
+		r.result = ReconcileMissing
+		return r
+	}
+
+	schema, err := workerresource.SidecarSchema(cr.fs, w)
+	if err != nil {
+		r.result, r.message = ReconcileInvalid, err.Error()
+		return r
+	}
+	data, err := cr.fs.ReadFile(path)
+	if err != nil {
+		r.result, r.message = ReconcileInvalid, err.Error()
+		return r
+	}
+	sc, problems := workerresource.ParseSidecar(data, schema)
+	if len(problems) > 0 {
+		r.result, r.message = ReconcileInvalid, strings.Join(problems, "; ")
+		return r
+	}
+	r.sidecar = sc
+	r.recommendation = sc.RecommendedStatus
+	return r
+}
+
+// mergeFindings writes each valid sidecar into its worker's subsection of the
+// Findings section and marks the result merged or unchanged.
+func mergeFindings(body string, results []*sidecarResult) string {
+	findings, _ := sectionContent(body, findingsHeading)
+	changed := false
+	for _, r := range results {
+		if r.result != "" {
+			continue
+		}
+		heading := "### " + r.worker.Name
+		block := renderSidecar(r.worker, r.sidecar)
+		if existing, ok := sectionContent(findings, heading); ok && existing == block {
+			r.result = ReconcileUnchanged
+			continue
+		}
+		findings = setSection(findings, heading, block)
+		r.result = ReconcileMerged
+		changed = true
+	}
+	if !changed {
+		return body
+	}
+	return setSection(body, findingsHeading, findings)
+}
+
+// renderSidecar renders a sidecar as the content of a worker's subsection.
+func renderSidecar(w workerresource.Worker, sc workerresource.Sidecar) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Sidecar: `%s`\n", w.SidecarPath)
+	if sc.Summary != "" {
+		b.WriteString("\n" + strings.TrimSpace(sc.Summary) + "\n")
+	}
+	b.WriteString("\n")
+	if len(sc.Findings) == 0 {
+		b.WriteString("- no findings\n")
+	}
+	for _, f := range sc.Findings {
+		fmt.Fprintf(&b, "- **%s** %s", f.Severity, strings.Join(strings.Fields(f.Message), " "))
+		if f.Path != "" {
+			fmt.Fprintf(&b, " (`%s`)", f.Path)
+		}
+		b.WriteString("\n")
+	}
+	if sc.RecommendedStatus != "" {
+		fmt.Fprintf(&b, "\nRecommends `%s`", sc.RecommendedStatus)
+		if sc.Reason != "" {
+			b.WriteString(": " + strings.Join(strings.Fields(sc.Reason), " "))
+		}
+		b.WriteString("\n")
+	}
+	return trimBlankLines(b.String())
+}
+
+// applyRecommendation transitions the case to the status recommended by the
+// valid sidecars when they agree and the state machine allows it, recording
+// the outcome in each recommending result's message.
+func (cr *CaseResource) applyRecommendation(ctx *agentops.AppContext, id, current string, results []*sidecarResult) {
+	byStatus := map[string][]string{}
+	var recommending []*sidecarResult
+	for _, r := range results {
+		if r.recommendation == "" || (r.result != ReconcileMerged && r.result != ReconcileUnchanged) {
+			continue
+		}
+		byStatus[r.recommendation] = append(byStatus[r.recommendation], r.worker.Name)
+		recommending = append(recommending, r)
+	}
+	if len(recommending) == 0 {
+		return
+	}
+	report := func(msg string) {
+		for _, r := range recommending {
+			r.message = msg
+		}
+	}
+
+	if len(byStatus) > 1 {
+		statuses := make([]string, 0, len(byStatus))
+		for s, names := range byStatus {
+			statuses = append(statuses, fmt.Sprintf("%s (%s)", s, strings.Join(names, ", ")))
+		}
+		sort.Strings(statuses)
+		report("conflicting recommendations: " + strings.Join(statuses, ", ") + "; none applied")
+		return
+	}
+
+	target := recommending[0].recommendation
+	if target == current {
+		report("already " + current)
+		return
+	}
+	action, ok := cr.sm.ActionTo(current, target)
+	if !ok {
+		report(fmt.Sprintf("not applied: no action moves %s to %s", current, target))
+		return
+	}
+
+	reason := "recommended by " + strings.Join(byStatus[target], ", ")
+	if r := recommending[0].sidecar.Reason; r != "" {
+		reason += ": " + r
+	}
+	if _, err := cr.Transition(ctx, id, action, resource.WithReason(reason)); err != nil {
+		report(fmt.Sprintf("not applied: %v", err))
+		return
+	}
+	report(fmt.Sprintf("applied: %s -> %s via %s", current, target, action))
+}
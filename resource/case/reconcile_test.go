@@ -0,0 +1,142 @@
+package caseresource
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gh-xj/agentops/dal"
+	"github.com/gh-xj/agentops/resource"
+)
+
+// writeWorker registers a worker skill in the project at root.
+func writeWorker(t *testing.T, root, name, frontmatter string) {
+	t.Helper()
+	dir := filepath.Join(root, ".agentops", "workers", name)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "SKILL.md"), []byte("---\n"+frontmatter+"---\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeSidecar(t *testing.T, rec *resource.Record, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(filepath.Dir(rec.RawPath), name), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// reconcileResults maps worker name to "result|message".
+func reconcileResults(t *testing.T, records []resource.Record) map[string]string {
+	t.Helper()
+	out := map[string]string{}
+	for _, r := range records {
+		out[r.Fields["worker"].(string)] = r.Fields["result"].(string) + "|" + r.Fields["message"].(string)
+	}
+	return out
+}
+
+func TestCaseResourceReconcile(t *testing.T) {
+	root, strat := setupTestProject(t)
+	writeWorker(t, root, "review", "worker-type: review\nsidecar-path: review.json\n")
+	writeWorker(t, root, "verify", "worker-type: verify\nsidecar-path: verify.json\n")
+	writeWorker(t, root, "triage", "worker-type: triage\nsidecar-path: triage.json\n")
+	cr := New(dal.NewFileSystem(), dal.NewExecutor(), strat)
+	ctx := testCtx()
+
+	created, err := cr.Create(ctx, "reconcile-me", nil)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if _, err := cr.SetSection(ctx, created.ID, "Findings", "- manual note"); err != nil {
+		t.Fatal(err)
+	}
+	writeSidecar(t, created, "review.json", `{"summary": "one issue", "findings": [{"severity": "high", "message": "race in cache", "path": "cache.go"}], "recommended_status": "in_progress", "reason": "needs a fix"}`)
+	writeSidecar(t, created, "verify.json", `{"findings": [{"severity": "bogus", "message": "x"}]}`)
+
+	records, err := cr.Reconcile(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	got := reconcileResults(t, records)
+	if got["review"] != "merged|applied: open -> in_progress via start" {
+		t.Errorf("review = %q", got["review"])
+	}
+	if !strings.HasPrefix(got["verify"], "invalid|$.findings[0].severity") {
+		t.Errorf("verify = %q", got["verify"])
+	}
+	if got["triage"] != "missing|" {
+		t.Errorf("triage = %q", got["triage"])
+	}
+
+	findings, err := cr.GetSection(ctx, created.ID, "Findings")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "- manual note\n\n### review\n\nSidecar: `review.json`\n\none issue\n\n- **high** race in cache (`cache.go`)\n\nRecommends `in_progress`: needs a fix"
+	if findings != want {
+		t.Errorf("Findings =\n%s\nwant\n%s", findings, want)
+	}
+
+	rec, _ := cr.Get(ctx, created.ID)
+	if rec.Fields["status"] != "in_progress" {
+		t.Errorf("status = %v, want in_progress", rec.Fields["status"])
+	}
+	history, _ := cr.History(ctx, created.ID)
+	if last := history[len(history)-1]; last.Fields["reason"] != "recommended by review: needs a fix" {
+		t.Errorf("history reason = %v", last.Fields["reason"])
+	}
+
+	// A second run changes nothing.
+	records, err = cr.Reconcile(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("second Reconcile: %v", err)
+	}
+	if got := reconcileResults(t, records)["review"]; got != "unchanged|already in_progress" {
+		t.Errorf("second review = %q", got)
+	}
+	if again, _ := cr.GetSection(ctx, created.ID, "Findings"); again != findings {
+		t.Errorf("second run rewrote findings:\n%s", again)
+	}
+}
+
+func TestCaseResourceReconcileRecommendationRules(t *testing.T) {
+	root, strat := setupTestProject(t)
+	writeWorker(t, root, "review", "worker-type: review\nsidecar-path: review.json\n")
+	writeWorker(t, root, "verify", "worker-type: verify\nsidecar-path: verify.json\n")
+	cr := New(dal.NewFileSystem(), dal.NewExecutor(), strat)
+	ctx := testCtx()
+
+	created, err := cr.Create(ctx, "recommend", nil)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	// Disagreeing workers: nothing is applied.
+	writeSidecar(t, created, "review.json", `{"findings": [], "recommended_status": "in_progress"}`)
+	writeSidecar(t, created, "verify.json", `{"findings": [], "recommended_status": "closed_no_action"}`)
+	records, err := cr.Reconcile(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	if got := reconcileResults(t, records)["verify"]; !strings.Contains(got, "conflicting recommendations: closed_no_action (verify), in_progress (review); none applied") {
+		t.Errorf("verify = %q", got)
+	}
+
+	// A status the state machine cannot reach from open is not applied.
+	writeSidecar(t, created, "review.json", `{"findings": [], "recommended_status": "resolved"}`)
+	writeSidecar(t, created, "verify.json", `{"findings": []}`)
+	records, err = cr.Reconcile(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	if got := reconcileResults(t, records)["review"]; got != "merged|not applied: no action moves open to resolved" {
+		t.Errorf("review = %q", got)
+	}
+	if rec, _ := cr.Get(ctx, created.ID); rec.Fields["status"] != "open" {
+		t.Errorf("status = %v, want open", rec.Fields["status"])
+	}
+}
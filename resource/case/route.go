@@ -0,0 +1,85 @@
+package caseresource
+
+import (
+	"fmt"
+	"strings"
+
+	agentops "github.com/gh-xj/agentops"
+	"github.com/gh-xj/agentops/resource"
+	workerresource "github.com/gh-xj/agentops/resource/worker"
+	"github.com/gh-xj/agentops/routing"
+)
+
+var _ resource.Router = (*CaseResource)(nil)
+
+// Classify assigns the case a type from the cues of routing.yaml, matched
+// against its title, body and frontmatter, and writes it to the type field.
+func (cr *CaseResource) Classify(ctx *agentops.AppContext, id string) (routing.Classification, error) {
+	if cr.strat == nil {
+		return routing.Classification{}, fmt.Errorf("no strategy loaded")
+	}
+	var c routing.Classification
+	_, err := cr.updateCase(ctx, id, "classify", func(fm *Frontmatter, body string) (string, error) {
+		var err error
+		c, err = routing.Classify(cr.strat.Routing, routing.Subject{
+			Type:   fm.Type,
+			Title:  caseTitle(body),
+			Body:   body,
+			Fields: cr.recordFromFrontmatter(id, "", *fm).Fields,
+		})
+		if err != nil {
+			return "", err
+		}
+		fm.Type = c.Type
+		return body, nil
+	})
+	if err != nil {
+		return routing.Classification{}, err
+	}
+	return c, nil
+}
+
+// Route classifies the case and selects its workers from the registry for
+// its type and its risk field, as set by Assess.
+func (cr *CaseResource) Route(ctx *agentops.AppContext, id string) (*resource.Route, error) {
+	c, err := cr.Classify(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	rec, err := cr.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	risk, _ := rec.Fields[RiskField].(string)
+
+	workers, err := workerresource.Discover(cr.fs, cr.strat.Root)
+	if err != nil {
+		return nil, fmt.Errorf("discover workers: %w", err)
+	}
+	if ordered, err := workerresource.ExecutionOrder(workers); err == nil {
+		workers = ordered
+	}
+	sel := routing.SelectWorkers(cr.strat.Routing, c.Type, risk, workers)
+
+	out := &resource.Route{
+		ID:      id,
+		Type:    c.Type,
+		Risk:    risk,
+		Workers: []string{},
+		Trace:   append(c.Trace, sel.Trace...),
+	}
+	for _, w := range sel.Workers {
+		out.Workers = append(out.Workers, w.Name)
+	}
+	return out, nil
+}
+
+// caseTitle returns the text of the first level-one heading of body.
+func caseTitle(body string) string {
+	for _, line := range strings.Split(body, "\n") {
+		if title, ok := strings.CutPrefix(line, "# "); ok {
+			return strings.TrimSpace(title)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,104 @@
+package caseresource
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gh-xj/agentops/dal"
+	"github.com/gh-xj/agentops/strategy"
+)
+
+func TestCaseResourceRoute(t *testing.T) {
+	root, _ := setupTestProject(t)
+	files := map[string]string{
+		"routing.yaml": `default_route:
+  workers: [triage]
+overrides:
+  risky-bugs:
+    type: bug
+    risk: high
+    workers: [review]
+cues:
+  crash:
+    type: bug
+    title: "(?i)crash"
+`,
+		"risk.yaml": `thresholds: {high: 1}
+rules:
+  outage:
+    score: 1
+    keywords: [outage]
+`,
+		"workers/triage/SKILL.md": "---\nworker-type: triage\nsidecar-path: triage.json\n---\n",
+		"workers/review/SKILL.md": "---\nworker-type: review\nsidecar-path: review.json\nrequires: [triage]\n---\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, ".agentops", name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	strat, err := strategy.Discover(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cr := New(dal.NewFileSystem(), dal.NewExecutor(), strat)
+	ctx := testCtx()
+	ids := createCases(t, cr, "save-crash", "typo")
+
+	rec, err := cr.Get(ctx, ids[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(rec.RawPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := strings.Replace(string(data), "# "+ids[0], "# Crash on save", 1) + "\nCauses an outage.\n"
+	if err := os.WriteFile(rec.RawPath, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	route, err := cr.Route(ctx, ids[0])
+	if err != nil {
+		t.Fatalf("Route: %v", err)
+	}
+	if route.Type != "bug" || route.Risk != "" || !reflect.DeepEqual(route.Workers, []string{"triage"}) {
+		t.Errorf("unassessed route = %+v, want type bug with the default workers", route)
+	}
+	rec, err = cr.Get(ctx, ids[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Fields["type"] != "bug" {
+		t.Errorf("type = %v, want bug written back", rec.Fields["type"])
+	}
+
+	if _, err := cr.Assess(ctx, ids[0]); err != nil {
+		t.Fatal(err)
+	}
+	route, err = cr.Route(ctx, ids[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(route.Workers, []string{"triage", "review"}) {
+		t.Errorf("workers = %v, want review with its required triage; trace %v", route.Workers, route.Trace)
+	}
+	if got := route.Trace[len(route.Trace)-2]; got != "override risky-bugs -> workers review" {
+		t.Errorf("trace = %v", route.Trace)
+	}
+
+	route, err = cr.Route(ctx, ids[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if route.Type != "intake" || !strings.Contains(strings.Join(route.Trace, "\n"), "no cue matched -> keep type intake") {
+		t.Errorf("route = %+v, want type kept", route)
+	}
+}
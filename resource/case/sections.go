@@ -0,0 +1,152 @@
+package caseresource
+
+import (
+	"fmt"
+	"strings"
+
+	agentops "github.com/gh-xj/agentops"
+	"github.com/gh-xj/agentops/resource"
+)
+
+var _ resource.SectionEditor = (*CaseResource)(nil)
+
+// findSection locates heading in lines (as split by strings.SplitAfter) and
+// returns the index of the heading line and the end of its section: the next
+// heading of the same or a higher level, or len(lines). Headings inside
+// fenced code blocks are ignored.
+func findSection(lines []string, heading string) (start, end int, ok bool) {
+	level := headingLevel(heading)
+	start = -1
+	fenced := false
+	for i, line := range lines {
+		text := strings.TrimRight(line, " \t\r\n")
+		if isFence(text) {
+			fenced = !fenced
+			continue
+		}
+		if fenced {
+			continue
+		}
+		if start < 0 {
+			if text == heading {
+				start = i
+			}
+			continue
+		}
+		if l := headingLevel(text); l > 0 && l <= level {
+			return start, i, true
+		}
+	}
+	if start < 0 {
+		return 0, 0, false
+	}
+	return start, len(lines), true
+}
+
+func isFence(line string) bool {
+	line = strings.TrimLeft(line, " ")
+	return strings.HasPrefix(line, "```") || strings.HasPrefix(line, "~~~")
+}
+
+// sectionContent returns the text under heading without surrounding blank
+// lines. ok is false when the body has no such section.
+func sectionContent(body, heading string) (string, bool) {
+	lines := strings.SplitAfter(body, "\n")
+	start, end, ok := findSection(lines, normalizeHeading(heading))
+	if !ok {
+		return "", false
+	}
+	return trimBlankLines(strings.Join(lines[start+1:end], "")), true
+}
+
+// setSection replaces the text under heading with content, appending the
+// section to the end of the body when it does not exist yet.
+func setSection(body, heading, content string) string {
+	heading = normalizeHeading(heading)
+	block := heading + "\n"
+	if content = trimBlankLines(content); content != "" {
+		block += "\n" + content + "\n"
+	}
+
+	lines := strings.SplitAfter(body, "\n")
+	start, end, ok := findSection(lines, heading)
+	if !ok {
+		if body != "" && !strings.HasSuffix(body, "\n") {
+			body += "\n"
+		}
+		return body + "\n" + block
+	}
+	if end < len(lines) {
+		block += "\n"
+	}
+	return strings.Join(lines[:start], "") + block + strings.Join(lines[end:], "")
+}
+
+// appendSection adds content as new lines at the end of heading's section.
+func appendSection(body, heading, content string) string {
+	existing, _ := sectionContent(body, heading)
+	if existing == "" {
+		return setSection(body, heading, content)
+	}
+	return setSection(body, heading, existing+"\n"+trimBlankLines(content))
+}
+
+// trimBlankLines removes leading and trailing blank lines and the trailing
+// newline, keeping indentation of the first line.
+func trimBlankLines(s string) string {
+	lines := strings.Split(s, "\n")
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// GetSection returns the content of a case.md section. heading may be given
+// with or without its leading "## ".
+func (cr *CaseResource) GetSection(ctx *agentops.AppContext, id, heading string) (string, error) {
+	if cr.strat == nil {
+		return "", fmt.Errorf("no strategy loaded")
+	}
+	caseMDPath, err := cr.findCaseMD(id)
+	if err != nil {
+		return "", err
+	}
+	data, err := cr.fs.ReadFile(caseMDPath)
+	if err != nil {
+		return "", fmt.Errorf("read case.md: %w", err)
+	}
+	_, body, err := ParseFrontmatter(string(data))
+	if err != nil {
+		return "", fmt.Errorf("parse frontmatter: %w", err)
+	}
+	content, ok := sectionContent(body, heading)
+	if !ok {
+		return "", fmt.Errorf("case %q has no section %q", id, normalizeHeading(heading))
+	}
+	return content, nil
+}
+
+// SetSection replaces the content of a case.md section, creating it when
+// missing. The frontmatter and all other sections are left as they are.
+func (cr *CaseResource) SetSection(ctx *agentops.AppContext, id, heading, content string) (*resource.Record, error) {
+	if cr.strat == nil {
+		return nil, fmt.Errorf("no strategy loaded")
+	}
+	return cr.updateCase(ctx, id, "section set", func(fm *Frontmatter, body string) (string, error) {
+		return setSection(body, heading, content), nil
+	})
+}
+
+// AppendSection adds content to the end of a case.md section, creating it
+// when missing.
+func (cr *CaseResource) AppendSection(ctx *agentops.AppContext, id, heading, content string) (*resource.Record, error) {
+	if cr.strat == nil {
+		return nil, fmt.Errorf("no strategy loaded")
+	}
+	return cr.updateCase(ctx, id, "section append", func(fm *Frontmatter, body string) (string, error) {
+		return appendSection(body, heading, content), nil
+	})
+}
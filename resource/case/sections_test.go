@@ -0,0 +1,137 @@
+package caseresource
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gh-xj/agentops/dal"
+)
+
+const sectionBody = "# Title\n\n## Findings\n\n- first\n\n```sh\n# not a heading\n```\n\n### Detail\n\nnested\n\n## Next Action\n\n## Close Criteria\n\ndone\n"
+
+func TestSectionContent(t *testing.T) {
+	got, ok := sectionContent(sectionBody, "Findings")
+	want := "- first\n\n```sh\n# not a heading\n```\n\n### Detail\n\nnested"
+	if !ok || got != want {
+		t.Errorf("Findings = %q (ok=%v), want %q", got, ok, want)
+	}
+	if got, ok := sectionContent(sectionBody, "## Next Action"); !ok || got != "" {
+		t.Errorf("Next Action = %q (ok=%v), want empty", got, ok)
+	}
+	if got, _ := sectionContent(sectionBody, "### Detail"); got != "nested" {
+		t.Errorf("Detail = %q, want nested", got)
+	}
+	if _, ok := sectionContent(sectionBody, "Missing"); ok {
+		t.Error("missing section should not be found")
+	}
+}
+
+func TestSetSection(t *testing.T) {
+	got := setSection(sectionBody, "Next Action", "\nrerun CI\n\n")
+	if !strings.Contains(got, "## Next Action\n\nrerun CI\n\n## Close Criteria\n\ndone\n") {
+		t.Errorf("set existing section, got:\n%s", got)
+	}
+	if !strings.HasPrefix(got, sectionBody[:strings.Index(sectionBody, "## Next Action")]) {
+		t.Errorf("earlier sections should be untouched, got:\n%s", got)
+	}
+
+	got = setSection(sectionBody, "Close Criteria", "")
+	if !strings.HasSuffix(got, "## Close Criteria\n") {
+		t.Errorf("clearing the last section, got:\n%s", got)
+	}
+
+	got = setSection("# Title", "Risks", "none known")
+	if got != "# Title\n\n## Risks\n\nnone known\n" {
+		t.Errorf("new section = %q", got)
+	}
+}
+
+func TestAppendSection(t *testing.T) {
+	got := appendSection(sectionBody, "Close Criteria", "- tests green")
+	if !strings.HasSuffix(got, "## Close Criteria\n\ndone\n- tests green\n") {
+		t.Errorf("append to existing, got:\n%s", got)
+	}
+	got = appendSection(sectionBody, "Next Action", "- retry")
+	if !strings.Contains(got, "## Next Action\n\n- retry\n\n## Close Criteria") {
+		t.Errorf("append to empty, got:\n%s", got)
+	}
+}
+
+func TestCaseResourceSections(t *testing.T) {
+	_, strat := setupTestProject(t)
+	cr := New(dal.NewFileSystem(), dal.NewExecutor(), strat)
+	ctx := testCtx()
+
+	created, err := cr.Create(ctx, "sections", nil)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	before, _ := os.ReadFile(created.RawPath)
+	frontmatter := string(before[:strings.Index(string(before), "# ")])
+
+	if _, err := cr.SetSection(ctx, created.ID, "Findings", "- flaky on arm64"); err != nil {
+		t.Fatalf("SetSection: %v", err)
+	}
+	if _, err := cr.AppendSection(ctx, created.ID, "## Findings", "- passes on retry"); err != nil {
+		t.Fatalf("AppendSection: %v", err)
+	}
+	got, err := cr.GetSection(ctx, created.ID, "Findings")
+	if err != nil {
+		t.Fatalf("GetSection: %v", err)
+	}
+	if got != "- flaky on arm64\n- passes on retry" {
+		t.Errorf("Findings = %q", got)
+	}
+
+	after, _ := os.ReadFile(created.RawPath)
+	if !strings.HasPrefix(string(after), frontmatter) {
+		t.Errorf("frontmatter changed:\n%s", after)
+	}
+	if _, err := cr.GetSection(ctx, created.ID, "Nope"); err == nil {
+		t.Error("GetSection of a missing section should fail")
+	}
+}
+
+func TestCaseResourceConcurrentAppends(t *testing.T) {
+	_, strat := setupTestProject(t)
+	cr := New(dal.NewFileSystem(), dal.NewExecutor(), strat)
+	ctx := testCtx()
+
+	created, err := cr.Create(ctx, "busy", nil)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	const writers = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := cr.AppendSection(ctx, created.ID, "Findings", fmt.Sprintf("- finding %d", i))
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("AppendSection: %v", err)
+		}
+	}
+
+	got, _ := cr.GetSection(ctx, created.ID, "Findings")
+	for i := 0; i < writers; i++ {
+		if !strings.Contains(got, fmt.Sprintf("- finding %d", i)) {
+			t.Errorf("finding %d lost:\n%s", i, got)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(created.RawPath), caseLockFile)); !os.IsNotExist(err) {
+		t.Errorf("case lock should be released, stat err = %v", err)
+	}
+}
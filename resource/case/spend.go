@@ -0,0 +1,170 @@
+package caseresource
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	agentops "github.com/gh-xj/agentops"
+	"github.com/gh-xj/agentops/budget"
+	"github.com/gh-xj/agentops/strategy"
+)
+
+// spendFile is the per-case ledger of recorded spend, one budget.Entry per line.
+const spendFile = "spend.jsonl"
+
+// RecordSpend appends entries to the spend ledger of case id, stamping each
+// with the time, the case and its type, and the caller's slot unless the
+// entry names one. Nothing is recorded when budget.yaml disables tracking.
+func (cr *CaseResource) RecordSpend(ctx *agentops.AppContext, id string, entries ...budget.Entry) error {
+	if cr.strat == nil {
+		return fmt.Errorf("no strategy loaded")
+	}
+	if cr.strat.Budget.Tracking.Disabled || len(entries) == 0 {
+		return nil
+	}
+	if err := cr.store.Sync(); err != nil {
+		return err
+	}
+	caseMDPath, err := cr.findCaseMD(id)
+	if err != nil {
+		return err
+	}
+	slot, err := cr.currentSlot(ctx)
+	if err != nil {
+		return fmt.Errorf("detect slot: %w", err)
+	}
+
+	fm, err := cr.appendSpend(caseMDPath, id, slot, entries)
+	if err != nil {
+		return err
+	}
+	return cr.commit(id, caseMDPath, cr.newHistoryEntry(ctx, "spend", fm.Status, fm.Status))
+}
+
+// appendSpend writes entries to the ledger under the case lock.
+func (cr *CaseResource) appendSpend(caseMDPath, id, slot string, entries []budget.Entry) (Frontmatter, error) {
+	caseDir := filepath.Dir(caseMDPath)
+	unlock, err := cr.lockCase(caseDir)
+	if err != nil {
+		return Frontmatter{}, err
+	}
+	defer unlock()
+
+	data, err := cr.fs.ReadFile(caseMDPath)
+	if err != nil {
+		return Frontmatter{}, fmt.Errorf("read case.md: %w", err)
+	}
+	fm, _, err := ParseFrontmatter(string(data))
+	if err != nil {
+		return Frontmatter{}, fmt.Errorf("parse frontmatter: %w", err)
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	for _, e := range entries {
+		e.Timestamp, e.Case, e.CaseType = now, id, fm.Type
+		if e.Slot == "" {
+			e.Slot = slot
+		}
+		if err := cr.appendJSONL(filepath.Join(caseDir, spendFile), e); err != nil {
+			return Frontmatter{}, err
+		}
+	}
+	return fm, nil
+}
+
+// Spend returns the spend recorded against every case, archived cases
+// included, so pruning does not lower per_slot totals.
+func (cr *CaseResource) Spend(ctx *agentops.AppContext) ([]budget.Entry, error) {
+	if cr.strat == nil {
+		return nil, fmt.Errorf("no strategy loaded")
+	}
+	cases, err := cr.cases.Cases()
+	if err != nil {
+		return nil, err
+	}
+	var all []budget.Entry
+	for _, c := range cases {
+		entries, err := readJSONL[budget.Entry](cr.fs, filepath.Join(c.Dir, spendFile))
+		if err != nil {
+			return nil, fmt.Errorf("case %s: %w", c.ID, err)
+		}
+		all = append(all, entries...)
+	}
+	archived, err := cr.archivedSpend()
+	if err != nil {
+		return nil, err
+	}
+	return append(all, archived...), nil
+}
+
+// archivedSpend reads the spend ledgers of the cases in the archive manifest,
+// from their archived directories or tarballs.
+func (cr *CaseResource) archivedSpend() ([]budget.Entry, error) {
+	casesRoot, err := cr.casesDir()
+	if err != nil {
+		return nil, err
+	}
+	archived, err := readJSONL[ArchiveEntry](cr.fs, filepath.Join(casesRoot, archiveDir, manifestFile))
+	if err != nil {
+		return nil, err
+	}
+	var all []budget.Entry
+	for _, a := range archived {
+		path := filepath.Join(casesRoot, a.Path)
+		var entries []budget.Entry
+		if a.Format == strategy.ArchiveFormatTarGz {
+			var data []byte
+			if data, err = readTarGzFile(path, a.ID+"/"+spendFile); err == nil {
+				entries, err = parseJSONL[budget.Entry](spendFile, data)
+			}
+		} else {
+			entries, err = readJSONL[budget.Entry](cr.fs, filepath.Join(path, spendFile))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("archived case %s: %w", a.ID, err)
+		}
+		all = append(all, entries...)
+	}
+	return all, nil
+}
+
+// CheckBudget fails with ExitBudgetExceeded when case id has reached a
+// per_case limit of budget.yaml, or the caller's slot a per_slot limit over
+// all cases. An empty id checks the slot only.
+func (cr *CaseResource) CheckBudget(ctx *agentops.AppContext, id string) error {
+	entries, err := cr.Spend(ctx)
+	if err != nil {
+		return err
+	}
+	limits := cr.strat.Budget.Limits
+
+	var reached []string
+	if id != "" {
+		hit, err := budget.Exhausted(limits.PerCase, budget.Sum(entries, func(e budget.Entry) bool { return e.Case == id }))
+		if err != nil {
+			return fmt.Errorf("budget.yaml per_case: %w", err)
+		}
+		if len(hit) > 0 {
+			reached = append(reached, fmt.Sprintf("case %s: %s", id, strings.Join(hit, ", ")))
+		}
+	}
+	slot, err := cr.currentSlot(ctx)
+	if err != nil {
+		return fmt.Errorf("detect slot: %w", err)
+	}
+	if slot != "" {
+		hit, err := budget.Exhausted(limits.PerSlot, budget.Sum(entries, func(e budget.Entry) bool { return e.Slot == slot }))
+		if err != nil {
+			return fmt.Errorf("budget.yaml per_slot: %w", err)
+		}
+		if len(hit) > 0 {
+			reached = append(reached, fmt.Sprintf("slot %s: %s", slot, strings.Join(hit, ", ")))
+		}
+	}
+	if len(reached) > 0 {
+		return agentops.NewCLIError(agentops.ExitBudgetExceeded, "budget_exceeded",
+			"budget limit reached: "+strings.Join(reached, "; "), nil)
+	}
+	return nil
+}
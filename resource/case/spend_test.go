@@ -0,0 +1,136 @@
+package caseresource
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	agentops "github.com/gh-xj/agentops"
+	"github.com/gh-xj/agentops/budget"
+	"github.com/gh-xj/agentops/dal"
+)
+
+func TestCaseResourceRecordSpend(t *testing.T) {
+	root, strat := setupTestProject(t)
+	writeWorker(t, root, "review", "worker-type: review\nsidecar-path: review.json\n")
+	cr := New(dal.NewFileSystem(), dal.NewExecutor(), strat)
+	ctx := slotCtx("alpha")
+	created, err := cr.Create(ctx, "spend-me", nil)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	if err := cr.RecordSpend(ctx, created.ID, budget.Entry{Source: budget.SourceLoop, LoopIterations: 3, WallClockMS: 1500}); err != nil {
+		t.Fatalf("RecordSpend: %v", err)
+	}
+	writeSidecar(t, created, "review.json", `{"summary": "fine", "findings": [], "usage": {"tokens": 1200, "wall_clock_seconds": 30}}`)
+	if _, err := cr.Reconcile(ctx, created.ID); err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+
+	entries, err := cr.Spend(ctx)
+	if err != nil {
+		t.Fatalf("Spend: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("entries = %+v, want loop and worker spend", entries)
+	}
+	for _, e := range entries {
+		if e.Case != created.ID || e.CaseType != "intake" || e.Slot != "alpha" || e.Time().IsZero() {
+			t.Errorf("entry not stamped with case, type, slot and time: %+v", e)
+		}
+	}
+	if w := entries[1]; w.Source != budget.SourceWorker || w.Worker != "review" || w.WorkerInvocations != 1 || w.Tokens != 1200 || w.WallClockMS != 30000 {
+		t.Errorf("worker entry = %+v", w)
+	}
+	if tot := budget.Sum(entries, nil); tot.LoopIterations != 3 || tot.WorkerInvocations != 1 {
+		t.Errorf("totals = %+v", tot)
+	}
+}
+
+func TestCaseResourceRecordSpendTrackingDisabled(t *testing.T) {
+	_, strat := setupTestProject(t)
+	strat.Budget.Tracking.Disabled = true
+	cr := New(dal.NewFileSystem(), dal.NewExecutor(), strat)
+	ctx := testCtx()
+	id := createCases(t, cr, "untracked")[0]
+
+	if err := cr.RecordSpend(ctx, id, budget.Entry{Source: budget.SourceDispatch, WallClockMS: 10}); err != nil {
+		t.Fatalf("RecordSpend: %v", err)
+	}
+	if entries, _ := cr.Spend(ctx); len(entries) != 0 {
+		t.Errorf("entries = %+v, want none", entries)
+	}
+}
+
+func TestCaseResourceCheckBudget(t *testing.T) {
+	_, strat := setupTestProject(t)
+	strat.Budget.Limits.PerCase.LoopIterations = 5
+	strat.Budget.Limits.PerSlot.WallClock = "1m"
+	cr := New(dal.NewFileSystem(), dal.NewExecutor(), strat)
+	ctx := slotCtx("alpha")
+	ids := createCases(t, cr, "first", "second")
+
+	if err := cr.CheckBudget(ctx, ids[0]); err != nil {
+		t.Fatalf("CheckBudget before spend: %v", err)
+	}
+	if err := cr.RecordSpend(ctx, ids[0], budget.Entry{Source: budget.SourceLoop, LoopIterations: 5}); err != nil {
+		t.Fatal(err)
+	}
+	err := cr.CheckBudget(ctx, ids[0])
+	var cliErr *agentops.CLIError
+	if !errors.As(err, &cliErr) || cliErr.Code != agentops.ExitBudgetExceeded {
+		t.Fatalf("CheckBudget = %v, want ExitBudgetExceeded", err)
+	}
+	if !strings.Contains(err.Error(), "case "+ids[0]+": loop_iterations 5/5") {
+		t.Errorf("error = %v", err)
+	}
+	if err := cr.CheckBudget(ctx, ids[1]); err != nil {
+		t.Errorf("other case: %v", err)
+	}
+
+	// The slot limit covers every case the slot worked on.
+	if err := cr.RecordSpend(ctx, ids[1], budget.Entry{Source: budget.SourceDispatch, WallClockMS: 60000}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cr.CheckBudget(ctx, ids[1]); err == nil || !strings.Contains(err.Error(), "slot alpha: wall_clock 1m0s/1m") {
+		t.Errorf("CheckBudget after slot spend = %v", err)
+	}
+	if err := cr.CheckBudget(slotCtx("beta"), ids[1]); err != nil {
+		t.Errorf("other slot: %v", err)
+	}
+}
+
+func TestCaseResourceSpendIncludesArchivedCases(t *testing.T) {
+	for _, format := range []string{"dir", "tar.gz"} {
+		t.Run(format, func(t *testing.T) {
+			_, cr := setupRetentionProject(t, "  format: "+format+"\n")
+			cr.strat.Budget.Limits.PerSlot.WallClock = "1m"
+			ctx := slotCtx("alpha")
+			ids := createCases(t, cr, "archived", "active")
+			for _, id := range ids {
+				if err := cr.RecordSpend(ctx, id, budget.Entry{Source: budget.SourceDispatch, WallClockMS: 30000}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if _, err := cr.Transition(ctx, ids[0], "close_no_action"); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := cr.Archive(ctx, ids[0]); err != nil {
+				t.Fatalf("Archive: %v", err)
+			}
+
+			entries, err := cr.Spend(ctx)
+			if err != nil {
+				t.Fatalf("Spend: %v", err)
+			}
+			if tot := budget.Sum(entries, func(e budget.Entry) bool { return e.Case == ids[0] }); tot.WallClock != 30*time.Second {
+				t.Errorf("archived case spend = %+v, want it kept", tot)
+			}
+			if err := cr.CheckBudget(ctx, ids[1]); err == nil || !strings.Contains(err.Error(), "slot alpha: wall_clock 1m0s/1m") {
+				t.Errorf("per_slot should count the archived case's spend, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,296 @@
+package caseresource
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	agentops "github.com/gh-xj/agentops"
+	"github.com/gh-xj/agentops/dal"
+	"github.com/gh-xj/agentops/strategy"
+)
+
+// Storage holds the case directories and shares writes to them between
+// slots. Writers call Sync before reading a case they are about to change
+// and Commit once the change is on disk.
+type Storage interface {
+	// Root returns the directory holding the cases.
+	Root() string
+	// Sync brings the cases up to date with writes made by other slots.
+	Sync() error
+	// Commit records a change to a case so other slots can see it.
+	Commit(c Change) error
+}
+
+// Change describes one write to a case, or several recorded together.
+type Change struct {
+	CaseID  string
+	Path    string // case.md after the write
+	Entry   HistoryEntry
+	Subject string // first line of the message; "case <action>: <id>" when empty
+}
+
+// Message returns the structured commit message for the change:
+//
+//	<subject>
+//
+//	case: <id>
+//	action: <action>
+//	status: <from> -> <to>
+//	slot: <slot>
+//	actor: <actor>
+//	reason: <reason>
+func (c Change) Message() string {
+	var b strings.Builder
+	if c.Subject != "" {
+		fmt.Fprintf(&b, "%s\n\n", c.Subject)
+	} else {
+		fmt.Fprintf(&b, "case %s: %s\n\n", c.Entry.Action, c.CaseID)
+	}
+	fmt.Fprintf(&b, "case: %s\n", c.CaseID)
+	fmt.Fprintf(&b, "action: %s\n", c.Entry.Action)
+	switch {
+	case c.Entry.From == "" || c.Entry.From == c.Entry.To:
+		fmt.Fprintf(&b, "status: %s\n", c.Entry.To)
+	default:
+		fmt.Fprintf(&b, "status: %s -> %s\n", c.Entry.From, c.Entry.To)
+	}
+	fmt.Fprintf(&b, "slot: %s\n", orNone(c.Entry.Slot))
+	fmt.Fprintf(&b, "actor: %s\n", orNone(c.Entry.Actor))
+	if c.Entry.Reason != "" {
+		fmt.Fprintf(&b, "reason: %s\n", strings.Join(strings.Fields(c.Entry.Reason), " "))
+	}
+	return b.String()
+}
+
+// NewStorage returns the storage backend configured in storage.yaml. in-repo
+// cases live in the project's own repository and are committed with the rest
+// of its changes; separate-repo cases live in their own git repository, which
+// is committed, pulled and pushed on every write, or once per batch of writes
+// made under DeferCommits.
+func NewStorage(fs dal.FileSystem, exec dal.Executor, strat *strategy.Strategy) Storage {
+	if strat.Storage.Backend == strategy.BackendInRepo {
+		return &localStorage{root: filepath.Join(strat.Root, "cases")}
+	}
+	caseRepoPath := strat.Storage.CaseRepoPath
+	if caseRepoPath == "" {
+		caseRepoPath = filepath.Join("..", filepath.Base(strat.Root)+"-cases")
+	}
+	// Resolve relative to the project root.
+	if !filepath.IsAbs(caseRepoPath) {
+		caseRepoPath = filepath.Join(strat.Root, caseRepoPath)
+	}
+	return &gitStorage{repo: caseRepoPath, fs: fs, exec: exec}
+}
+
+// localStorage is a plain directory with no sharing of its own.
+type localStorage struct {
+	root string
+}
+
+func (s *localStorage) Root() string        { return s.root }
+func (s *localStorage) Sync() error         { return nil }
+func (s *localStorage) Commit(Change) error { return nil }
+
+// gitStorage keeps cases in cases/ of a dedicated git repository. Every write
+// is committed; when the branch tracks an upstream, Sync pulls with rebase
+// and Commit pushes, replaying the commit onto any newer upstream work. A
+// case repository that is not (yet) a git repository is used as a plain
+// directory.
+type gitStorage struct {
+	repo string
+	fs   dal.FileSystem
+	exec dal.Executor
+}
+
+func (s *gitStorage) Root() string { return filepath.Join(s.repo, "cases") }
+
+func (s *gitStorage) Sync() error {
+	if _, ok := s.upstream(); !s.isRepo() || !ok {
+		return nil
+	}
+	if _, err := s.git("pull", "--rebase", "--autostash", "--quiet"); err != nil {
+		_, _ = s.git("rebase", "--abort")
+		return agentops.NewCLIError(agentops.ExitStorageConflict, "storage_conflict",
+			fmt.Sprintf("cannot bring case repository %s up to date; resolve it with git and retry", s.repo), err)
+	}
+	return nil
+}
+
+func (s *gitStorage) Commit(c Change) error {
+	if !s.isRepo() {
+		return nil
+	}
+	// Lock files of writes still in progress are never committed.
+	paths := []string{"--", "cases", ":(exclude,glob)**/" + caseLockFile}
+	status, err := s.git(append([]string{"status", "--porcelain"}, paths...)...)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(status) == "" {
+		return nil
+	}
+	if _, err := s.git(append([]string{"add", "-A"}, paths...)...); err != nil {
+		return err
+	}
+	if _, err := s.git("commit", "--quiet", "-m", c.Message()); err != nil {
+		return err
+	}
+	upstream, ok := s.upstream()
+	if !ok {
+		return nil
+	}
+	if _, err := s.git("push", "--quiet"); err == nil {
+		return nil
+	}
+
+	// Another slot pushed first: replay this commit on top of its work.
+	if _, err := s.git("pull", "--rebase", "--quiet"); err != nil {
+		remote := s.statusAt(upstream, c.Path)
+		_, _ = s.git("rebase", "--abort")
+		msg := fmt.Sprintf("case %q was changed by another slot", c.CaseID)
+		if remote != "" && remote != c.Entry.To {
+			msg = fmt.Sprintf("case %q: conflicting status edits: this slot set %s, another slot set %s", c.CaseID, c.Entry.To, remote)
+		}
+		return agentops.NewCLIError(agentops.ExitStorageConflict, "storage_conflict",
+			msg+"; the local commit is kept unpushed in "+s.repo, err)
+	}
+	if _, err := s.git("push", "--quiet"); err != nil {
+		return fmt.Errorf("push case repository: %w", err)
+	}
+	return nil
+}
+
+// revision returns the commit the case repository is at, or "" when it is
+// not a git repository.
+func (s *gitStorage) revision() string {
+	if !s.isRepo() {
+		return ""
+	}
+	out, err := s.git("rev-parse", "HEAD")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(out)
+}
+
+// isRepo reports whether the case repository is a git work tree.
+func (s *gitStorage) isRepo() bool {
+	return s.fs.Exists(filepath.Join(s.repo, ".git"))
+}
+
+// upstream returns the branch the current branch pulls from and pushes to,
+// if it has one.
+func (s *gitStorage) upstream() (string, bool) {
+	out, err := s.git("rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{u}")
+	if err != nil {
+		return "", false
+	}
+	return strings.TrimSpace(out), true
+}
+
+// statusAt returns the status of case.md at path in rev, or "" when it cannot
+// be read.
+func (s *gitStorage) statusAt(rev, path string) string {
+	rel, err := filepath.Rel(s.repo, path)
+	if err != nil {
+		return ""
+	}
+	out, err := s.git("show", rev+":"+filepath.ToSlash(rel))
+	if err != nil {
+		return ""
+	}
+	fm, _, err := ParseFrontmatter(out)
+	if err != nil {
+		return ""
+	}
+	return fm.Status
+}
+
+// git runs a git command in the case repository. A failure is reported with
+// ExitStorageFailed rather than git's own exit status.
+func (s *gitStorage) git(args ...string) (string, error) {
+	out, err := s.exec.RunInDir(s.repo, "git", args...)
+	if err != nil {
+		return "", agentops.NewCLIError(agentops.ExitStorageFailed, "storage_failed",
+			fmt.Sprintf("git %s in case repository %s", strings.Join(args, " "), s.repo), err)
+	}
+	return out, nil
+}
+
+// commit hands a completed write to the storage backend and the case index.
+// While commits are deferred the write is folded into the pending change
+// instead of being committed on its own.
+func (cr *CaseResource) commit(id, caseMDPath string, entry HistoryEntry) error {
+	change := Change{CaseID: id, Path: caseMDPath, Entry: entry}
+	switch {
+	case cr.deferred == 0:
+		if err := cr.store.Commit(change); err != nil {
+			return err
+		}
+	case cr.pending == nil:
+		cr.pending = &change
+	default:
+		cr.pending.Path = caseMDPath
+		cr.pending.Entry.To = entry.To
+		if entry.Reason != "" {
+			cr.pending.Entry.Reason = entry.Reason
+		}
+	}
+	return cr.refreshIndex(id)
+}
+
+// DeferCommits holds back the storage commits of case writes until the
+// returned resume is called, so that an operation making several writes, such
+// as a dispatch cycle, records them as one change with CommitDeferred. Writes
+// still update the case index. Calls nest; writes left uncommitted when the
+// outermost resume is called are committed with the next write.
+func (cr *CaseResource) DeferCommits() (resume func()) {
+	cr.deferred++
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			cr.deferred--
+			if cr.deferred == 0 {
+				cr.pending = nil
+			}
+		})
+	}
+}
+
+// CommitDeferred commits the writes held back by DeferCommits as one change
+// recorded as action, with subject, when set, as the first line of its
+// message. It does nothing when no write is pending.
+func (cr *CaseResource) CommitDeferred(action, subject string) error {
+	if cr.pending == nil {
+		return nil
+	}
+	change := *cr.pending
+	cr.pending = nil
+	change.Entry.Action = action
+	change.Subject = subject
+	return cr.store.Commit(change)
+}
+
+// batch runs fn with commits deferred and commits its writes as one change
+// recorded as action. Inside an enclosing batch the writes are left to it.
+func (cr *CaseResource) batch(action string, fn func() error) error {
+	if cr.deferred > 0 {
+		return fn()
+	}
+	resume := cr.DeferCommits()
+	defer resume()
+	err := fn()
+	if cerr := cr.CommitDeferred(action, ""); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
@@ -0,0 +1,196 @@
+package caseresource
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	agentops "github.com/gh-xj/agentops"
+	"github.com/gh-xj/agentops/dal"
+	"github.com/gh-xj/agentops/strategy"
+)
+
+// git runs a git command in dir, failing the test on error.
+func git(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return string(out)
+}
+
+// setupSharedCaseRepo creates a bare case repository and returns a case
+// resource for each named slot, each with its own project and its own clone
+// of the case repository.
+func setupSharedCaseRepo(t *testing.T, slots ...string) map[string]*CaseResource {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	tmp := t.TempDir()
+	bare := filepath.Join(tmp, "cases.git")
+	git(t, tmp, "init", "--quiet", "--bare", "--initial-branch=main", bare)
+
+	seed := filepath.Join(tmp, "seed")
+	git(t, tmp, "clone", "--quiet", bare, seed)
+	git(t, seed, "config", "user.email", "seed@example.com")
+	git(t, seed, "config", "user.name", "seed")
+	if err := os.WriteFile(filepath.Join(seed, "README.md"), []byte("cases\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	git(t, seed, "add", "README.md")
+	git(t, seed, "commit", "--quiet", "-m", "init")
+	git(t, seed, "push", "--quiet", "origin", "HEAD:main")
+
+	resources := map[string]*CaseResource{}
+	for _, slot := range slots {
+		clone := filepath.Join(tmp, slot+"-cases")
+		git(t, tmp, "clone", "--quiet", bare, clone)
+		git(t, clone, "config", "user.email", slot+"@example.com")
+		git(t, clone, "config", "user.name", slot)
+
+		project := filepath.Join(tmp, slot)
+		if err := strategy.Bootstrap(project); err != nil {
+			t.Fatalf("bootstrap: %v", err)
+		}
+		storage := "backend: separate-repo\ncase_repo_path: ../" + slot + "-cases\n"
+		if err := os.WriteFile(filepath.Join(project, ".agentops", "storage.yaml"), []byte(storage), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		strat, err := strategy.Discover(project)
+		if err != nil {
+			t.Fatalf("discover: %v", err)
+		}
+		resources[slot] = New(dal.NewFileSystem(), dal.NewExecutor(), strat)
+	}
+	return resources
+}
+
+func slotRepo(cr *CaseResource) string {
+	return filepath.Dir(cr.store.Root())
+}
+
+func TestGitStorageCommitsAndSharesWrites(t *testing.T) {
+	slots := setupSharedCaseRepo(t, "alpha", "beta")
+	alpha, beta := slots["alpha"], slots["beta"]
+
+	created, err := alpha.Create(slotCtx("alpha"), "shared", nil)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	msg := git(t, slotRepo(alpha), "log", "-1", "--format=%B")
+	for _, want := range []string{"case create: " + created.ID, "action: create", "status: open", "slot: alpha"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("create commit message missing %q:\n%s", want, msg)
+		}
+	}
+
+	// beta pulls before writing, so it sees the case alpha pushed.
+	if _, err := beta.Transition(slotCtx("beta"), created.ID, "start"); err != nil {
+		t.Fatalf("transition from the other slot: %v", err)
+	}
+	msg = git(t, slotRepo(beta), "log", "-1", "--format=%B")
+	if !strings.Contains(msg, "status: open -> in_progress") || !strings.Contains(msg, "slot: beta") {
+		t.Errorf("transition commit message:\n%s", msg)
+	}
+
+	if _, err := alpha.AppendSection(slotCtx("alpha"), created.ID, "Findings", "from alpha"); err != nil {
+		t.Fatalf("append section: %v", err)
+	}
+	rec, err := alpha.Get(testCtx(), created.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Fields["status"] != "in_progress" {
+		t.Errorf("alpha sees status %v, want in_progress", rec.Fields["status"])
+	}
+	if status := git(t, slotRepo(alpha), "status", "--porcelain"); strings.TrimSpace(status) != "" {
+		t.Errorf("alpha's case repository has uncommitted changes:\n%s", status)
+	}
+}
+
+func TestGitStorageDetectsConflictingStatusEdits(t *testing.T) {
+	slots := setupSharedCaseRepo(t, "alpha", "beta")
+	alpha, beta := slots["alpha"], slots["beta"]
+
+	created, err := alpha.Create(slotCtx("alpha"), "contested", nil)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if err := beta.store.Sync(); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+
+	// beta blocks the case locally while alpha starts it and pushes first.
+	caseMD := filepath.Join(beta.store.Root(), created.ID, "case.md")
+	data, err := os.ReadFile(caseMD)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(caseMD, []byte(strings.Replace(string(data), "status: open", "status: blocked", 1)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := alpha.Transition(slotCtx("alpha"), created.ID, "start"); err != nil {
+		t.Fatalf("transition: %v", err)
+	}
+
+	err = beta.store.Commit(Change{
+		CaseID: created.ID,
+		Path:   caseMD,
+		Entry:  HistoryEntry{Action: "block", From: "open", To: "blocked", Slot: "beta"},
+	})
+	var cliErr *agentops.CLIError
+	if !errors.As(err, &cliErr) || cliErr.Code != agentops.ExitStorageConflict {
+		t.Fatalf("err = %v, want storage conflict", err)
+	}
+	if !strings.Contains(err.Error(), "this slot set blocked, another slot set in_progress") {
+		t.Errorf("err = %v, want both statuses", err)
+	}
+	if _, err := os.Stat(filepath.Join(slotRepo(beta), ".git", "rebase-merge")); err == nil {
+		t.Error("the failed rebase was not aborted")
+	}
+	if msg := git(t, slotRepo(beta), "log", "-1", "--format=%s"); !strings.HasPrefix(msg, "case block: ") {
+		t.Errorf("beta's local commit was lost; HEAD is %q", msg)
+	}
+}
+
+func TestSeparateRepoWithoutGitIsPlainDirectory(t *testing.T) {
+	root, _ := setupTestProject(t)
+	storage := "backend: separate-repo\ncase_repo_path: case-store\n"
+	if err := os.WriteFile(filepath.Join(root, ".agentops", "storage.yaml"), []byte(storage), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	strat, err := strategy.Discover(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cr := New(dal.NewFileSystem(), dal.NewExecutor(), strat)
+
+	rec, err := cr.Create(testCtx(), "local", nil)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if want := filepath.Join(root, "case-store", "cases", rec.ID, "case.md"); rec.RawPath != want {
+		t.Errorf("RawPath = %s, want %s", rec.RawPath, want)
+	}
+	if _, err := cr.Transition(testCtx(), rec.ID, "start"); err != nil {
+		t.Errorf("transition: %v", err)
+	}
+}
+
+func TestChangeMessage(t *testing.T) {
+	c := Change{
+		CaseID: "CASE-20260321-x",
+		Entry:  HistoryEntry{Action: "resolve", From: "in_progress", To: "resolved", Actor: "alice", Reason: "fixed\nupstream"},
+	}
+	want := "case resolve: CASE-20260321-x\n\ncase: CASE-20260321-x\naction: resolve\nstatus: in_progress -> resolved\nslot: none\nactor: alice\nreason: fixed upstream\n"
+	if got := c.Message(); got != want {
+		t.Errorf("Message() =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,524 @@
+package caseresource
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	agentops "github.com/gh-xj/agentops"
+	"github.com/gh-xj/agentops/resource"
+	"github.com/gh-xj/agentops/strategy"
+
+	_ "modernc.org/sqlite" // registers the pure Go "sqlite" driver
+)
+
+// CaseStore answers queries over the working set of cases. The markdown tree
+// is the source of truth: every write goes to case.md and history.jsonl, and
+// a store only ever reads them or holds a copy.
+type CaseStore interface {
+	// Cases returns the frontmatter of every case in the working set.
+	Cases() ([]StoredCase, error)
+	// History returns the recorded status changes of case id, oldest first.
+	History(id string) ([]HistoryEntry, error)
+}
+
+// CaseIndex is a CaseStore holding a copy of the markdown tree that must be
+// kept up to date with it.
+type CaseIndex interface {
+	CaseStore
+	// Refresh re-reads case id from markdown, dropping it from the index
+	// when it has left the working set.
+	Refresh(id string) error
+	// Rebuild replaces the index with the markdown tree and returns the
+	// number of cases indexed.
+	Rebuild() (int, error)
+}
+
+// StoredCase is one case as returned by a CaseStore.
+type StoredCase struct {
+	ID          string
+	Dir         string // case directory
+	Group       string // empty for cases stored flat
+	Slot        string // empty for cases stored flat
+	Frontmatter Frontmatter
+}
+
+var _ resource.Reindexer = (*CaseResource)(nil)
+
+// newCaseStore returns the store configured by storage.yaml's index key.
+func newCaseStore(cr *CaseResource) CaseStore {
+	md := &markdownStore{cr: cr}
+	if cr.strat.Storage.Index != strategy.IndexSQLite {
+		return md
+	}
+	return &sqliteIndex{
+		path:     filepath.Join(cr.strat.Root, ".agentops", sqliteIndexFile),
+		source:   md,
+		revision: cr.indexRevision,
+	}
+}
+
+// indexRevision names the version of the cases an index is built from: the
+// commit of the case repository, when the storage has one, and the commit of
+// the project. Either is empty outside git.
+func (cr *CaseResource) indexRevision() string {
+	storage := ""
+	if r, ok := cr.store.(revisioned); ok {
+		storage = r.revision()
+	}
+	project, err := cr.exec.RunInDir(cr.strat.Root, "git", "rev-parse", "HEAD")
+	if err != nil {
+		project = ""
+	}
+	return storage + " " + strings.TrimSpace(project)
+}
+
+// Reindex rebuilds the case index from the markdown tree, picking up edits
+// made by hand or outside agentops, and returns the number of cases indexed.
+func (cr *CaseResource) Reindex(ctx *agentops.AppContext) (int, error) {
+	if cr.strat == nil {
+		return 0, fmt.Errorf("no strategy loaded")
+	}
+	idx, ok := cr.cases.(CaseIndex)
+	if !ok {
+		return 0, agentops.NewCLIError(agentops.ExitUsage, "no_index",
+			"no case index is configured; set index: "+strategy.IndexSQLite+" in .agentops/storage.yaml", nil)
+	}
+	if err := cr.store.Sync(); err != nil {
+		return 0, err
+	}
+	return idx.Rebuild()
+}
+
+// refreshIndex brings the index entry of case id up to date after a write.
+func (cr *CaseResource) refreshIndex(id string) error {
+	idx, ok := cr.cases.(CaseIndex)
+	if !ok {
+		return nil
+	}
+	if err := idx.Refresh(id); err != nil {
+		return fmt.Errorf("update case index (case.md is written; run `case reindex`): %w", err)
+	}
+	return nil
+}
+
+// markdownStore reads every query straight from the cases directory.
+type markdownStore struct {
+	cr *CaseResource
+}
+
+func (s *markdownStore) Cases() ([]StoredCase, error) {
+	casesRoot, err := s.cr.casesDir()
+	if err != nil {
+		return nil, err
+	}
+	locs, err := s.cr.scanCases(casesRoot)
+	if err != nil {
+		// A missing cases directory holds no cases.
+		return nil, nil
+	}
+	cases := make([]StoredCase, 0, len(locs))
+	for _, loc := range locs {
+		fm, err := s.cr.readFrontmatter(loc)
+		if err != nil {
+			continue
+		}
+		cases = append(cases, StoredCase{ID: loc.ID, Dir: loc.Dir, Group: loc.Group, Slot: loc.Slot, Frontmatter: fm})
+	}
+	return cases, nil
+}
+
+func (s *markdownStore) History(id string) ([]HistoryEntry, error) {
+	loc, err := s.cr.locate(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.cr.readHistory(loc.Dir)
+}
+
+// sqliteIndexFile is the index database under .agentops/. It is local to the
+// checkout and never committed.
+const sqliteIndexFile = "cases.db"
+
+const sqliteIndexSchema = `
+CREATE TABLE IF NOT EXISTS cases (
+	id          TEXT PRIMARY KEY,
+	dir         TEXT NOT NULL,
+	grp         TEXT NOT NULL,
+	slot        TEXT NOT NULL,
+	status      TEXT NOT NULL,
+	frontmatter TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS cases_status ON cases (status);
+CREATE TABLE IF NOT EXISTS history (
+	case_id     TEXT NOT NULL,
+	seq         INTEGER NOT NULL,
+	timestamp   TEXT NOT NULL,
+	action      TEXT NOT NULL,
+	from_status TEXT NOT NULL,
+	to_status   TEXT NOT NULL,
+	actor       TEXT NOT NULL,
+	slot        TEXT NOT NULL,
+	reason      TEXT NOT NULL,
+	PRIMARY KEY (case_id, seq)
+);
+CREATE TABLE IF NOT EXISTS meta (
+	key   TEXT PRIMARY KEY,
+	value TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS stamps (
+	id    TEXT PRIMARY KEY,
+	stamp TEXT NOT NULL
+);
+`
+
+// revisioned is implemented by storage backends that can name the version
+// of the cases on disk, so an index can tell when another slot's writes were
+// pulled in.
+type revisioned interface {
+	revision() string
+}
+
+// sqliteIndex holds the frontmatter and history of every case in a SQLite
+// database. It is built from markdown on first use, and rebuilt whenever the
+// revision differs from the one it was built at or a case directory no
+// longer matches its recorded stamp, as after an edit made by hand.
+type sqliteIndex struct {
+	path     string
+	source   *markdownStore
+	revision func() string
+}
+
+func (x *sqliteIndex) Cases() ([]StoredCase, error) {
+	db, err := x.open()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`SELECT id, dir, grp, slot, frontmatter FROM cases ORDER BY grp, slot, id`)
+	if err != nil {
+		return nil, fmt.Errorf("query case index: %w", err)
+	}
+	defer rows.Close()
+
+	root := x.source.cr.store.Root()
+	var cases []StoredCase
+	for rows.Next() {
+		var c StoredCase
+		var raw string
+		if err := rows.Scan(&c.ID, &c.Dir, &c.Group, &c.Slot, &raw); err != nil {
+			return nil, fmt.Errorf("read case index: %w", err)
+		}
+		if c.Frontmatter, _, err = ParseFrontmatter(raw); err != nil {
+			return nil, fmt.Errorf("case index: %s: %w", c.ID, err)
+		}
+		c.Dir = filepath.Join(root, filepath.FromSlash(c.Dir))
+		cases = append(cases, c)
+	}
+	return cases, rows.Err()
+}
+
+func (x *sqliteIndex) History(id string) ([]HistoryEntry, error) {
+	db, err := x.open()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	var indexed int
+	if err := db.QueryRow(`SELECT count(*) FROM cases WHERE id = ?`, id).Scan(&indexed); err != nil {
+		return nil, fmt.Errorf("query case index: %w", err)
+	}
+	if indexed == 0 {
+		// Let the markdown tree explain why the case is not there.
+		return x.source.History(id)
+	}
+
+	rows, err := db.Query(`SELECT timestamp, action, from_status, to_status, actor, slot, reason
+		FROM history WHERE case_id = ? ORDER BY seq`, id)
+	if err != nil {
+		return nil, fmt.Errorf("query case index: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []HistoryEntry
+	for rows.Next() {
+		var e HistoryEntry
+		if err := rows.Scan(&e.Timestamp, &e.Action, &e.From, &e.To, &e.Actor, &e.Slot, &e.Reason); err != nil {
+			return nil, fmt.Errorf("read case index: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// Refresh leaves the recorded revision alone: a stale index is rebuilt on
+// its next query regardless. It records the new stamp of the case, so the
+// write it follows does not make the index look stale.
+func (x *sqliteIndex) Refresh(id string) error {
+	db, err := x.openDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := deleteIndexed(tx, id); err != nil {
+		return err
+	}
+	cr := x.source.cr
+	if loc, err := cr.locate(id); err == nil {
+		fm, err := cr.readFrontmatter(loc)
+		if err != nil {
+			return err
+		}
+		c := StoredCase{ID: loc.ID, Dir: loc.Dir, Group: loc.Group, Slot: loc.Slot, Frontmatter: fm}
+		if err := x.insert(tx, c); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`INSERT INTO stamps (id, stamp) VALUES (?, ?)`, id, caseStamp(loc.Dir)); err != nil {
+			return fmt.Errorf("update case index: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+func (x *sqliteIndex) Rebuild() (int, error) {
+	if err := ignoreIndex(filepath.Dir(x.path)); err != nil {
+		return 0, err
+	}
+	db, err := x.openDB()
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+	return x.rebuild(db)
+}
+
+// open opens the index, building or rebuilding it from markdown when it is
+// new or out of date.
+func (x *sqliteIndex) open() (*sql.DB, error) {
+	db, err := x.openDB()
+	if err != nil {
+		return nil, err
+	}
+	var built string
+	err = db.QueryRow(`SELECT value FROM meta WHERE key = 'revision'`).Scan(&built)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+	case err != nil:
+		_ = db.Close()
+		return nil, fmt.Errorf("read case index: %w", err)
+	case built == x.revision():
+		fresh, err := x.fresh(db)
+		if err != nil {
+			_ = db.Close()
+			return nil, err
+		}
+		if fresh {
+			return db, nil
+		}
+	}
+	if _, err := x.rebuild(db); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// openDB opens the database and creates its tables, without checking that
+// they are up to date. Creating the database also keeps it out of git.
+func (x *sqliteIndex) openDB() (*sql.DB, error) {
+	if _, err := os.Stat(x.path); errors.Is(err, os.ErrNotExist) {
+		if err := ignoreIndex(filepath.Dir(x.path)); err != nil {
+			return nil, err
+		}
+	}
+	db, err := sql.Open("sqlite", "file:"+x.path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, fmt.Errorf("open case index: %w", err)
+	}
+	if _, err := db.Exec(sqliteIndexSchema); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("open case index %s: %w", x.path, err)
+	}
+	return db, nil
+}
+
+func (x *sqliteIndex) rebuild(db *sql.DB) (int, error) {
+	revision := x.revision()
+	// Stamps are taken first: a case edited while the index is rebuilt then
+	// looks stale and is read again on the next query.
+	stamps, err := x.source.cr.caseStamps()
+	if err != nil {
+		return 0, err
+	}
+	cases, err := x.source.Cases()
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, stmt := range []string{`DELETE FROM cases`, `DELETE FROM history`, `DELETE FROM stamps`} {
+		if _, err := tx.Exec(stmt); err != nil {
+			return 0, fmt.Errorf("clear case index: %w", err)
+		}
+	}
+	for _, c := range cases {
+		if err := x.insert(tx, c); err != nil {
+			return 0, err
+		}
+	}
+	for id, stamp := range stamps {
+		if _, err := tx.Exec(`INSERT INTO stamps (id, stamp) VALUES (?, ?)`, id, stamp); err != nil {
+			return 0, fmt.Errorf("write case index: %w", err)
+		}
+	}
+	if _, err := tx.Exec(`INSERT OR REPLACE INTO meta (key, value) VALUES ('revision', ?)`, revision); err != nil {
+		return 0, fmt.Errorf("write case index: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("write case index: %w", err)
+	}
+	return len(cases), nil
+}
+
+// fresh reports whether every case directory still matches the stamp it was
+// indexed with, and no case has appeared or gone since.
+func (x *sqliteIndex) fresh(db *sql.DB) (bool, error) {
+	current, err := x.source.cr.caseStamps()
+	if err != nil {
+		return false, err
+	}
+	rows, err := db.Query(`SELECT id, stamp FROM stamps`)
+	if err != nil {
+		return false, fmt.Errorf("read case index: %w", err)
+	}
+	defer rows.Close()
+	indexed := 0
+	for rows.Next() {
+		var id, stamp string
+		if err := rows.Scan(&id, &stamp); err != nil {
+			return false, fmt.Errorf("read case index: %w", err)
+		}
+		if current[id] != stamp {
+			return false, nil
+		}
+		indexed++
+	}
+	return indexed == len(current), rows.Err()
+}
+
+// caseStamps returns the stamp of every case directory in the working set,
+// keyed by case ID.
+func (cr *CaseResource) caseStamps() (map[string]string, error) {
+	casesRoot, err := cr.casesDir()
+	if err != nil {
+		return nil, err
+	}
+	locs, err := cr.scanCases(casesRoot)
+	if err != nil {
+		// A missing cases directory holds no cases.
+		return map[string]string{}, nil
+	}
+	stamps := make(map[string]string, len(locs))
+	for _, loc := range locs {
+		stamps[loc.ID] = caseStamp(loc.Dir)
+	}
+	return stamps, nil
+}
+
+// caseStamp summarises where a case lives and the size and modification
+// time of the files it is read from, so that any edit to them, by agentops
+// or not, changes it.
+func caseStamp(dir string) string {
+	var b strings.Builder
+	b.WriteString(dir)
+	for _, name := range []string{"case.md", historyFile} {
+		if info, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			fmt.Fprintf(&b, ";%d.%d", info.ModTime().UnixNano(), info.Size())
+		} else {
+			b.WriteString(";-")
+		}
+	}
+	return b.String()
+}
+
+// insert adds case c and its history to the index.
+func (x *sqliteIndex) insert(tx *sql.Tx, c StoredCase) error {
+	history, err := x.source.cr.readHistory(c.Dir)
+	if err != nil {
+		return fmt.Errorf("%s: %w", c.ID, err)
+	}
+	rel, err := filepath.Rel(x.source.cr.store.Root(), c.Dir)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`INSERT INTO cases (id, dir, grp, slot, status, frontmatter) VALUES (?, ?, ?, ?, ?, ?)`,
+		c.ID, filepath.ToSlash(rel), c.Group, c.Slot, c.Frontmatter.Status, RenderFrontmatter(c.Frontmatter)); err != nil {
+		return fmt.Errorf("index case %s: %w", c.ID, err)
+	}
+	for i, e := range history {
+		if _, err := tx.Exec(`INSERT INTO history (case_id, seq, timestamp, action, from_status, to_status, actor, slot, reason)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			c.ID, i+1, e.Timestamp, e.Action, e.From, e.To, e.Actor, e.Slot, e.Reason); err != nil {
+			return fmt.Errorf("index history of %s: %w", c.ID, err)
+		}
+	}
+	return nil
+}
+
+func deleteIndexed(tx *sql.Tx, id string) error {
+	for _, stmt := range []string{`DELETE FROM cases WHERE id = ?`, `DELETE FROM history WHERE case_id = ?`, `DELETE FROM stamps WHERE id = ?`} {
+		if _, err := tx.Exec(stmt, id); err != nil {
+			return fmt.Errorf("update case index: %w", err)
+		}
+	}
+	return nil
+}
+
+// ignoreIndex keeps the index database out of the project repository by
+// adding it to .agentops/.gitignore, creating the file if needed and leaving
+// the entries already there alone.
+func ignoreIndex(dir string) error {
+	path := filepath.Join(dir, ".gitignore")
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("read %s: %w", path, err)
+	}
+	present := map[string]bool{}
+	for _, line := range strings.Split(string(data), "\n") {
+		present[strings.TrimSpace(line)] = true
+	}
+	content := string(data)
+	for _, entry := range []string{sqliteIndexFile, sqliteIndexFile + "-*"} {
+		if present[entry] {
+			continue
+		}
+		if content != "" && !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+		content += entry + "\n"
+	}
+	if content == string(data) {
+		return nil
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,244 @@
+package caseresource
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	agentops "github.com/gh-xj/agentops"
+	"github.com/gh-xj/agentops/dal"
+	"github.com/gh-xj/agentops/resource"
+	"github.com/gh-xj/agentops/strategy"
+)
+
+// setupIndexedProject returns a case resource querying through the SQLite
+// index, and one reading the same cases straight from markdown.
+func setupIndexedProject(t *testing.T, storage string) (root string, indexed, plain *CaseResource) {
+	t.Helper()
+	root, _ = setupTestProject(t)
+	if err := os.WriteFile(filepath.Join(root, ".agentops", "storage.yaml"), []byte(storage+"index: sqlite\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	strat, err := strategy.Discover(root)
+	if err != nil {
+		t.Fatalf("discover: %v", err)
+	}
+	indexed = New(dal.NewFileSystem(), dal.NewExecutor(), strat)
+	plainStrat := *strat
+	plainStrat.Storage.Index = ""
+	plain = New(dal.NewFileSystem(), dal.NewExecutor(), &plainStrat)
+	return root, indexed, plain
+}
+
+// listIDs returns the ID and status of every listed case.
+func listIDs(t *testing.T, cr *CaseResource, filter resource.Filter) []string {
+	t.Helper()
+	records, err := cr.List(testCtx(), filter)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	out := make([]string, len(records))
+	for i, r := range records {
+		out[i] = r.ID + "=" + r.Fields["status"].(string)
+	}
+	return out
+}
+
+func TestSQLiteIndexMatchesMarkdown(t *testing.T) {
+	for _, layout := range []string{"", "layout: grouped\n"} {
+		name := "flat"
+		if layout != "" {
+			name = "grouped"
+		}
+		t.Run(name, func(t *testing.T) {
+			root, indexed, plain := setupIndexedProject(t, "backend: in-repo\n"+layout)
+			ctx := testCtx()
+			ids := createCases(t, indexed, "alpha", "beta", "gamma")
+			if _, err := indexed.Transition(ctx, ids[0], "start"); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := indexed.Link(ctx, ids[1], LinkBlockedBy, ids[2]); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := indexed.Transition(ctx, ids[2], "close_no_action"); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := os.Stat(filepath.Join(root, ".agentops", sqliteIndexFile)); err != nil {
+				t.Fatalf("index database not created: %v", err)
+			}
+			ignore, err := os.ReadFile(filepath.Join(root, ".agentops", ".gitignore"))
+			if err != nil || !strings.Contains(string(ignore), sqliteIndexFile) {
+				t.Errorf(".agentops/.gitignore = %q, %v; want it to ignore the index", ignore, err)
+			}
+
+			for _, filter := range []resource.Filter{
+				nil,
+				{resource.Equals("status", "active")},
+				{resource.Equals("status", "closed_no_action")},
+				{resource.Equals(LinkBlockedBy, ids[2])},
+				{resource.Grep("beta")},
+			} {
+				got, want := listIDs(t, indexed, filter), listIDs(t, plain, filter)
+				if strings.Join(got, ",") != strings.Join(want, ",") {
+					t.Errorf("List(%v) = %v, markdown gives %v", filter, got, want)
+				}
+			}
+
+			got, err := indexed.History(ctx, ids[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := plain.History(ctx, ids[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != 2 || len(got) != len(want) || got[1].Fields["to"] != want[1].Fields["to"] {
+				t.Errorf("History = %v, markdown gives %v", got, want)
+			}
+
+			g, err := indexed.Graph(ctx, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(g.Edges) != 1 || g.Nodes[1].Status != "closed_no_action" {
+				t.Errorf("Graph = %+v", g)
+			}
+		})
+	}
+}
+
+func TestSQLiteIndexPicksUpHandEdits(t *testing.T) {
+	_, indexed, _ := setupIndexedProject(t, "backend: in-repo\n")
+	ctx := testCtx()
+	ids := createCases(t, indexed, "edited", "archived")
+
+	// The index is built on first query; an edit made outside agentops after
+	// that changes the stamp of the case, so the next query rebuilds it.
+	listIDs(t, indexed, nil)
+	rec, err := indexed.Get(ctx, ids[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(rec.RawPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(rec.RawPath, []byte(strings.Replace(string(data), "status: open", "status: blocked", 1)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := listIDs(t, indexed, resource.Filter{resource.Equals("status", "blocked")}); len(got) != 1 || !strings.HasPrefix(got[0], ids[0]) {
+		t.Errorf("List(status=blocked) after hand edit = %v", got)
+	}
+	n, err := indexed.Reindex(ctx)
+	if err != nil {
+		t.Fatalf("Reindex: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("Reindex indexed %d cases, want 2", n)
+	}
+
+	// Archiving drops the case from the index.
+	if _, err := indexed.Transition(ctx, ids[1], "close_no_action"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := indexed.Archive(ctx, ids[1]); err != nil {
+		t.Fatal(err)
+	}
+	if got := listIDs(t, indexed, nil); len(got) != 1 {
+		t.Errorf("List after archive = %v, want only %s", got, ids[0])
+	}
+}
+
+func TestSQLiteIndexStaysFreshAfterWrites(t *testing.T) {
+	_, indexed, _ := setupIndexedProject(t, "backend: in-repo\n")
+	ctx := testCtx()
+	ids := createCases(t, indexed, "written")
+	listIDs(t, indexed, nil)
+	if _, err := indexed.Transition(ctx, ids[0], "start"); err != nil {
+		t.Fatal(err)
+	}
+
+	idx := indexed.cases.(*sqliteIndex)
+	db, err := idx.openDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if fresh, err := idx.fresh(db); err != nil || !fresh {
+		t.Errorf("index stale after an agentops write: fresh = %v, err = %v", fresh, err)
+	}
+}
+
+func TestSQLiteIndexGitignore(t *testing.T) {
+	root, indexed, _ := setupIndexedProject(t, "backend: in-repo\n")
+	ctx := testCtx()
+	ignorePath := filepath.Join(root, ".agentops", ".gitignore")
+	if err := os.WriteFile(ignorePath, []byte("notes/"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Creating the index adds it to an existing .gitignore.
+	createCases(t, indexed, "ignored")
+	want := "notes/\ncases.db\ncases.db-*\n"
+	if data, _ := os.ReadFile(ignorePath); string(data) != want {
+		t.Errorf(".gitignore after creating the index = %q, want %q", data, want)
+	}
+
+	// Queries leave it alone; reindex restores it.
+	if err := os.Remove(ignorePath); err != nil {
+		t.Fatal(err)
+	}
+	listIDs(t, indexed, nil)
+	if _, err := os.Stat(ignorePath); !os.IsNotExist(err) {
+		t.Errorf("a query should not write .gitignore: %v", err)
+	}
+	if _, err := indexed.Reindex(ctx); err != nil {
+		t.Fatalf("Reindex: %v", err)
+	}
+	if data, _ := os.ReadFile(ignorePath); string(data) != "cases.db\ncases.db-*\n" {
+		t.Errorf(".gitignore after reindex = %q", data)
+	}
+	if _, err := indexed.Reindex(ctx); err != nil {
+		t.Fatalf("Reindex: %v", err)
+	}
+	if data, _ := os.ReadFile(ignorePath); string(data) != "cases.db\ncases.db-*\n" {
+		t.Errorf("reindex should not duplicate entries, got %q", data)
+	}
+}
+
+func TestSQLiteIndexFollowsCaseRepository(t *testing.T) {
+	slots := setupSharedCaseRepo(t, "alpha", "beta")
+	alpha, beta := slots["alpha"], slots["beta"]
+	for _, cr := range []*CaseResource{alpha, beta} {
+		cr.strat.Storage.Index = strategy.IndexSQLite
+		cr.cases = newCaseStore(cr)
+	}
+
+	if got := listIDs(t, beta, nil); len(got) != 0 {
+		t.Fatalf("beta lists %v before any case exists", got)
+	}
+	created, err := alpha.Create(slotCtx("alpha"), "shared", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// beta's index was built before the pull; a new revision rebuilds it.
+	if err := beta.store.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if got := listIDs(t, beta, nil); len(got) != 1 || got[0] != created.ID+"=open" {
+		t.Errorf("beta lists %v after pulling alpha's case", got)
+	}
+}
+
+func TestReindexWithoutIndex(t *testing.T) {
+	_, strat := setupTestProject(t)
+	cr := New(dal.NewFileSystem(), dal.NewExecutor(), strat)
+	_, err := cr.Reindex(testCtx())
+	var cliErr *agentops.CLIError
+	if !errors.As(err, &cliErr) || cliErr.Code != agentops.ExitUsage {
+		t.Errorf("Reindex without an index: err = %v, want usage CLIError", err)
+	}
+}
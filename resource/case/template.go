@@ -0,0 +1,113 @@
+package caseresource
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+
+	agentops "github.com/gh-xj/agentops"
+	"github.com/gh-xj/agentops/resource"
+)
+
+// setPrefix marks user-supplied template variables in Create opts
+// (--set key=value arrives as opts["set.key"]).
+const setPrefix = "set."
+
+// caseTemplate returns the template for caseType: templates/<type>.md when
+// the strategy has one, otherwise schema.md. It returns "" when neither exists.
+func (cr *CaseResource) caseTemplate(caseType string) string {
+	if tpl, ok := cr.strat.Templates[caseType]; ok && caseType != "" {
+		return tpl
+	}
+	return cr.strat.SchemaTemplate
+}
+
+// templateVars returns the variables available to a case template: slug,
+// date, slot, id and type, plus every --set key. A --set key may not shadow
+// a built-in variable.
+func (cr *CaseResource) templateVars(ctx *agentops.AppContext, slug, id, date, caseType string, opts map[string]string) (map[string]string, error) {
+	slot := opts["slot"]
+	if slot == "" {
+		slot, _ = cr.currentSlot(ctx)
+	}
+	vars := map[string]string{
+		"slug": slug,
+		"date": date,
+		"slot": slot,
+		"id":   id,
+		"type": caseType,
+	}
+	for k, v := range opts {
+		key, ok := strings.CutPrefix(k, setPrefix)
+		if !ok {
+			continue
+		}
+		if _, builtin := vars[key]; builtin {
+			return nil, agentops.NewCLIError(agentops.ExitUsage, "invalid_option",
+				fmt.Sprintf("--set %s: %q is a built-in template variable", key, key), nil)
+		}
+		vars[key] = v
+	}
+	return vars, nil
+}
+
+// renderTemplate executes a case template. Referencing a variable that was
+// not set is an error, so a template can require --set values.
+func renderTemplate(name, text string, vars map[string]string) (string, error) {
+	tpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("parse template %s: %w", name, err)
+	}
+	var b strings.Builder
+	if err := tpl.Execute(&b, vars); err != nil {
+		return "", agentops.NewCLIError(agentops.ExitUsage, "template_failed",
+			fmt.Sprintf("render template %s: %v", name, err), err)
+	}
+	return b.String(), nil
+}
+
+// templateFields returns the custom frontmatter fields declared by the
+// strategy's schema.md and type templates, in template order.
+func (cr *CaseResource) templateFields() []resource.FieldDef {
+	if cr.strat == nil {
+		return nil
+	}
+	texts := []string{cr.strat.SchemaTemplate}
+	types := make([]string, 0, len(cr.strat.Templates))
+	for t := range cr.strat.Templates {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	for _, t := range types {
+		texts = append(texts, cr.strat.Templates[t])
+	}
+
+	var fields []resource.FieldDef
+	seen := map[string]bool{}
+	for _, text := range texts {
+		if text == "" {
+			continue
+		}
+		// Render with empty variables so placeholders do not break YAML.
+		tpl, err := template.New("fields").Option("missingkey=zero").Parse(text)
+		if err != nil {
+			continue
+		}
+		var b strings.Builder
+		if err := tpl.Execute(&b, map[string]string{}); err != nil {
+			continue
+		}
+		fm, _, err := ParseFrontmatter(b.String())
+		if err != nil {
+			continue
+		}
+		for _, f := range fm.ExtraFields() {
+			if !seen[f.Name] {
+				seen[f.Name] = true
+				fields = append(fields, f)
+			}
+		}
+	}
+	return fields
+}
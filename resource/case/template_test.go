@@ -0,0 +1,145 @@
+package caseresource
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	agentops "github.com/gh-xj/agentops"
+	"github.com/gh-xj/agentops/dal"
+	"github.com/gh-xj/agentops/strategy"
+)
+
+const incidentTemplate = `---
+type: incident
+status: open
+claimed_by: none
+created: "YYYY-MM-DD"
+severity: "{{.severity}}"
+---
+# Case Title
+
+Incident {{.slug}} opened {{.date}} from slot "{{.slot}}" ({{.id}}).
+
+## Timeline
+
+## Findings
+`
+
+// setupTemplateProject bootstraps a project with .agentops/templates/<type>.md files.
+func setupTemplateProject(t *testing.T, templates map[string]string) *CaseResource {
+	t.Helper()
+	root, _ := setupTestProject(t)
+	dir := filepath.Join(root, ".agentops", "templates")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range templates {
+		if err := os.WriteFile(filepath.Join(dir, name+".md"), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	strat, err := strategy.Discover(root)
+	if err != nil {
+		t.Fatalf("discover: %v", err)
+	}
+	return New(dal.NewFileSystem(), dal.NewExecutor(), strat)
+}
+
+func TestCaseResourceCreateFromTypeTemplate(t *testing.T) {
+	cr := setupTemplateProject(t, map[string]string{"incident": incidentTemplate})
+	ctx := testCtx()
+	ctx.Values["slot"] = "alpha"
+
+	rec, err := cr.Create(ctx, "db-outage", map[string]string{"type": "incident", "set.severity": "sev1"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if rec.Fields["type"] != "incident" {
+		t.Errorf("type = %v, want incident", rec.Fields["type"])
+	}
+	if rec.Fields["severity"] != "sev1" {
+		t.Errorf("severity = %v, want sev1", rec.Fields["severity"])
+	}
+
+	data, err := os.ReadFile(rec.RawPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := string(data)
+	date := strings.TrimPrefix(strings.TrimSuffix(rec.ID, "-db-outage"), "CASE-")
+	want := `Incident db-outage opened ` + date + ` from slot "alpha" (` + rec.ID + `).`
+	if !strings.Contains(content, want) {
+		t.Errorf("case.md missing rendered line %q:\n%s", want, content)
+	}
+	if !strings.Contains(content, "# "+rec.ID+"\n") || !strings.Contains(content, "## Timeline") {
+		t.Errorf("case.md does not follow the incident skeleton:\n%s", content)
+	}
+}
+
+func TestCaseResourceCreateTypeWithoutTemplate(t *testing.T) {
+	cr := setupTemplateProject(t, map[string]string{"incident": incidentTemplate})
+
+	rec, err := cr.Create(testCtx(), "tidy-up", map[string]string{"type": "quality"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if rec.Fields["type"] != "quality" {
+		t.Errorf("type = %v, want quality", rec.Fields["type"])
+	}
+	data, err := os.ReadFile(rec.RawPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "## User Intent") {
+		t.Errorf("expected the schema.md skeleton:\n%s", data)
+	}
+}
+
+func TestCaseResourceCreateTemplateErrors(t *testing.T) {
+	cr := setupTemplateProject(t, map[string]string{"incident": incidentTemplate})
+	casesRoot := filepath.Join(cr.strat.Root, "cases")
+
+	tests := []struct {
+		name string
+		opts map[string]string
+		want string
+	}{
+		{"missing variable", map[string]string{"type": "incident"}, "severity"},
+		{"shadowed built-in", map[string]string{"type": "incident", "set.slug": "x"}, "built-in"},
+		{"invalid type", map[string]string{"type": "Bad Type"}, "invalid type"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := cr.Create(testCtx(), "broken", tt.opts)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("err = %v, want mention of %q", err, tt.want)
+			}
+		})
+	}
+
+	var cliErr *agentops.CLIError
+	_, err := cr.Create(testCtx(), "broken", map[string]string{"type": "incident"})
+	if !errors.As(err, &cliErr) || cliErr.Code != agentops.ExitUsage {
+		t.Errorf("err = %v, want usage CLIError", err)
+	}
+	entries, _ := os.ReadDir(casesRoot)
+	for _, e := range entries {
+		if strings.Contains(e.Name(), "broken") {
+			t.Errorf("failed create left %s behind", e.Name())
+		}
+	}
+}
+
+func TestCaseResourceSchemaIncludesTemplateFields(t *testing.T) {
+	cr := setupTemplateProject(t, map[string]string{"incident": incidentTemplate})
+
+	for _, f := range cr.Schema().Fields {
+		if f.Name == "severity" {
+			return
+		}
+	}
+	t.Error("schema is missing the severity field declared by templates/incident.md")
+}
@@ -2,7 +2,9 @@ package caseresource
 
 import (
 	"fmt"
+	"sort"
 
+	agentops "github.com/gh-xj/agentops"
 	"github.com/gh-xj/agentops/strategy"
 )
 
@@ -21,8 +23,11 @@ func (sm *StateMachine) Initial() string {
 	return sm.config.Initial
 }
 
-// Apply applies an action to the current status and returns the new status.
-func (sm *StateMachine) Apply(currentStatus, action string) (string, error) {
+// Apply applies an action to the subject's status and returns the new status.
+// Once the action is allowed from the current status, its guards are
+// evaluated against the subject; unmet guards deny the transition with a
+// *GuardError wrapped in a CLIError carrying ExitTransitionDenied.
+func (sm *StateMachine) Apply(subj GuardSubject, action string) (string, error) {
 	def, ok := sm.config.Transitions[action]
 	if !ok {
 		return "", fmt.Errorf("unknown action %q", action)
@@ -30,12 +35,65 @@ func (sm *StateMachine) Apply(currentStatus, action string) (string, error) {
 
 	fromStates := def.FromStates()
 	for _, s := range fromStates {
-		if s == currentStatus {
-			return def.To, nil
+		if s != subj.Status {
+			continue
 		}
+		if unmet := evaluateGuards(def.Guards, subj); len(unmet) > 0 {
+			guardErr := &GuardError{Action: action, Unmet: unmet}
+			return "", agentops.NewCLIError(agentops.ExitTransitionDenied, "transition_denied", fmt.Sprintf("cannot %s", action), guardErr)
+		}
+		return def.To, nil
+	}
+
+	return "", fmt.Errorf("action %q not allowed from status %q (allowed from: %v)", action, subj.Status, fromStates)
+}
+
+// Guards returns the guards configured for an action.
+func (sm *StateMachine) Guards(action string) strategy.TransitionGuards {
+	return sm.config.Transitions[action].Guards
+}
+
+// ActionTo returns an action that moves currentStatus to target. When several
+// actions qualify, the alphabetically first one is returned.
+func (sm *StateMachine) ActionTo(currentStatus, target string) (string, bool) {
+	names := make([]string, 0, len(sm.config.Transitions))
+	for name := range sm.config.Transitions {
+		names = append(names, name)
 	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		def := sm.config.Transitions[name]
+		if def.To != target {
+			continue
+		}
+		for _, s := range def.FromStates() {
+			if s == currentStatus {
+				return name, true
+			}
+		}
+	}
+	return "", false
+}
+
+// ActionsFrom returns the actions allowed from status, sorted by name.
+func (sm *StateMachine) ActionsFrom(status string) []string {
+	var actions []string
+	for name, def := range sm.config.Transitions {
+		for _, s := range def.FromStates() {
+			if s == status {
+				actions = append(actions, name)
+				break
+			}
+		}
+	}
+	sort.Strings(actions)
+	return actions
+}
 
-	return "", fmt.Errorf("action %q not allowed from status %q (allowed from: %v)", action, currentStatus, fromStates)
+// Target returns the status an action moves a case to.
+func (sm *StateMachine) Target(action string) string {
+	return sm.config.Transitions[action].To
 }
 
 // AllStatuses returns all known statuses from the categories config.
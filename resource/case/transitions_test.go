@@ -51,7 +51,7 @@ func TestStateMachineApplyValid(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.current+"_"+tt.action, func(t *testing.T) {
-			got, err := sm.Apply(tt.current, tt.action)
+			got, err := sm.Apply(GuardSubject{Status: tt.current}, tt.action)
 			if err != nil {
 				t.Fatalf("unexpected error: %v", err)
 			}
@@ -65,7 +65,7 @@ func TestStateMachineApplyValid(t *testing.T) {
 func TestStateMachineApplyInvalidAction(t *testing.T) {
 	sm := NewStateMachine(defaultTransitionsConfig())
 
-	_, err := sm.Apply("open", "nonexistent")
+	_, err := sm.Apply(GuardSubject{Status: "open"}, "nonexistent")
 	if err == nil {
 		t.Fatal("expected error for unknown action")
 	}
@@ -75,7 +75,7 @@ func TestStateMachineApplyInvalidFromState(t *testing.T) {
 	sm := NewStateMachine(defaultTransitionsConfig())
 
 	// "start" only allows from "open", not "blocked"
-	_, err := sm.Apply("blocked", "start")
+	_, err := sm.Apply(GuardSubject{Status: "blocked"}, "start")
 	if err == nil {
 		t.Fatal("expected error for invalid from state")
 	}
@@ -156,3 +156,26 @@ func TestStateMachineExpandStatusFilter(t *testing.T) {
 		t.Fatal("expected error for unknown filter")
 	}
 }
+
+func TestStateMachineActionTo(t *testing.T) {
+	sm := NewStateMachine(defaultTransitionsConfig())
+
+	tests := []struct {
+		current string
+		target  string
+		want    string
+		ok      bool
+	}{
+		{"open", "blocked", "block", true},
+		{"in_progress", "resolved", "resolve", true},
+		{"blocked", "in_progress", "unblock", true},
+		{"resolved", "blocked", "", false},
+		{"open", "unknown", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := sm.ActionTo(tt.current, tt.target)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ActionTo(%q, %q) = (%q, %v), want (%q, %v)", tt.current, tt.target, got, ok, tt.want, tt.ok)
+		}
+	}
+}
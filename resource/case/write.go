@@ -0,0 +1,140 @@
+package caseresource
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	agentops "github.com/gh-xj/agentops"
+	"github.com/gh-xj/agentops/resource"
+)
+
+// caseLockFile guards read-modify-write cycles on a case's case.md.
+const caseLockFile = ".case.lock"
+
+const (
+	caseLockTimeout = 5 * time.Second
+	caseLockPoll    = 20 * time.Millisecond
+)
+
+// lockCase takes the exclusive write lock of the case in caseDir, waiting up
+// to caseLockTimeout for another writer to finish.
+func (cr *CaseResource) lockCase(caseDir string) (func(), error) {
+	lockPath := filepath.Join(caseDir, caseLockFile)
+	deadline := time.Now().Add(caseLockTimeout)
+	for {
+		err := cr.fs.CreateExclusive(lockPath, 0o600)
+		if err == nil {
+			return func() { _ = cr.fs.Remove(lockPath) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("create case lock %q: %w", lockPath, err)
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("acquire case lock %q: timeout after %s (remove it if no writer is running)", lockPath, caseLockTimeout)
+		}
+		time.Sleep(caseLockPoll)
+	}
+}
+
+// movedLock returns the unlock function of a case lock that moved with its
+// case directory to caseDir.
+func (cr *CaseResource) movedLock(caseDir string) func() {
+	return func() { _ = cr.fs.Remove(filepath.Join(caseDir, caseLockFile)) }
+}
+
+// relocateRetries bounds how often lockLocated follows a case that another
+// writer moved while the lock was being taken.
+const relocateRetries = 3
+
+// lockLocated locates case id and takes its lock. Cases only move under
+// their lock, so a case that moved between locating and locking is located
+// again, and the returned location stays current until unlock is called.
+func (cr *CaseResource) lockLocated(id string) (CaseLocation, func(), error) {
+	for attempt := 0; ; attempt++ {
+		loc, err := cr.locate(id)
+		if err != nil {
+			return CaseLocation{}, nil, err
+		}
+		unlock, err := cr.lockCase(loc.Dir)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) && attempt < relocateRetries {
+				continue
+			}
+			return CaseLocation{}, nil, err
+		}
+		cur, err := cr.locate(id)
+		if err == nil && cur.Dir == loc.Dir {
+			return loc, unlock, nil
+		}
+		unlock()
+		if err != nil {
+			return CaseLocation{}, nil, err
+		}
+		if attempt >= relocateRetries {
+			return CaseLocation{}, nil, fmt.Errorf("case %q keeps moving; retry the command", id)
+		}
+	}
+}
+
+// writeCaseMD replaces case.md atomically so readers never see a partial file.
+func (cr *CaseResource) writeCaseMD(caseMDPath string, content []byte) error {
+	tmp := caseMDPath + ".tmp"
+	if err := cr.fs.WriteFile(tmp, content, 0o644); err != nil {
+		return fmt.Errorf("write case.md: %w", err)
+	}
+	if err := cr.fs.Rename(tmp, caseMDPath); err != nil {
+		_ = cr.fs.Remove(tmp)
+		return fmt.Errorf("write case.md: %w", err)
+	}
+	return nil
+}
+
+// updateCase applies fn to a case's frontmatter and body under the case lock,
+// writes the result and commits it to storage as action.
+func (cr *CaseResource) updateCase(ctx *agentops.AppContext, id, action string, fn func(fm *Frontmatter, body string) (string, error)) (*resource.Record, error) {
+	if err := cr.store.Sync(); err != nil {
+		return nil, err
+	}
+	caseMDPath, err := cr.findCaseMD(id)
+	if err != nil {
+		return nil, err
+	}
+	fm, err := cr.rewriteCase(caseMDPath, fn)
+	if err != nil {
+		return nil, err
+	}
+	if err := cr.commit(id, caseMDPath, cr.newHistoryEntry(ctx, action, fm.Status, fm.Status)); err != nil {
+		return nil, err
+	}
+	return cr.recordFromFrontmatter(id, caseMDPath, fm), nil
+}
+
+// rewriteCase is the locked read-modify-write cycle of updateCase.
+func (cr *CaseResource) rewriteCase(caseMDPath string, fn func(fm *Frontmatter, body string) (string, error)) (Frontmatter, error) {
+	unlock, err := cr.lockCase(filepath.Dir(caseMDPath))
+	if err != nil {
+		return Frontmatter{}, err
+	}
+	defer unlock()
+
+	data, err := cr.fs.ReadFile(caseMDPath)
+	if err != nil {
+		return Frontmatter{}, fmt.Errorf("read case.md: %w", err)
+	}
+	fm, body, err := ParseFrontmatter(string(data))
+	if err != nil {
+		return Frontmatter{}, fmt.Errorf("parse frontmatter: %w", err)
+	}
+
+	body, err = fn(&fm, body)
+	if err != nil {
+		return Frontmatter{}, err
+	}
+	if err := cr.writeCaseMD(caseMDPath, []byte(RenderFrontmatter(fm)+body)); err != nil {
+		return Frontmatter{}, err
+	}
+	return fm, nil
+}
@@ -0,0 +1,269 @@
+package resource
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// GrepKey is the field of the Filter condition holding a case-insensitive
+// full-text search. Each resource decides what text a record is searched by;
+// see Filter.Match.
+const GrepKey = "grep"
+
+// Filter operators, longest first so that ">=" is not read as ">".
+var filterOps = []string{"!=", ">=", "<=", "=", ">", "<", "~"}
+
+// Condition is one parsed filter expression, such as created>=20260101.
+//
+// Operators: = (equal), != (not equal), > >= < <= (ordered; numeric when both
+// sides are numbers) and ~ (case-insensitive substring). A list-valued field
+// matches =, ~ and the ordered operators when any element does, and != when
+// no element equals the value. Missing fields compare as "".
+type Condition struct {
+	Field string
+	Op    string
+	Value string
+}
+
+// Equals returns the condition that field equals value.
+func Equals(field, value string) Condition {
+	return Condition{Field: field, Op: "=", Value: value}
+}
+
+// Grep returns the full-text search condition for query.
+func Grep(query string) Condition {
+	return Condition{Field: GrepKey, Op: "~", Value: query}
+}
+
+// ParseCondition parses a "<field><op><value>" expression.
+func ParseCondition(expr string) (Condition, error) {
+	i := strings.IndexAny(expr, "!=<>~")
+	if i <= 0 {
+		return Condition{}, fmt.Errorf("invalid filter %q: want <field><op><value>, e.g. type=pr", expr)
+	}
+	field, rest := strings.TrimSpace(expr[:i]), expr[i:]
+	for _, op := range filterOps {
+		if strings.HasPrefix(rest, op) {
+			return Condition{Field: field, Op: op, Value: strings.TrimSpace(rest[len(op):])}, nil
+		}
+	}
+	return Condition{}, fmt.Errorf("invalid filter %q: unknown operator", expr)
+}
+
+// FieldCondition reads spec, a value optionally prefixed with an operator, as
+// a condition on field. A bare value means equality, so "prod" and ">=2" are
+// both valid specs.
+func FieldCondition(field, spec string) Condition {
+	for _, op := range filterOps {
+		if strings.HasPrefix(spec, op) {
+			return Condition{Field: field, Op: op, Value: spec[len(op):]}
+		}
+	}
+	return Equals(field, spec)
+}
+
+// ParseFilter parses filter expressions into a Filter, in the order given. A
+// field filtered more than once must satisfy every condition, so
+// created>=20260101 together with created<=20270101 selects a range.
+func ParseFilter(exprs []string) (Filter, error) {
+	f := make(Filter, 0, len(exprs))
+	for _, expr := range exprs {
+		c, err := ParseCondition(expr)
+		if err != nil {
+			return nil, err
+		}
+		f = append(f, c)
+	}
+	return f, nil
+}
+
+// Conditions returns the field conditions of f, excluding the GrepKey
+// search, in the order they were given.
+func (f Filter) Conditions() []Condition {
+	conds := make([]Condition, 0, len(f))
+	for _, c := range f {
+		if c.Field != GrepKey {
+			conds = append(conds, c)
+		}
+	}
+	return conds
+}
+
+// Condition returns the condition f holds for field, if it holds exactly one.
+func (f Filter) Condition(field string) (Condition, bool) {
+	if field == GrepKey {
+		return Condition{}, false
+	}
+	var found []Condition
+	for _, c := range f {
+		if c.Field == field {
+			found = append(found, c)
+		}
+	}
+	if len(found) != 1 {
+		return Condition{}, false
+	}
+	return found[0], true
+}
+
+// GrepQuery returns the full-text search of f, if it has one.
+func (f Filter) GrepQuery() (string, bool) {
+	for _, c := range f {
+		if c.Field == GrepKey && c.Value != "" {
+			return c.Value, true
+		}
+	}
+	return "", false
+}
+
+// Without returns a copy of f without the conditions on fields.
+func (f Filter) Without(fields ...string) Filter {
+	out := make(Filter, 0, len(f))
+	for _, c := range f {
+		if !slices.Contains(fields, c.Field) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// Match reports whether rec satisfies every condition in f. The GrepKey
+// search runs over text; resources without a body pass RecordText(rec).
+func (f Filter) Match(rec Record, text string) bool {
+	for _, c := range f {
+		if c.Field == GrepKey {
+			if c.Value != "" && !strings.Contains(strings.ToLower(text), strings.ToLower(c.Value)) {
+				return false
+			}
+			continue
+		}
+		if !c.Match(fieldValue(rec, c.Field)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Match reports whether a field value satisfies the condition.
+func (c Condition) Match(v any) bool {
+	if items, ok := listValue(v); ok {
+		if c.Op == "!=" {
+			for _, item := range items {
+				if item == c.Value {
+					return false
+				}
+			}
+			return true
+		}
+		for _, item := range items {
+			if c.matchString(item) {
+				return true
+			}
+		}
+		return false
+	}
+	return c.matchString(scalarString(v))
+}
+
+func (c Condition) matchString(s string) bool {
+	switch c.Op {
+	case "=":
+		return s == c.Value
+	case "!=":
+		return s != c.Value
+	case "~":
+		return strings.Contains(strings.ToLower(s), strings.ToLower(c.Value))
+	case ">":
+		return CompareValues(s, c.Value) > 0
+	case ">=":
+		return CompareValues(s, c.Value) >= 0
+	case "<":
+		return CompareValues(s, c.Value) < 0
+	case "<=":
+		return CompareValues(s, c.Value) <= 0
+	}
+	return false
+}
+
+// CompareValues orders two field values, numerically when both parse as
+// numbers and lexically otherwise.
+func CompareValues(a, b string) int {
+	fa, errA := strconv.ParseFloat(a, 64)
+	fb, errB := strconv.ParseFloat(b, 64)
+	if errA == nil && errB == nil {
+		switch {
+		case fa < fb:
+			return -1
+		case fa > fb:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(a, b)
+}
+
+// SortRecords sorts records in place by a field. A leading '-' sorts
+// descending. Ties keep their existing order.
+func SortRecords(records []Record, key string) {
+	desc := strings.HasPrefix(key, "-")
+	field := strings.TrimPrefix(key, "-")
+	sort.SliceStable(records, func(i, j int) bool {
+		c := CompareValues(scalarString(fieldValue(records[i], field)), scalarString(fieldValue(records[j], field)))
+		if desc {
+			return c > 0
+		}
+		return c < 0
+	})
+}
+
+// RecordText renders a record's ID and field values as searchable text.
+func RecordText(rec Record) string {
+	keys := make([]string, 0, len(rec.Fields))
+	for k := range rec.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := []string{rec.ID}
+	for _, k := range keys {
+		parts = append(parts, scalarString(rec.Fields[k]))
+	}
+	return strings.Join(parts, "\n")
+}
+
+// fieldValue returns a record field, falling back to the record ID for "id".
+func fieldValue(rec Record, field string) any {
+	if v, ok := rec.Fields[field]; ok {
+		return v
+	}
+	if field == "id" {
+		return rec.ID
+	}
+	return nil
+}
+
+func listValue(v any) ([]string, bool) {
+	switch items := v.(type) {
+	case []string:
+		return items, true
+	case []any:
+		out := make([]string, len(items))
+		for i, item := range items {
+			out[i] = scalarString(item)
+		}
+		return out, true
+	}
+	return nil, false
+}
+
+func scalarString(v any) string {
+	if v == nil {
+		return ""
+	}
+	if items, ok := listValue(v); ok {
+		return strings.Join(items, ",")
+	}
+	return fmt.Sprint(v)
+}
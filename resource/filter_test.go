@@ -0,0 +1,172 @@
+package resource
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseCondition(t *testing.T) {
+	tests := []struct {
+		expr string
+		want Condition
+	}{
+		{"type=pr", Condition{"type", "=", "pr"}},
+		{"created>=20260101", Condition{"created", ">=", "20260101"}},
+		{"claimed_by!=none", Condition{"claimed_by", "!=", "none"}},
+		{"priority<3", Condition{"priority", "<", "3"}},
+		{"title~flaky", Condition{"title", "~", "flaky"}},
+		{"linear_ref=", Condition{"linear_ref", "=", ""}},
+	}
+	for _, tt := range tests {
+		got, err := ParseCondition(tt.expr)
+		if err != nil {
+			t.Errorf("ParseCondition(%q): %v", tt.expr, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseCondition(%q) = %+v, want %+v", tt.expr, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"", "type", "=pr"} {
+		if _, err := ParseCondition(bad); err == nil {
+			t.Errorf("ParseCondition(%q) should fail", bad)
+		}
+	}
+}
+
+func TestParseFilter(t *testing.T) {
+	f, err := ParseFilter([]string{"type=pr", "claimed_by!=none", "note==x"})
+	if err != nil {
+		t.Fatalf("ParseFilter: %v", err)
+	}
+	want := Filter{{"type", "=", "pr"}, {"claimed_by", "!=", "none"}, {"note", "=", "=x"}}
+	if !slices.Equal(f, want) {
+		t.Errorf("ParseFilter = %+v, want %+v", f, want)
+	}
+	for _, c := range want {
+		if got, _ := f.Condition(c.Field); got != c {
+			t.Errorf("Condition(%s) = %+v, want %+v", c.Field, got, c)
+		}
+	}
+
+	f, err = ParseFilter([]string{"type=pr", "type!=issue"})
+	if err != nil {
+		t.Fatalf("filtering a field twice: %v", err)
+	}
+	if _, ok := f.Condition("type"); ok {
+		t.Error("Condition should not pick one of several conditions on a field")
+	}
+
+	f = append(f, Grep("flaky"))
+	if got := f.Conditions(); !slices.Equal(got, []Condition{{"type", "=", "pr"}, {"type", "!=", "issue"}}) {
+		t.Errorf("Conditions = %+v, want the field conditions in order", got)
+	}
+	if q, ok := f.GrepQuery(); !ok || q != "flaky" {
+		t.Errorf("GrepQuery = %q, %v", q, ok)
+	}
+	if got := f.Without("type"); !slices.Equal(got, Filter{Grep("flaky")}) {
+		t.Errorf("Without(type) = %+v", got)
+	}
+}
+
+func TestFieldCondition(t *testing.T) {
+	for spec, want := range map[string]Condition{
+		"prod":        {"labels", "=", "prod"},
+		">=2":         {"labels", ">=", "2"},
+		"~github.com": {"labels", "~", "github.com"},
+		"!=":          {"labels", "!=", ""},
+		"":            {"labels", "=", ""},
+	} {
+		if got := FieldCondition("labels", spec); got != want {
+			t.Errorf("FieldCondition(labels, %q) = %+v, want %+v", spec, got, want)
+		}
+	}
+}
+
+func TestParseFilterRange(t *testing.T) {
+	f, err := ParseFilter([]string{"created>=20260101", "created<=20270101"})
+	if err != nil {
+		t.Fatalf("ParseFilter: %v", err)
+	}
+	for created, want := range map[string]bool{
+		"20251231": false,
+		"20260101": true,
+		"20260615": true,
+		"20270101": true,
+		"20270102": false,
+	} {
+		rec := Record{ID: "CASE-1", Fields: map[string]any{"created": created}}
+		if got := f.Match(rec, ""); got != want {
+			t.Errorf("created=%s: Match = %v, want %v", created, got, want)
+		}
+	}
+}
+
+func TestFilterMatch(t *testing.T) {
+	rec := Record{
+		ID: "CASE-1",
+		Fields: map[string]any{
+			"type":       "pr",
+			"claimed_by": "none",
+			"created":    "20260115",
+			"priority":   2,
+			"labels":     []any{"infra", "flaky"},
+		},
+	}
+	tests := []struct {
+		filter Filter
+		want   bool
+	}{
+		{Filter{Equals("type", "pr")}, true},
+		{Filter{Equals("type", "issue")}, false},
+		{Filter{{"claimed_by", "!=", "none"}}, false},
+		{Filter{{"created", ">=", "20260101"}, Equals("type", "pr")}, true},
+		{Filter{{"created", "<", "20260101"}}, false},
+		{Filter{{"priority", "<", "10"}}, true}, // numeric, not lexical
+		{Filter{Equals("labels", "flaky")}, true},
+		{Filter{{"labels", "!=", "flaky"}}, false},
+		{Filter{{"labels", "~", "INF"}}, true},
+		{Filter{Equals("missing", "")}, true},
+		{Filter{{"missing", "!=", ""}}, false},
+		{Filter{Equals("id", "CASE-1")}, true},
+		{Filter{Grep("Retry")}, true},
+		{Filter{Grep("absent")}, false},
+		{nil, true},
+	}
+	for _, tt := range tests {
+		if got := tt.filter.Match(rec, "body with a retry loop"); got != tt.want {
+			t.Errorf("%v.Match = %v, want %v", tt.filter, got, tt.want)
+		}
+	}
+}
+
+func TestSortRecords(t *testing.T) {
+	records := []Record{
+		{ID: "a", Fields: map[string]any{"priority": 10}},
+		{ID: "b", Fields: map[string]any{"priority": 2}},
+		{ID: "c", Fields: map[string]any{"priority": 2}},
+		{ID: "d", Fields: map[string]any{}},
+	}
+	ids := func() string {
+		var out []string
+		for _, r := range records {
+			out = append(out, r.ID)
+		}
+		return strings.Join(out, ",")
+	}
+
+	SortRecords(records, "priority")
+	if got := ids(); got != "d,b,c,a" {
+		t.Errorf("ascending = %s, want d,b,c,a", got)
+	}
+	SortRecords(records, "-priority")
+	if got := ids(); got != "a,b,c,d" {
+		t.Errorf("descending = %s, want a,b,c,d", got)
+	}
+	SortRecords(records, "-id")
+	if got := ids(); got != "d,c,b,a" {
+		t.Errorf("by id = %s, want d,c,b,a", got)
+	}
+}
@@ -45,6 +45,36 @@ func (f *realFS) ReadDir(path string) ([]dal.DirEntry, error) {
 	return result, nil
 }
 
+func (f *realFS) AppendFile(path string, data []byte, perm int) error {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, os.FileMode(perm))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.Write(data)
+	return err
+}
+
+func (f *realFS) CreateExclusive(path string, perm int) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, os.FileMode(perm))
+	if err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+func (f *realFS) Rename(oldPath, newPath string) error {
+	return os.Rename(oldPath, newPath)
+}
+
+func (f *realFS) Remove(path string) error {
+	return os.Remove(path)
+}
+
+func (f *realFS) RemoveAll(path string) error {
+	return os.RemoveAll(path)
+}
+
 func (f *realFS) BaseName(path string) string {
 	return filepath.Base(path)
 }
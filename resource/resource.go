@@ -10,16 +10,18 @@ type Record struct {
 	RawPath string         `json:"raw_path,omitempty"`
 }
 
-// Filter constrains which records are returned by List.
-type Filter map[string]string
+// Filter constrains which records are returned by List: a record must
+// satisfy every condition. A nil Filter matches every record.
+type Filter []Condition
 
 // ResourceSchema describes the shape and rules of a resource kind.
 type ResourceSchema struct {
-	Kind        string
-	Fields      []FieldDef
-	Statuses    []string
-	CreateArgs  []ArgDef
-	Description string
+	Kind          string
+	Fields        []FieldDef
+	Statuses      []string
+	CreateArgs    []ArgDef
+	CreateOptions []ArgDef // passed to Create in opts, as --<name> flags on create
+	Description   string
 }
 
 // FieldDef describes one field in a resource schema.
@@ -27,6 +29,9 @@ type FieldDef struct {
 	Name     string
 	Type     string
 	Required bool
+	// Hidden fields are left out of the default table columns. They are
+	// still in JSON output and shown when selected by name.
+	Hidden bool
 }
 
 // ArgDef describes one argument accepted by Create.
@@ -34,6 +39,9 @@ type ArgDef struct {
 	Name        string
 	Description string
 	Required    bool
+	// Repeatable options take key=value pairs, passed to Create as
+	// opts["<name>.<key>"] = value.
+	Repeatable bool
 }
 
 // Resource is the core interface every agentops resource kind must implement.
@@ -61,7 +69,91 @@ type Syncer interface {
 
 // Transitioner is an optional interface for resources with state machines.
 type Transitioner interface {
-	Transition(ctx *agentops.AppContext, id string, action string) (*Record, error)
+	Transition(ctx *agentops.AppContext, id string, action string, opts ...TransitionOption) (*Record, error)
+}
+
+// TransitionOptions holds the optional details of one transition.
+type TransitionOptions struct {
+	Reason string // why the transition is made, recorded in history
+}
+
+// TransitionOption sets one of the TransitionOptions.
+type TransitionOption func(*TransitionOptions)
+
+// WithReason records why the transition is made.
+func WithReason(reason string) TransitionOption {
+	return func(o *TransitionOptions) { o.Reason = reason }
+}
+
+// ApplyTransitionOptions resolves opts into TransitionOptions.
+func ApplyTransitionOptions(opts ...TransitionOption) TransitionOptions {
+	var o TransitionOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// Claimer is an optional interface for resources owned by one slot at a time.
+// force overrides another slot's ownership.
+type Claimer interface {
+	Claim(ctx *agentops.AppContext, id string, force bool) (*Record, error)
+	Release(ctx *agentops.AppContext, id string, force bool) (*Record, error)
+}
+
+// Historian is an optional interface for resources that keep an audit trail.
+// HistorySchema describes the records History returns.
+type Historian interface {
+	History(ctx *agentops.AppContext, id string) ([]Record, error)
+	HistorySchema() ResourceSchema
+}
+
+// Reconciler is an optional interface for resources that merge worker output
+// back into a record. ReconcileSchema describes the records Reconcile returns.
+type Reconciler interface {
+	Reconcile(ctx *agentops.AppContext, id string) ([]Record, error)
+	ReconcileSchema() ResourceSchema
+}
+
+// SectionEditor is an optional interface for resources with a markdown body
+// organised in headed sections.
+type SectionEditor interface {
+	GetSection(ctx *agentops.AppContext, id, heading string) (string, error)
+	SetSection(ctx *agentops.AppContext, id, heading, content string) (*Record, error)
+	AppendSection(ctx *agentops.AppContext, id, heading, content string) (*Record, error)
+}
+
+// Archiver is an optional interface for resources whose finished instances
+// can be moved out of the working set while staying resolvable by Get.
+type Archiver interface {
+	Archive(ctx *agentops.AppContext, id string) (*Record, error)
+}
+
+// Linker is an optional interface for resources whose records reference one
+// another. Relations names the link kinds Link and Unlink accept.
+type Linker interface {
+	Relations() []string
+	Link(ctx *agentops.AppContext, id, relation, target string) (*Record, error)
+	Unlink(ctx *agentops.AppContext, id, relation, target string) (*Record, error)
+}
+
+// Grapher is an optional interface for resources whose links form a graph.
+// An empty id graphs every linked record; otherwise only the records
+// connected to id.
+type Grapher interface {
+	Graph(ctx *agentops.AppContext, id string) (*Graph, error)
+}
+
+// Assessor is an optional interface for resources whose records are scored
+// for risk. Assess records the resulting level on the record.
+type Assessor interface {
+	Assess(ctx *agentops.AppContext, id string) (*Assessment, error)
+}
+
+// Router is an optional interface for resources whose records are classified
+// and routed to workers. Route records the classification on the record.
+type Router interface {
+	Route(ctx *agentops.AppContext, id string) (*Route, error)
 }
 
 // Doctor is an optional interface for resources that support health checks.
@@ -69,6 +161,13 @@ type Doctor interface {
 	Doctor(ctx *agentops.AppContext) ([]DoctorCheck, error)
 }
 
+// Reindexer is an optional interface for resources that keep a query index
+// alongside their source files. Reindex rebuilds it from the source files and
+// returns the number of records indexed.
+type Reindexer interface {
+	Reindex(ctx *agentops.AppContext) (int, error)
+}
+
 // Pruner is an optional interface for resources that support cleanup of stale entries.
 type Pruner interface {
 	Prune(ctx *agentops.AppContext, confirm bool) ([]PruneResult, error)
@@ -80,12 +179,57 @@ type DoctorCheck struct {
 	Status   string `json:"status"` // ok, warn, err
 	Message  string `json:"message"`
 	Severity string `json:"severity"`
+	Fix      string `json:"fix,omitempty"` // command that resolves the finding
 }
 
 // PruneResult represents a single cleanup action taken or proposed.
 type PruneResult struct {
 	Name   string `json:"name"`
 	Path   string `json:"path"`
-	Action string `json:"action"` // removed, would_remove, skipped
+	Action string `json:"action"` // removed, would_remove, archived, would_archive, skipped
 	Reason string `json:"reason"`
 }
+
+// Assessment is the risk level of a record and the rules that produced it.
+type Assessment struct {
+	ID      string         `json:"id"`
+	Score   int            `json:"score"`
+	Level   string         `json:"level"`
+	Rules   []AssessedRule `json:"rules"`
+	Actions []string       `json:"actions"` // escalation actions for the level
+}
+
+// AssessedRule is a rule that matched during an assessment.
+type AssessedRule struct {
+	Name    string   `json:"name"`
+	Score   int      `json:"score"`
+	Matched []string `json:"matched"` // the signals that satisfied the rule
+}
+
+// Route is the routing decision for a record and the trace explaining it.
+type Route struct {
+	ID      string   `json:"id"`
+	Type    string   `json:"type"`
+	Risk    string   `json:"risk"`
+	Workers []string `json:"workers"`
+	Trace   []string `json:"trace"`
+}
+
+// Graph is a set of records and the links between them.
+type Graph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+// GraphNode is one record in a Graph.
+type GraphNode struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+}
+
+// GraphEdge is a link from one record to another.
+type GraphEdge struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Relation string `json:"relation"`
+}
@@ -74,6 +74,32 @@ func SlotNameFromPath(path, prefix string) (string, error) {
 	return name, nil
 }
 
+// slotMarker is the optional file that pins a directory to a slot name.
+const slotMarker = ".slot"
+
+// DetectSlot returns the slot that dir belongs to. A .slot marker file takes
+// precedence; otherwise the name is derived from the directory basename and the
+// copy_prefix in .agentops/slot.yaml. It returns "" when dir is not a slot copy.
+func DetectSlot(fs dal.FileSystem, dir string) (string, error) {
+	if data, err := fs.ReadFile(filepath.Join(dir, slotMarker)); err == nil {
+		name := strings.TrimSpace(string(data))
+		if err := ValidateSlotName(name); err != nil {
+			return "", fmt.Errorf("%s: %w", slotMarker, err)
+		}
+		return name, nil
+	}
+
+	cfg, err := LoadSlotConfig(fs, filepath.Join(dir, ".agentops"), dir)
+	if err != nil {
+		return "", err
+	}
+	name, err := SlotNameFromPath(dir, cfg.CopyPrefix)
+	if err != nil {
+		return "", nil
+	}
+	return name, nil
+}
+
 // IsDirty returns true if the working tree has uncommitted changes.
 func IsDirty(exec dal.Executor, dir string) (bool, error) {
 	out, err := gitRun(exec, dir, "status", "--porcelain")
@@ -130,7 +130,9 @@ func (s *SlotResource) Create(ctx *agentops.AppContext, slug string, opts map[st
 	return infoToRecord(info), nil
 }
 
-// List returns all slots for the project by scanning sibling directories.
+// List returns all slots for the project by scanning sibling directories,
+// filtered by field conditions. The slot key, which scopes other resources
+// to a slot, is ignored.
 func (s *SlotResource) List(ctx *agentops.AppContext, filter resource.Filter) ([]resource.Record, error) {
 	projectDir, cfg, err := s.loadConfig(ctx)
 	if err != nil {
@@ -144,7 +146,11 @@ func (s *SlotResource) List(ctx *agentops.AppContext, filter resource.Filter) ([
 
 	records := make([]resource.Record, 0, len(infos))
 	for _, info := range infos {
-		records = append(records, *infoToRecord(info))
+		rec := infoToRecord(info)
+		if !filter.Without("slot").Match(*rec, resource.RecordText(*rec)) {
+			continue
+		}
+		records = append(records, *rec)
 	}
 	return records, nil
 }
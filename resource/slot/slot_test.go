@@ -83,6 +83,36 @@ func (f *realFS) ReadDir(path string) ([]dal.DirEntry, error) {
 	return result, nil
 }
 
+func (f *realFS) AppendFile(path string, data []byte, perm int) error {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, os.FileMode(perm))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.Write(data)
+	return err
+}
+
+func (f *realFS) CreateExclusive(path string, perm int) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, os.FileMode(perm))
+	if err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+func (f *realFS) Rename(oldPath, newPath string) error {
+	return os.Rename(oldPath, newPath)
+}
+
+func (f *realFS) Remove(path string) error {
+	return os.Remove(path)
+}
+
+func (f *realFS) RemoveAll(path string) error {
+	return os.RemoveAll(path)
+}
+
 func (f *realFS) BaseName(path string) string {
 	return filepath.Base(path)
 }
@@ -395,6 +425,62 @@ func TestSlotNameFromPath(t *testing.T) {
 	}
 }
 
+// --- DetectSlot ---
+
+func TestDetectSlot(t *testing.T) {
+	fs := &realFS{}
+	parent := t.TempDir()
+
+	// Copy directory named <prefix>-<name> with an explicit copy_prefix.
+	copyDir := filepath.Join(parent, "myrepo-alpha")
+	if err := os.MkdirAll(filepath.Join(copyDir, ".agentops"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(copyDir, ".agentops", "slot.yaml"), []byte("copy_prefix: myrepo\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := DetectSlot(fs, copyDir)
+	if err != nil {
+		t.Fatalf("DetectSlot: %v", err)
+	}
+	if got != "alpha" {
+		t.Errorf("DetectSlot(copy) = %q, want %q", got, "alpha")
+	}
+
+	// The source repo itself is not a slot.
+	mainDir := filepath.Join(parent, "myrepo")
+	if err := os.MkdirAll(mainDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	got, err = DetectSlot(fs, mainDir)
+	if err != nil {
+		t.Fatalf("DetectSlot: %v", err)
+	}
+	if got != "" {
+		t.Errorf("DetectSlot(main) = %q, want empty", got)
+	}
+
+	// A .slot marker overrides path detection.
+	if err := os.WriteFile(filepath.Join(mainDir, ".slot"), []byte("maxwell\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, err = DetectSlot(fs, mainDir)
+	if err != nil {
+		t.Fatalf("DetectSlot: %v", err)
+	}
+	if got != "maxwell" {
+		t.Errorf("DetectSlot(marker) = %q, want %q", got, "maxwell")
+	}
+
+	// An invalid marker is an error.
+	if err := os.WriteFile(filepath.Join(mainDir, ".slot"), []byte("Bad Name"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DetectSlot(fs, mainDir); err == nil {
+		t.Error("expected error for invalid .slot marker")
+	}
+}
+
 // --- IsDirty ---
 
 func TestIsDirty(t *testing.T) {
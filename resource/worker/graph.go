@@ -0,0 +1,242 @@
+package workerresource
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	agentops "github.com/gh-xj/agentops"
+)
+
+// WorkerTypes lists the worker-type values defined by protocol/worker.md.
+var WorkerTypes = []string{"review", "verify", "challenge", "reflect", "triage", "custom"}
+
+// Capabilities lists the capability values defined by protocol/worker.md.
+var Capabilities = []string{"read-only", "can-edit", "can-run-commands"}
+
+// Check validates a set of workers against protocol/worker.md: required
+// fields, known worker types and capabilities, unique names and sidecar paths,
+// resolvable requires and an acyclic requires graph. Each finding's Path is
+// the SKILL.md of the worker it concerns.
+func Check(workers []Worker) []agentops.DoctorFinding {
+	var findings []agentops.DoctorFinding
+	add := func(w Worker, code, format string, args ...any) {
+		findings = append(findings, agentops.DoctorFinding{
+			Code:    code,
+			Path:    w.Path,
+			Message: fmt.Sprintf("worker %s: ", w.Name) + fmt.Sprintf(format, args...),
+		})
+	}
+
+	byName := make(map[string]Worker, len(workers))
+	sidecars := make(map[string][]string)
+	for _, w := range workers {
+		if prev, ok := byName[w.Name]; ok {
+			add(w, "duplicate_worker", "also declared at %s", prev.Path)
+			continue
+		}
+		byName[w.Name] = w
+
+		if w.Type == "" {
+			add(w, "missing_field", "missing required field: worker-type")
+		} else if !contains(WorkerTypes, w.Type) {
+			add(w, "unknown_worker_type", "unknown worker-type %q (want one of %s)", w.Type, strings.Join(WorkerTypes, ", "))
+		}
+		for _, c := range w.Capabilities {
+			if !contains(Capabilities, c) {
+				add(w, "unknown_capability", "unknown capability %q (want one of %s)", c, strings.Join(Capabilities, ", "))
+			}
+		}
+
+		switch {
+		case w.SidecarPath == "":
+			add(w, "missing_field", "missing required field: sidecar-path")
+		case filepath.IsAbs(w.SidecarPath) || escapesCaseDir(w.SidecarPath):
+			add(w, "invalid_sidecar_path", "sidecar-path %q must be relative to the case directory", w.SidecarPath)
+		case filepath.Clean(w.SidecarPath) == "case.md":
+			add(w, "invalid_sidecar_path", "workers must not write to case.md")
+		default:
+			key := filepath.Clean(w.SidecarPath)
+			sidecars[key] = append(sidecars[key], w.Name)
+		}
+	}
+
+	for _, w := range workers {
+		if byName[w.Name].Path != w.Path {
+			continue
+		}
+		if key := filepath.Clean(w.SidecarPath); len(sidecars[key]) > 1 {
+			add(w, "duplicate_sidecar_path", "sidecar-path %q is shared with %s", w.SidecarPath, strings.Join(others(sidecars[key], w.Name), ", "))
+		}
+		for _, req := range w.Requires {
+			if _, ok := byName[req]; !ok {
+				add(w, "unknown_requires", "requires unknown worker %q", req)
+			}
+		}
+	}
+
+	cycles := findCycles(byName)
+	for _, w := range workers {
+		if cycle, ok := cycles[w.Name]; ok && byName[w.Name].Path == w.Path {
+			add(w, "requires_cycle", "requires cycle: %s", cycle)
+		}
+	}
+	return findings
+}
+
+// ExecutionOrder returns workers sorted so every worker comes after the
+// workers it requires. Independent workers keep name order. It fails on
+// unknown requires and on cycles.
+func ExecutionOrder(workers []Worker) ([]Worker, error) {
+	byName := make(map[string]Worker, len(workers))
+	for _, w := range workers {
+		if _, ok := byName[w.Name]; !ok {
+			byName[w.Name] = w
+		}
+	}
+
+	indegree := make(map[string]int, len(byName))
+	dependents := make(map[string][]string)
+	for name, w := range byName {
+		reqs := uniq(w.Requires)
+		for _, req := range reqs {
+			if _, ok := byName[req]; !ok {
+				return nil, fmt.Errorf("worker %s requires unknown worker %q", name, req)
+			}
+			dependents[req] = append(dependents[req], name)
+		}
+		indegree[name] = len(reqs)
+	}
+
+	var ready []string
+	for name, n := range indegree {
+		if n == 0 {
+			ready = append(ready, name)
+		}
+	}
+	sort.Strings(ready)
+
+	order := make([]Worker, 0, len(byName))
+	for len(ready) > 0 {
+		name := ready[0]
+		ready = ready[1:]
+		order = append(order, byName[name])
+		for _, dep := range dependents[name] {
+			indegree[dep]--
+			if indegree[dep] == 0 {
+				ready = append(ready, dep)
+			}
+		}
+		sort.Strings(ready)
+	}
+
+	if len(order) != len(byName) {
+		cycles := findCycles(byName)
+		names := make([]string, 0, len(cycles))
+		for name := range cycles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("requires cycle: %s", cycles[names[0]])
+	}
+	return order, nil
+}
+
+// findCycles maps every worker on a requires cycle to a rendering of that
+// cycle, e.g. "a -> b -> a".
+func findCycles(byName map[string]Worker) map[string]string {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int, len(byName))
+	cycles := make(map[string]string)
+	var stack []string
+
+	var visit func(name string)
+	visit = func(name string) {
+		state[name] = visiting
+		stack = append(stack, name)
+		reqs := append([]string(nil), byName[name].Requires...)
+		sort.Strings(reqs)
+		for _, req := range reqs {
+			if _, ok := byName[req]; !ok {
+				continue
+			}
+			switch state[req] {
+			case unvisited:
+				visit(req)
+			case visiting:
+				start := indexOf(stack, req)
+				members := stack[start:]
+				rendered := strings.Join(append(append([]string(nil), members...), req), " -> ")
+				for _, m := range members {
+					if _, seen := cycles[m]; !seen {
+						cycles[m] = rendered
+					}
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[name] = done
+	}
+
+	names := make([]string, 0, len(byName))
+	for name := range byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if state[name] == unvisited {
+			visit(name)
+		}
+	}
+	return cycles
+}
+
+func escapesCaseDir(p string) bool {
+	clean := filepath.Clean(p)
+	return clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator))
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func indexOf(list []string, s string) int {
+	for i, v := range list {
+		if v == s {
+			return i
+		}
+	}
+	return -1
+}
+
+func others(names []string, self string) []string {
+	var out []string
+	for _, n := range names {
+		if n != self {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+func uniq(list []string) []string {
+	seen := make(map[string]bool, len(list))
+	var out []string
+	for _, v := range list {
+		if !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	return out
+}
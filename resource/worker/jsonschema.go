@@ -0,0 +1,184 @@
+package workerresource
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// schemaKeywords are the keywords ValidateJSON enforces, and
+// annotationKeywords those it may skip because they do not constrain a
+// document.
+var (
+	schemaKeywords     = []string{"type", "enum", "required", "properties", "additionalProperties", "items"}
+	annotationKeywords = []string{"$schema", "$id", "$comment", "title", "description", "default", "examples"}
+)
+
+// CheckSchema reports the first keyword of schema that ValidateJSON cannot
+// enforce, so that a schema relying on one fails to load instead of passing
+// every document. additionalProperties is supported only as a boolean.
+func CheckSchema(schema map[string]any) error {
+	return checkSchema(schema, "$")
+}
+
+func checkSchema(schema map[string]any, path string) error {
+	keys := make([]string, 0, len(schema))
+	for k := range schema {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if !slices.Contains(schemaKeywords, k) && !slices.Contains(annotationKeywords, k) {
+			return fmt.Errorf("%s: unsupported keyword %q", path, k)
+		}
+	}
+	if ap, ok := schema["additionalProperties"]; ok {
+		if _, isBool := ap.(bool); !isBool {
+			return fmt.Errorf("%s: unsupported keyword %q (only a boolean is supported)", path, "additionalProperties")
+		}
+	}
+	if props, ok := schema["properties"].(map[string]any); ok {
+		names := make([]string, 0, len(props))
+		for name := range props {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if sub, ok := props[name].(map[string]any); ok {
+				if err := checkSchema(sub, path+".properties."+name); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if items, ok := schema["items"].(map[string]any); ok {
+		return checkSchema(items, path+".items")
+	}
+	return nil
+}
+
+// ValidateJSON checks a decoded JSON document against a JSON Schema and
+// returns one message per violation, prefixed with the JSON path.
+//
+// Only the keywords sidecar schemas need are supported: type, enum, required,
+// properties, additionalProperties (as a boolean) and items. Schemas are
+// expected to have passed CheckSchema; other keywords are not enforced.
+func ValidateJSON(schema map[string]any, doc any) []string {
+	var errs []string
+	validateJSON(schema, doc, "$", &errs)
+	return errs
+}
+
+func validateJSON(schema map[string]any, v any, path string, errs *[]string) {
+	add := func(format string, args ...any) {
+		*errs = append(*errs, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	if t, ok := schema["type"]; ok {
+		types := schemaTypes(t)
+		if !matchesAnyType(v, types) {
+			add("expected %s, got %s", strings.Join(types, " or "), jsonType(v))
+			return
+		}
+	}
+
+	if enum, ok := schema["enum"].([]any); ok {
+		found := false
+		for _, e := range enum {
+			if fmt.Sprint(e) == fmt.Sprint(v) && jsonType(e) == jsonType(v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			allowed := make([]string, len(enum))
+			for i, e := range enum {
+				allowed[i] = fmt.Sprint(e)
+			}
+			add("must be one of %s", strings.Join(allowed, ", "))
+		}
+	}
+
+	switch val := v.(type) {
+	case map[string]any:
+		if required, ok := schema["required"].([]any); ok {
+			for _, r := range required {
+				if name, ok := r.(string); ok {
+					if _, present := val[name]; !present {
+						add("missing required property %q", name)
+					}
+				}
+			}
+		}
+		props, _ := schema["properties"].(map[string]any)
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if sub, ok := props[k].(map[string]any); ok {
+				validateJSON(sub, val[k], path+"."+k, errs)
+				continue
+			}
+			if extra, ok := schema["additionalProperties"].(bool); ok && !extra {
+				add("unexpected property %q", k)
+			}
+		}
+	case []any:
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range val {
+				validateJSON(items, item, fmt.Sprintf("%s[%d]", path, i), errs)
+			}
+		}
+	}
+}
+
+func schemaTypes(t any) []string {
+	switch tt := t.(type) {
+	case string:
+		return []string{tt}
+	case []any:
+		out := make([]string, 0, len(tt))
+		for _, x := range tt {
+			if s, ok := x.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func matchesAnyType(v any, types []string) bool {
+	actual := jsonType(v)
+	for _, t := range types {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonType names the JSON Schema type of a value decoded by encoding/json.
+func jsonType(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if val == float64(int64(val)) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
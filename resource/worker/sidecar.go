@@ -0,0 +1,120 @@
+package workerresource
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/gh-xj/agentops/dal"
+)
+
+// SidecarSchemaFile is the optional JSON Schema, next to a worker's SKILL.md,
+// that the worker's sidecar must satisfy in addition to the base schema.
+const SidecarSchemaFile = "sidecar.schema.json"
+
+// Severities are the finding severities a sidecar may report, most severe first.
+var Severities = []string{"critical", "high", "medium", "low", "info"}
+
+// Sidecar is the output a worker writes to its sidecar-path.
+type Sidecar struct {
+	Summary           string    `json:"summary,omitempty"`
+	Findings          []Finding `json:"findings"`
+	RecommendedStatus string    `json:"recommended_status,omitempty"`
+	Reason            string    `json:"reason,omitempty"`
+	Usage             Usage     `json:"usage,omitzero"`
+}
+
+// Usage is the optional spend a worker reports for the run that wrote its
+// sidecar.
+type Usage struct {
+	Tokens           int `json:"tokens,omitempty"`
+	WallClockSeconds int `json:"wall_clock_seconds,omitempty"`
+}
+
+// Finding is one observation reported by a worker.
+type Finding struct {
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+	Path     string `json:"path,omitempty"`
+}
+
+// BaseSidecarSchema returns the JSON Schema every sidecar must satisfy.
+func BaseSidecarSchema() map[string]any {
+	severities := make([]any, len(Severities))
+	for i, s := range Severities {
+		severities[i] = s
+	}
+	return map[string]any{
+		"type":     "object",
+		"required": []any{"findings"},
+		"properties": map[string]any{
+			"summary": map[string]any{"type": "string"},
+			"findings": map[string]any{
+				"type": "array",
+				"items": map[string]any{
+					"type":     "object",
+					"required": []any{"severity", "message"},
+					"properties": map[string]any{
+						"severity": map[string]any{"type": "string", "enum": severities},
+						"message":  map[string]any{"type": "string"},
+						"path":     map[string]any{"type": "string"},
+					},
+				},
+			},
+			"recommended_status": map[string]any{"type": "string"},
+			"reason":             map[string]any{"type": "string"},
+			"usage": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"tokens":             map[string]any{"type": "integer"},
+					"wall_clock_seconds": map[string]any{"type": "integer"},
+				},
+			},
+		},
+	}
+}
+
+// SidecarSchema loads the worker's own sidecar schema. It returns nil when
+// the worker does not declare one, and an error when the schema uses a
+// keyword ValidateJSON cannot enforce.
+func SidecarSchema(fs dal.FileSystem, w Worker) (map[string]any, error) {
+	path := filepath.Join(filepath.Dir(w.Path), SidecarSchemaFile)
+	if w.Path == "" || !fs.Exists(path) {
+		return nil, nil
+	}
+	data, err := fs.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", SidecarSchemaFile, err)
+	}
+	var schema map[string]any
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("parse %s of worker %s: %w", SidecarSchemaFile, w.Name, err)
+	}
+	if err := CheckSchema(schema); err != nil {
+		return nil, fmt.Errorf("%s of worker %s: %w", SidecarSchemaFile, w.Name, err)
+	}
+	return schema, nil
+}
+
+// ParseSidecar decodes sidecar JSON and validates it against the base schema
+// and, when not nil, the worker's schema. Violations are returned as problems
+// rather than an error so that callers can report all of them.
+func ParseSidecar(data []byte, workerSchema map[string]any) (Sidecar, []string) {
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return Sidecar{}, []string{fmt.Sprintf("invalid JSON: %v", err)}
+	}
+	problems := ValidateJSON(BaseSidecarSchema(), doc)
+	if workerSchema != nil {
+		problems = append(problems, ValidateJSON(workerSchema, doc)...)
+	}
+	if len(problems) > 0 {
+		return Sidecar{}, problems
+	}
+
+	var sc Sidecar
+	if err := json.Unmarshal(data, &sc); err != nil {
+		return Sidecar{}, []string{fmt.Sprintf("invalid sidecar: %v", err)}
+	}
+	return sc, nil
+}
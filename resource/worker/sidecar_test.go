@@ -0,0 +1,96 @@
+package workerresource
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gh-xj/agentops/dal"
+)
+
+func TestParseSidecar(t *testing.T) {
+	sc, problems := ParseSidecar([]byte(`{
+		"summary": "two issues",
+		"findings": [
+			{"severity": "high", "message": "race in cache", "path": "cache.go"},
+			{"severity": "info", "message": "naming"}
+		],
+		"recommended_status": "resolved"
+	}`), nil)
+	if len(problems) > 0 {
+		t.Fatalf("unexpected problems: %v", problems)
+	}
+	if len(sc.Findings) != 2 || sc.Findings[0].Path != "cache.go" || sc.RecommendedStatus != "resolved" {
+		t.Errorf("sidecar = %+v", sc)
+	}
+}
+
+func TestParseSidecarReportsEveryProblem(t *testing.T) {
+	_, problems := ParseSidecar([]byte(`{"findings": [{"severity": "urgent"}, "x"], "summary": 3}`), nil)
+	got := strings.Join(problems, "\n")
+	for _, want := range []string{
+		`$.findings[0]: missing required property "message"`,
+		`$.findings[0].severity: must be one of critical, high, medium, low, info`,
+		`$.findings[1]: expected object, got string`,
+		`$.summary: expected string, got integer`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing problem %q in:\n%s", want, got)
+		}
+	}
+
+	if _, problems := ParseSidecar([]byte(`not json`), nil); len(problems) != 1 || !strings.HasPrefix(problems[0], "invalid JSON") {
+		t.Errorf("problems = %v", problems)
+	}
+}
+
+func TestSidecarWorkerSchema(t *testing.T) {
+	root := t.TempDir()
+	writeSkill(t, root, ".agentops/workers", "verify", "worker-type: verify\nsidecar-path: verify.json\n")
+	schema := `{"required": ["commands"], "properties": {"commands": {"type": "array", "items": {"type": "string"}}}, "additionalProperties": true}`
+	if err := os.WriteFile(filepath.Join(root, ".agentops/workers/verify", SidecarSchemaFile), []byte(schema), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	fs := dal.NewFileSystem()
+	workers, err := Discover(fs, root)
+	if err != nil {
+		t.Fatalf("Discover: %v", err)
+	}
+	ws, err := SidecarSchema(fs, workers[0])
+	if err != nil || ws == nil {
+		t.Fatalf("SidecarSchema = %v, %v", ws, err)
+	}
+
+	_, problems := ParseSidecar([]byte(`{"findings": []}`), ws)
+	if len(problems) != 1 || problems[0] != `$: missing required property "commands"` {
+		t.Errorf("problems = %v", problems)
+	}
+	if _, problems := ParseSidecar([]byte(`{"findings": [], "commands": ["go test ./..."]}`), ws); len(problems) > 0 {
+		t.Errorf("valid sidecar rejected: %v", problems)
+	}
+}
+
+func TestSidecarSchemaRejectsUnsupportedKeywords(t *testing.T) {
+	for schema, want := range map[string]string{
+		`{"properties": {"name": {"type": "string", "pattern": "^[a-z]+$"}}}`: `$.properties.name: unsupported keyword "pattern"`,
+		`{"oneOf": [{"type": "string"}]}`:                                     `$: unsupported keyword "oneOf"`,
+		`{"items": {"$ref": "#/definitions/x"}}`:                              `$.items: unsupported keyword "$ref"`,
+		`{"additionalProperties": {"type": "string"}}`:                        `$: unsupported keyword "additionalProperties"`,
+	} {
+		root := t.TempDir()
+		writeSkill(t, root, ".agentops/workers", "verify", "worker-type: verify\nsidecar-path: verify.json\n")
+		if err := os.WriteFile(filepath.Join(root, ".agentops/workers/verify", SidecarSchemaFile), []byte(schema), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		fs := dal.NewFileSystem()
+		workers, err := Discover(fs, root)
+		if err != nil {
+			t.Fatalf("Discover: %v", err)
+		}
+		if _, err := SidecarSchema(fs, workers[0]); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("SidecarSchema(%s) error = %v, want %s", schema, err, want)
+		}
+	}
+}
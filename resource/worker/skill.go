@@ -0,0 +1,94 @@
+package workerresource
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gh-xj/agentops/dal"
+	"gopkg.in/yaml.v3"
+)
+
+// skillFile is the file every worker directory must contain.
+const skillFile = "SKILL.md"
+
+// Worker is a worker skill declared by SKILL.md frontmatter.
+type Worker struct {
+	Name         string   `yaml:"-"`
+	Type         string   `yaml:"worker-type"`
+	SidecarPath  string   `yaml:"sidecar-path"`
+	Blocking     bool     `yaml:"blocking"`
+	Requires     []string `yaml:"requires"`
+	Capabilities []string `yaml:"capabilities"`
+	Path         string   `yaml:"-"` // absolute path to SKILL.md
+}
+
+// SearchDirs returns the directories workers are discovered in, relative to
+// the project root, in precedence order.
+func SearchDirs() []string {
+	return []string{
+		filepath.Join(".agentops", "workers"),
+		filepath.Join(".claude", "skills"),
+	}
+}
+
+// Discover loads every worker declared under root. Legacy .agentops/workers
+// entries are always workers; .claude/skills entries are workers only when
+// they declare worker-type. Workers are returned sorted by name, with entries
+// from earlier search directories first on name collisions.
+func Discover(fs dal.FileSystem, root string) ([]Worker, error) {
+	var workers []Worker
+	for i, rel := range SearchDirs() {
+		dir := filepath.Join(root, rel)
+		if !fs.Exists(dir) {
+			continue
+		}
+		entries, err := fs.ReadDir(dir)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", rel, err)
+		}
+		for _, entry := range entries {
+			if !entry.IsDir {
+				continue
+			}
+			path := filepath.Join(dir, entry.Name, skillFile)
+			if !fs.Exists(path) {
+				continue
+			}
+			data, err := fs.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("read %s: %w", path, err)
+			}
+			w, err := ParseSkill(entry.Name, string(data))
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+			// Plain skills without worker-type are not workers.
+			if i > 0 && w.Type == "" {
+				continue
+			}
+			w.Path = path
+			workers = append(workers, w)
+		}
+	}
+	sort.SliceStable(workers, func(a, b int) bool { return workers[a].Name < workers[b].Name })
+	return workers, nil
+}
+
+// ParseSkill parses the frontmatter of a SKILL.md file into a Worker named name.
+func ParseSkill(name, content string) (Worker, error) {
+	w := Worker{Name: name}
+	if !strings.HasPrefix(content, "---\n") {
+		return w, nil
+	}
+	rest := content[4:]
+	endIdx := strings.Index(rest, "\n---")
+	if endIdx < 0 {
+		return w, fmt.Errorf("unterminated YAML frontmatter")
+	}
+	if err := yaml.Unmarshal([]byte(rest[:endIdx]), &w); err != nil {
+		return w, fmt.Errorf("parse frontmatter: %w", err)
+	}
+	return w, nil
+}
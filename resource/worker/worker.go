@@ -0,0 +1,153 @@
+// Package workerresource exposes worker skills declared per protocol/worker.md
+// as a read-only resource.
+package workerresource
+
+import (
+	"fmt"
+
+	agentops "github.com/gh-xj/agentops"
+	"github.com/gh-xj/agentops/dal"
+	"github.com/gh-xj/agentops/resource"
+	"github.com/gh-xj/agentops/strategy"
+)
+
+// WorkerResource implements Resource and Validator for worker skills.
+type WorkerResource struct {
+	fs    dal.FileSystem
+	strat *strategy.Strategy
+}
+
+// New creates a WorkerResource. strat may be nil; operations will return errors.
+func New(fs dal.FileSystem, strat *strategy.Strategy) *WorkerResource {
+	return &WorkerResource{fs: fs, strat: strat}
+}
+
+// Schema returns the resource schema for workers.
+func (wr *WorkerResource) Schema() resource.ResourceSchema {
+	return resource.ResourceSchema{
+		Kind:        "worker",
+		Description: "Worker skill declared by SKILL.md frontmatter",
+		Fields: []resource.FieldDef{
+			{Name: "name", Type: "string", Required: true},
+			{Name: "worker_type", Type: "string", Required: true},
+			{Name: "sidecar_path", Type: "string", Required: true},
+			{Name: "blocking", Type: "bool"},
+			{Name: "requires", Type: "[]string"},
+			{Name: "capabilities", Type: "[]string"},
+		},
+	}
+}
+
+// Workers discovers all workers declared in the project.
+func (wr *WorkerResource) Workers() ([]Worker, error) {
+	if wr.strat == nil {
+		return nil, fmt.Errorf("no strategy loaded")
+	}
+	return Discover(wr.fs, wr.strat.Root)
+}
+
+// Create is not supported: workers are declared by writing a SKILL.md.
+func (wr *WorkerResource) Create(ctx *agentops.AppContext, slug string, opts map[string]string) (*resource.Record, error) {
+	return nil, fmt.Errorf("workers are declared in .agentops/workers/<name>/SKILL.md or .claude/skills/<name>/SKILL.md")
+}
+
+// List returns all workers matching filter. The resource.GrepKey search runs
+// over each worker's SKILL.md.
+func (wr *WorkerResource) List(ctx *agentops.AppContext, filter resource.Filter) ([]resource.Record, error) {
+	workers, err := wr.Workers()
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]resource.Record, 0, len(workers))
+	for _, w := range workers {
+		rec := workerToRecord(w)
+		text, err := wr.fs.ReadFile(w.Path)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", w.Path, err)
+		}
+		if !filter.Match(*rec, string(text)) {
+			continue
+		}
+		records = append(records, *rec)
+	}
+	return records, nil
+}
+
+// Get returns a single worker by name.
+func (wr *WorkerResource) Get(ctx *agentops.AppContext, id string) (*resource.Record, error) {
+	workers, err := wr.Workers()
+	if err != nil {
+		return nil, err
+	}
+	for _, w := range workers {
+		if w.Name == id {
+			return workerToRecord(w), nil
+		}
+	}
+	return nil, fmt.Errorf("worker %q not found", id)
+}
+
+// Validate checks a worker against protocol/worker.md. Graph-wide rules
+// (duplicate sidecar paths, requires cycles) are evaluated over all workers
+// and reported for each worker they involve.
+func (wr *WorkerResource) Validate(ctx *agentops.AppContext, id string) (*agentops.DoctorReport, error) {
+	workers, err := wr.Workers()
+	if err != nil {
+		return nil, err
+	}
+
+	var target *Worker
+	for i := range workers {
+		if workers[i].Name == id {
+			target = &workers[i]
+			break
+		}
+	}
+	if target == nil {
+		return nil, fmt.Errorf("worker %q not found", id)
+	}
+
+	report := &agentops.DoctorReport{
+		SchemaVersion: "1.0",
+		OK:            true,
+	}
+	for _, f := range Check(workers) {
+		if f.Path != target.Path {
+			continue
+		}
+		report.OK = false
+		report.Findings = append(report.Findings, f)
+	}
+	return report, nil
+}
+
+// Ordered discovers all workers, rejects an invalid worker graph and returns
+// the workers in execution order.
+func (wr *WorkerResource) Ordered() ([]Worker, error) {
+	workers, err := wr.Workers()
+	if err != nil {
+		return nil, err
+	}
+	if findings := Check(workers); len(findings) > 0 {
+		return nil, agentops.NewCLIError(agentops.ExitValidationFailed, "invalid_workers", fmt.Sprintf("%d worker findings, first: %s", len(findings), findings[0].Message), nil)
+	}
+	return ExecutionOrder(workers)
+}
+
+// workerToRecord converts a Worker to a resource.Record.
+func workerToRecord(w Worker) *resource.Record {
+	return &resource.Record{
+		Kind: "worker",
+		ID:   w.Name,
+		Fields: map[string]any{
+			"name":         w.Name,
+			"worker_type":  w.Type,
+			"sidecar_path": w.SidecarPath,
+			"blocking":     w.Blocking,
+			"requires":     w.Requires,
+			"capabilities": w.Capabilities,
+		},
+		RawPath: w.Path,
+	}
+}
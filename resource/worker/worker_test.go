@@ -0,0 +1,176 @@
+package workerresource
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	agentops "github.com/gh-xj/agentops"
+	"github.com/gh-xj/agentops/dal"
+	"github.com/gh-xj/agentops/resource"
+	"github.com/gh-xj/agentops/strategy"
+)
+
+// writeSkill writes a SKILL.md under root/dir/name with the given frontmatter.
+func writeSkill(t *testing.T, root, dir, name, frontmatter string) {
+	t.Helper()
+	skillDir := filepath.Join(root, dir, name)
+	if err := os.MkdirAll(skillDir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	content := "---\n" + frontmatter + "---\n\n# " + name + "\n"
+	if err := os.WriteFile(filepath.Join(skillDir, "SKILL.md"), []byte(content), 0o644); err != nil {
+		t.Fatalf("write skill: %v", err)
+	}
+}
+
+func newTestResource(t *testing.T, root string) (*WorkerResource, *agentops.AppContext) {
+	t.Helper()
+	wr := New(dal.NewFileSystem(), &strategy.Strategy{Root: root})
+	return wr, agentops.NewAppContext(context.Background())
+}
+
+func findingCodes(t *testing.T, wr *WorkerResource, ctx *agentops.AppContext, id string) []string {
+	t.Helper()
+	report, err := wr.Validate(ctx, id)
+	if err != nil {
+		t.Fatalf("Validate %s: %v", id, err)
+	}
+	var codes []string
+	for _, f := range report.Findings {
+		codes = append(codes, f.Code)
+	}
+	return codes
+}
+
+func TestDiscoverBothLocations(t *testing.T) {
+	root := t.TempDir()
+	writeSkill(t, root, ".agentops/workers", "reviewer", "worker-type: review\nsidecar-path: review.md\nblocking: true\ncapabilities: [read-only]\n")
+	writeSkill(t, root, ".claude/skills", "verifier", "worker-type: verify\nsidecar-path: verify.md\nrequires: [reviewer]\n")
+	writeSkill(t, root, ".claude/skills", "plain-skill", "description: not a worker\n")
+
+	wr, ctx := newTestResource(t, root)
+	records, err := wr.List(ctx, nil)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected 2 workers, got %d: %+v", len(records), records)
+	}
+
+	rec, err := wr.Get(ctx, "verifier")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if rec.Fields["worker_type"] != "verify" || rec.Fields["sidecar_path"] != "verify.md" {
+		t.Errorf("unexpected fields: %+v", rec.Fields)
+	}
+	if _, err := wr.Get(ctx, "plain-skill"); err == nil {
+		t.Error("skill without worker-type should not be a worker")
+	}
+
+	filtered, err := wr.List(ctx, resource.Filter{resource.Equals("worker_type", "review")})
+	if err != nil {
+		t.Fatalf("List filtered: %v", err)
+	}
+	if len(filtered) != 1 || filtered[0].ID != "reviewer" {
+		t.Errorf("filtered list = %+v", filtered)
+	}
+}
+
+func TestValidateReportsProtocolViolations(t *testing.T) {
+	root := t.TempDir()
+	dir := ".agentops/workers"
+	writeSkill(t, root, dir, "a", "worker-type: review\nsidecar-path: out.md\nrequires: [b]\n")
+	writeSkill(t, root, dir, "b", "worker-type: verify\nsidecar-path: out.md\nrequires: [a]\n")
+	writeSkill(t, root, dir, "c", "worker-type: wizard\nsidecar-path: ../c.md\ncapabilities: [can-fly]\nrequires: [ghost]\n")
+	writeSkill(t, root, dir, "ok", "worker-type: custom\nsidecar-path: ok.md\ncapabilities: [can-edit, can-run-commands]\n")
+
+	wr, ctx := newTestResource(t, root)
+
+	for _, id := range []string{"a", "b"} {
+		codes := strings.Join(findingCodes(t, wr, ctx, id), ",")
+		if !strings.Contains(codes, "duplicate_sidecar_path") || !strings.Contains(codes, "requires_cycle") {
+			t.Errorf("%s findings = %s, want duplicate_sidecar_path and requires_cycle", id, codes)
+		}
+	}
+
+	codes := strings.Join(findingCodes(t, wr, ctx, "c"), ",")
+	for _, want := range []string{"unknown_worker_type", "unknown_capability", "invalid_sidecar_path", "unknown_requires"} {
+		if !strings.Contains(codes, want) {
+			t.Errorf("c findings = %s, missing %s", codes, want)
+		}
+	}
+
+	report, err := wr.Validate(ctx, "ok")
+	if err != nil {
+		t.Fatalf("Validate ok: %v", err)
+	}
+	if !report.OK {
+		t.Errorf("expected ok worker to validate, got %+v", report.Findings)
+	}
+
+	if _, err := wr.Ordered(); agentops.ResolveExitCode(err) != agentops.ExitValidationFailed {
+		t.Errorf("Ordered on invalid graph: err = %v, want validation failure", err)
+	}
+}
+
+func TestCycleMessage(t *testing.T) {
+	workers := []Worker{
+		{Name: "a", Type: "review", SidecarPath: "a.md", Requires: []string{"b"}},
+		{Name: "b", Type: "review", SidecarPath: "b.md", Requires: []string{"c"}},
+		{Name: "c", Type: "review", SidecarPath: "c.md", Requires: []string{"a"}},
+		{Name: "d", Type: "review", SidecarPath: "d.md", Requires: []string{"a"}},
+	}
+	var cycleFindings []string
+	for _, f := range Check(workers) {
+		if f.Code == "requires_cycle" {
+			cycleFindings = append(cycleFindings, f.Message)
+		}
+	}
+	if len(cycleFindings) != 3 {
+		t.Fatalf("expected cycle reported for a, b, c only, got %v", cycleFindings)
+	}
+	if !strings.Contains(cycleFindings[0], "a -> b -> c -> a") {
+		t.Errorf("cycle message = %q", cycleFindings[0])
+	}
+
+	if _, err := ExecutionOrder(workers); err == nil || !strings.Contains(err.Error(), "requires cycle") {
+		t.Errorf("ExecutionOrder err = %v, want requires cycle", err)
+	}
+}
+
+func TestExecutionOrder(t *testing.T) {
+	workers := []Worker{
+		{Name: "reflect", Requires: []string{"review", "verify"}},
+		{Name: "review", Requires: []string{"triage"}},
+		{Name: "triage"},
+		{Name: "verify", Requires: []string{"triage"}},
+		{Name: "challenge"},
+	}
+	order, err := ExecutionOrder(workers)
+	if err != nil {
+		t.Fatalf("ExecutionOrder: %v", err)
+	}
+	var names []string
+	for _, w := range order {
+		names = append(names, w.Name)
+	}
+	got := strings.Join(names, ",")
+	if want := "challenge,triage,review,verify,reflect"; got != want {
+		t.Errorf("order = %s, want %s", got, want)
+	}
+
+	if _, err := ExecutionOrder([]Worker{{Name: "x", Requires: []string{"missing"}}}); err == nil {
+		t.Error("expected error for unknown requires")
+	}
+}
+
+func TestWorkersWithoutStrategy(t *testing.T) {
+	wr := New(dal.NewFileSystem(), nil)
+	if _, err := wr.List(agentops.NewAppContext(context.Background()), nil); err == nil {
+		t.Error("expected error without strategy")
+	}
+}
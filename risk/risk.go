@@ -0,0 +1,166 @@
+// Package risk scores cases against the rules declared in .agentops/risk.yaml
+// for the assess-risk phase of protocol/lifecycle.md.
+package risk
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/gh-xj/agentops/strategy"
+)
+
+// Subject holds the signals of a case that rules match against.
+type Subject struct {
+	Type   string
+	Paths  []string // paths the case touches, slash-separated
+	Labels []string
+	Body   string
+}
+
+// Hit is a rule that matched, with the signals that matched it.
+type Hit struct {
+	Rule    string
+	Score   int
+	Matched []string
+}
+
+// Assessment is the outcome of scoring a case.
+type Assessment struct {
+	Score   int
+	Level   string
+	Hits    []Hit    // in rule name order
+	Actions []string // escalation actions for Level
+}
+
+// Assess scores subj against the rules of cfg and derives its level and
+// escalation actions. It fails when a rule has a malformed path pattern.
+func Assess(cfg strategy.RiskConfig, subj Subject) (Assessment, error) {
+	names := make([]string, 0, len(cfg.Rules))
+	for name := range cfg.Rules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var a Assessment
+	for _, name := range names {
+		matched, ok, err := match(cfg.Rules[name], subj)
+		if err != nil {
+			return Assessment{}, fmt.Errorf("risk rule %q: %w", name, err)
+		}
+		if !ok {
+			continue
+		}
+		score := cfg.Rules[name].Score
+		a.Score += score
+		a.Hits = append(a.Hits, Hit{Rule: name, Score: score, Matched: matched})
+	}
+	a.Level = Level(cfg.Thresholds, a.Score)
+	a.Actions = cfg.Escalation[a.Level]
+	return a, nil
+}
+
+// Level returns the highest risk level whose threshold score reaches. Levels
+// without a threshold are never reached, except low, which is the floor.
+func Level(thresholds map[string]int, score int) string {
+	level := strategy.RiskLow
+	for _, l := range strategy.RiskLevels[1:] {
+		if min, ok := thresholds[l]; ok && score >= min {
+			level = l
+		}
+	}
+	return level
+}
+
+// match reports whether every condition of rule holds for subj, and which
+// signals satisfied them. A rule without conditions never matches.
+func match(rule strategy.RiskRule, subj Subject) ([]string, bool, error) {
+	pathHit, err := matchPaths(rule.Paths, subj.Paths)
+	if err != nil {
+		return nil, false, err
+	}
+	conditions := []struct {
+		given bool
+		hit   string
+	}{
+		{len(rule.Types) > 0, matchType(rule.Types, subj.Type)},
+		{len(rule.Paths) > 0, pathHit},
+		{len(rule.Labels) > 0, matchLabels(rule.Labels, subj.Labels)},
+		{len(rule.Keywords) > 0, matchKeywords(rule.Keywords, subj.Body)},
+	}
+	var matched []string
+	for _, c := range conditions {
+		if !c.given {
+			continue
+		}
+		if c.hit == "" {
+			return nil, false, nil
+		}
+		matched = append(matched, c.hit)
+	}
+	return matched, len(matched) > 0, nil
+}
+
+func matchType(types []string, caseType string) string {
+	for _, t := range types {
+		if t == caseType {
+			return "type " + t
+		}
+	}
+	return ""
+}
+
+func matchLabels(want, labels []string) string {
+	for _, w := range want {
+		for _, l := range labels {
+			if strings.EqualFold(l, w) {
+				return "label " + l
+			}
+		}
+	}
+	return ""
+}
+
+func matchKeywords(keywords []string, body string) string {
+	body = strings.ToLower(body)
+	for _, kw := range keywords {
+		if kw != "" && strings.Contains(body, strings.ToLower(kw)) {
+			return fmt.Sprintf("keyword %q", kw)
+		}
+	}
+	return ""
+}
+
+// matchPaths returns a description of the first touched path matching one of
+// the globs, or "" when none does.
+func matchPaths(globs, paths []string) (string, error) {
+	for _, glob := range globs {
+		for _, p := range paths {
+			ok, err := MatchPath(glob, p)
+			if err != nil {
+				return "", err
+			}
+			if ok {
+				return fmt.Sprintf("path %s (%s)", path.Clean(p), glob), nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// MatchPath reports whether the slash-separated path p matches glob, using
+// the syntax of path.Match. A glob ending in "/**" also matches everything
+// below the directories it matches.
+func MatchPath(glob, p string) (bool, error) {
+	p = path.Clean(p)
+	ok, err := path.Match(glob, p)
+	if err != nil {
+		return false, fmt.Errorf("bad path pattern %q: %w", glob, err)
+	}
+	dir, recursive := strings.CutSuffix(glob, "/**")
+	for parent := path.Dir(p); !ok && recursive && parent != "." && parent != "/"; parent = path.Dir(parent) {
+		ok, _ = path.Match(dir, parent) // dir is valid if glob is
+	}
+	return ok, nil
+}
@@ -0,0 +1,136 @@
+package risk
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gh-xj/agentops/strategy"
+)
+
+func testConfig() strategy.RiskConfig {
+	return strategy.RiskConfig{
+		Thresholds: map[string]int{"medium": 3, "high": 6},
+		Escalation: map[string][]string{"high": {"require-review", "notify-owner"}},
+		Rules: map[string]strategy.RiskRule{
+			"auth":     {Score: 4, Paths: []string{"internal/auth/**"}},
+			"incident": {Score: 3, Types: []string{"incident"}},
+			"security": {Score: 2, Labels: []string{"security"}, Keywords: []string{"password", "token"}},
+			"docs":     {Score: -1, Paths: []string{"docs/*.md"}},
+		},
+	}
+}
+
+func TestAssess(t *testing.T) {
+	cases := []struct {
+		name    string
+		subj    Subject
+		score   int
+		level   string
+		rules   []string
+		actions []string
+	}{
+		{
+			name:  "nothing matches",
+			subj:  Subject{Type: "bug", Body: "typo in the footer"},
+			level: "low",
+		},
+		{
+			name:  "type only",
+			subj:  Subject{Type: "incident"},
+			score: 3, level: "medium", rules: []string{"incident"},
+		},
+		{
+			name:  "all conditions of a rule must hold",
+			subj:  Subject{Type: "bug", Labels: []string{"Security"}, Body: "rotate the API token"},
+			score: 2, level: "low", rules: []string{"security"},
+		},
+		{
+			name:  "label without keyword",
+			subj:  Subject{Labels: []string{"security"}, Body: "update copy"},
+			level: "low",
+		},
+		{
+			name:  "scores add up",
+			subj:  Subject{Type: "incident", Paths: []string{"internal/auth/session/store.go"}},
+			score: 7, level: "high", rules: []string{"auth", "incident"},
+			actions: []string{"require-review", "notify-owner"},
+		},
+		{
+			name:  "negative scores lower the total",
+			subj:  Subject{Type: "incident", Paths: []string{"docs/runbook.md"}},
+			score: 2, level: "low", rules: []string{"docs", "incident"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			a, err := Assess(testConfig(), tc.subj)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var rules []string
+			for _, h := range a.Hits {
+				rules = append(rules, h.Rule)
+			}
+			if a.Score != tc.score || a.Level != tc.level || !reflect.DeepEqual(rules, tc.rules) || !reflect.DeepEqual(a.Actions, tc.actions) {
+				t.Errorf("got score %d level %s rules %v actions %v, want %d %s %v %v",
+					a.Score, a.Level, rules, a.Actions, tc.score, tc.level, tc.rules, tc.actions)
+			}
+		})
+	}
+}
+
+func TestAssessExplainsMatches(t *testing.T) {
+	a, err := Assess(testConfig(), Subject{Labels: []string{"security"}, Body: "Leaked PASSWORD in logs", Paths: []string{"./internal/auth/login.go"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Hit{
+		{Rule: "auth", Score: 4, Matched: []string{"path internal/auth/login.go (internal/auth/**)"}},
+		{Rule: "security", Score: 2, Matched: []string{"label security", `keyword "password"`}},
+	}
+	if !reflect.DeepEqual(a.Hits, want) {
+		t.Errorf("hits = %+v, want %+v", a.Hits, want)
+	}
+}
+
+func TestAssessBadPattern(t *testing.T) {
+	cfg := strategy.RiskConfig{Rules: map[string]strategy.RiskRule{"bad": {Score: 1, Paths: []string{"src/["}}}}
+	if _, err := Assess(cfg, Subject{Paths: []string{"src/main.go"}}); err == nil {
+		t.Error("expected an error for a malformed pattern")
+	}
+}
+
+func TestLevel(t *testing.T) {
+	thresholds := map[string]int{"medium": 3, "high": 6}
+	for score, want := range map[int]string{-2: "low", 0: "low", 3: "medium", 5: "medium", 6: "high", 40: "high"} {
+		if got := Level(thresholds, score); got != want {
+			t.Errorf("Level(%d) = %s, want %s", score, got, want)
+		}
+	}
+	if got := Level(nil, 100); got != "low" {
+		t.Errorf("Level without thresholds = %s, want low", got)
+	}
+}
+
+func TestMatchPath(t *testing.T) {
+	for _, tc := range []struct {
+		glob, path string
+		want       bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "cmd/main.go", false},
+		{"cmd/*/main.go", "cmd/agentops/main.go", true},
+		{"internal/**", "internal/a/b/c.go", true},
+		{"internal/**", "internal", false},
+		{"internal/**", "internalx/a.go", false},
+		{"*/auth/**", "pkg/auth/token/jwt.go", true},
+	} {
+		got, err := MatchPath(tc.glob, tc.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tc.want {
+			t.Errorf("MatchPath(%q, %q) = %v, want %v", tc.glob, tc.path, got, tc.want)
+		}
+	}
+}
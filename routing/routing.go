@@ -0,0 +1,208 @@
+// Package routing classifies cases and selects their workers with the rules
+// declared in .agentops/routing.yaml, for the classify and select-workers
+// phases of protocol/lifecycle.md. Every decision carries a trace of the
+// rules it considered.
+package routing
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gh-xj/agentops/resource"
+	workerresource "github.com/gh-xj/agentops/resource/worker"
+	"github.com/gh-xj/agentops/strategy"
+)
+
+// Subject holds what routing knows about a case.
+type Subject struct {
+	Type   string // current case type
+	Title  string
+	Body   string
+	Fields map[string]any // frontmatter
+}
+
+// Classification is the case type chosen by Classify.
+type Classification struct {
+	Type  string
+	Cue   string // cue that assigned Type; empty when none matched
+	Trace []string
+}
+
+// Selection is the set of workers chosen by SelectWorkers.
+type Selection struct {
+	Workers  []workerresource.Worker // in execution order
+	Override string                  // override that chose the workers, if any
+	Trace    []string
+}
+
+// Classify assigns a case type from the first cue, in name order, whose
+// conditions all hold for subj. When no cue matches, the type is
+// default_route.type, or the current type when that is unset. It fails when
+// a cue pattern does not compile.
+func Classify(cfg strategy.RoutingConfig, subj Subject) (Classification, error) {
+	var c Classification
+	for _, name := range sortedKeys(cfg.Cues) {
+		cue := cfg.Cues[name]
+		ok, why, err := matchCue(cue, subj)
+		if err != nil {
+			return Classification{}, fmt.Errorf("routing cue %q: %w", name, err)
+		}
+		if !ok {
+			c.Trace = append(c.Trace, fmt.Sprintf("cue %s: no match (%s)", name, why))
+			continue
+		}
+		c.Type, c.Cue = cue.Type, name
+		c.Trace = append(c.Trace, fmt.Sprintf("cue %s: matched (%s) -> type %s", name, why, cue.Type))
+		return c, nil
+	}
+	switch {
+	case cfg.DefaultRoute.Type != "":
+		c.Type = cfg.DefaultRoute.Type
+		c.Trace = append(c.Trace, "no cue matched -> default type "+c.Type)
+	default:
+		c.Type = subj.Type
+		c.Trace = append(c.Trace, "no cue matched -> keep type "+orNone(c.Type))
+	}
+	return c, nil
+}
+
+// matchCue reports whether every condition of cue holds for subj, with a
+// description of the conditions checked. A cue without conditions never
+// matches.
+func matchCue(cue strategy.RouteCue, subj Subject) (bool, string, error) {
+	var checked []string
+	for _, p := range []struct{ field, pattern, text string }{
+		{"title", cue.Title, subj.Title},
+		{"body", cue.Body, subj.Body},
+	} {
+		if p.pattern == "" {
+			continue
+		}
+		re, err := regexp.Compile(p.pattern)
+		if err != nil {
+			return false, "", fmt.Errorf("%s: %w", p.field, err)
+		}
+		if !re.MatchString(p.text) {
+			return false, fmt.Sprintf("%s does not match %q", p.field, p.pattern), nil
+		}
+		checked = append(checked, fmt.Sprintf("%s matches %q", p.field, p.pattern))
+	}
+
+	rec := resource.Record{Fields: subj.Fields}
+	for _, key := range sortedKeys(cue.Fields) {
+		cond := resource.Filter{resource.FieldCondition(key, cue.Fields[key])}
+		if !cond.Match(rec, "") {
+			return false, fmt.Sprintf("%s is not %s", key, cue.Fields[key]), nil
+		}
+		checked = append(checked, fmt.Sprintf("%s is %s", key, cue.Fields[key]))
+	}
+
+	if len(checked) == 0 {
+		return false, "no conditions", nil
+	}
+	return true, strings.Join(checked, ", "), nil
+}
+
+// SelectWorkers maps a case type and risk level to workers. The most
+// specific override matching both wins, ties going to the first in name
+// order; otherwise default_route.by_type, then default_route.workers. When
+// routing.yaml names no workers for the case, every registered worker is
+// selected. Workers missing from registered are skipped and the workers a
+// selected worker requires are added. registered must be in execution order,
+// which the selection keeps.
+func SelectWorkers(cfg strategy.RoutingConfig, caseType, risk string, registered []workerresource.Worker) Selection {
+	var s Selection
+	names, source, routed := route(cfg, caseType, risk)
+	if !routed {
+		s.Trace = append(s.Trace, fmt.Sprintf("no route for type %s, risk %s -> all registered workers", orNone(caseType), orNone(risk)))
+		s.Workers = append(s.Workers, registered...)
+		return s
+	}
+	if strings.HasPrefix(source, "override ") {
+		s.Override = strings.TrimPrefix(source, "override ")
+	}
+	s.Trace = append(s.Trace, fmt.Sprintf("%s -> workers %s", source, orNone(strings.Join(names, ", "))))
+
+	byName := make(map[string]workerresource.Worker, len(registered))
+	for _, w := range registered {
+		byName[w.Name] = w
+	}
+	selected := map[string]bool{}
+	var add func(name, via string)
+	add = func(name, via string) {
+		if selected[name] {
+			return
+		}
+		w, ok := byName[name]
+		if !ok {
+			s.Trace = append(s.Trace, fmt.Sprintf("worker %s is not registered; skipped", name))
+			return
+		}
+		selected[name] = true
+		if via != "" {
+			s.Trace = append(s.Trace, fmt.Sprintf("worker %s added: required by %s", name, via))
+		}
+		for _, req := range w.Requires {
+			add(req, name)
+		}
+	}
+	for _, name := range names {
+		add(name, "")
+	}
+	for _, w := range registered {
+		if selected[w.Name] {
+			s.Workers = append(s.Workers, w)
+		}
+	}
+	return s
+}
+
+// route returns the worker names routing.yaml gives a case type and risk
+// level, and which rule gave them. routed is false when no rule applies.
+func route(cfg strategy.RoutingConfig, caseType, risk string) (names []string, source string, routed bool) {
+	best, bestScore := "", -1
+	for _, name := range sortedKeys(cfg.Overrides) {
+		o := cfg.Overrides[name]
+		if (o.Type != "" && o.Type != caseType) || (o.Risk != "" && o.Risk != risk) {
+			continue
+		}
+		score := 0
+		if o.Type != "" {
+			score++
+		}
+		if o.Risk != "" {
+			score++
+		}
+		if score > bestScore {
+			best, bestScore = name, score
+		}
+	}
+	if best != "" {
+		return cfg.Overrides[best].Workers, "override " + best, true
+	}
+	if workers, ok := cfg.DefaultRoute.ByType[caseType]; ok {
+		return workers, "default route for type " + caseType, true
+	}
+	if cfg.DefaultRoute.Workers != nil {
+		return cfg.DefaultRoute.Workers, "default route", true
+	}
+	return nil, "", false
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
@@ -0,0 +1,138 @@
+package routing
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	workerresource "github.com/gh-xj/agentops/resource/worker"
+	"github.com/gh-xj/agentops/strategy"
+)
+
+func testConfig() strategy.RoutingConfig {
+	return strategy.RoutingConfig{
+		DefaultRoute: strategy.DefaultRoute{
+			Workers: []string{"triage"},
+			ByType:  map[string][]string{"pr": {"review"}},
+		},
+		Overrides: map[string]strategy.RouteOverride{
+			"any-high": {Risk: "high", Workers: []string{"review", "challenge"}},
+			"bug-high": {Type: "bug", Risk: "high", Workers: []string{"verify"}},
+			"incident": {Type: "incident", Workers: []string{"triage", "ghost"}},
+		},
+		Cues: map[string]strategy.RouteCue{
+			"crash":    {Type: "bug", Title: `(?i)panic|crash`},
+			"incident": {Type: "incident", Body: `(?m)^Severity: (1|2)$`, Fields: map[string]string{"labels": "prod"}},
+			"pull":     {Type: "pr", Fields: map[string]string{"pr": "~github.com"}},
+		},
+	}
+}
+
+func TestClassify(t *testing.T) {
+	cases := []struct {
+		name string
+		subj Subject
+		typ  string
+		cue  string
+	}{
+		{"title regexp", Subject{Type: "intake", Title: "Crash on save"}, "bug", "crash"},
+		{"body and fields", Subject{Body: "Severity: 1\n", Fields: map[string]any{"labels": []any{"prod", "db"}}}, "incident", "incident"},
+		{"fields must hold too", Subject{Type: "intake", Body: "Severity: 1\n", Fields: map[string]any{"labels": []any{"staging"}}}, "intake", ""},
+		{"field operator", Subject{Fields: map[string]any{"pr": "https://github.com/o/r/pull/1"}}, "pr", "pull"},
+		{"no cue keeps type", Subject{Type: "quality", Title: "Tidy docs"}, "quality", ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := Classify(testConfig(), tc.subj)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.Type != tc.typ || c.Cue != tc.cue {
+				t.Errorf("got type %q cue %q, want %q %q; trace %v", c.Type, c.Cue, tc.typ, tc.cue, c.Trace)
+			}
+		})
+	}
+}
+
+func TestClassifyTrace(t *testing.T) {
+	cfg := testConfig()
+	cfg.DefaultRoute.Type = "intake"
+	c, err := Classify(cfg, Subject{Type: "bug", Title: "Slow page"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		`cue crash: no match (title does not match "(?i)panic|crash")`,
+		`cue incident: no match (body does not match "(?m)^Severity: (1|2)$")`,
+		`cue pull: no match (pr is not ~github.com)`,
+		"no cue matched -> default type intake",
+	}
+	if c.Type != "intake" || !reflect.DeepEqual(c.Trace, want) {
+		t.Errorf("type %q, trace:\n%s", c.Type, strings.Join(c.Trace, "\n"))
+	}
+}
+
+func TestClassifyBadPattern(t *testing.T) {
+	cfg := strategy.RoutingConfig{Cues: map[string]strategy.RouteCue{"bad": {Type: "bug", Title: "("}}}
+	if _, err := Classify(cfg, Subject{Title: "x"}); err == nil {
+		t.Error("expected an error for a pattern that does not compile")
+	}
+}
+
+func TestSelectWorkers(t *testing.T) {
+	registered := []workerresource.Worker{
+		{Name: "triage"},
+		{Name: "challenge"},
+		{Name: "review", Requires: []string{"triage"}},
+		{Name: "verify"},
+	}
+	cases := []struct {
+		name, typ, risk string
+		override        string
+		workers         []string
+	}{
+		{"most specific override", "bug", "high", "bug-high", []string{"verify"}},
+		{"risk-only override", "pr", "high", "any-high", []string{"triage", "challenge", "review"}},
+		{"type-only override skips unknown workers", "incident", "low", "incident", []string{"triage"}},
+		{"default by type", "pr", "low", "", []string{"triage", "review"}},
+		{"default workers", "quality", "", "", []string{"triage"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := SelectWorkers(testConfig(), tc.typ, tc.risk, registered)
+			var names []string
+			for _, w := range s.Workers {
+				names = append(names, w.Name)
+			}
+			if s.Override != tc.override || !reflect.DeepEqual(names, tc.workers) {
+				t.Errorf("got override %q workers %v, want %q %v; trace %v", s.Override, names, tc.override, tc.workers, s.Trace)
+			}
+		})
+	}
+
+	s := SelectWorkers(testConfig(), "incident", "low", registered)
+	if !contains(s.Trace, "worker ghost is not registered; skipped") {
+		t.Errorf("trace should mention the unknown worker: %v", s.Trace)
+	}
+	s = SelectWorkers(testConfig(), "pr", "low", registered)
+	if !contains(s.Trace, "worker triage added: required by review") {
+		t.Errorf("trace should mention the required worker: %v", s.Trace)
+	}
+}
+
+func TestSelectWorkersWithoutRoute(t *testing.T) {
+	registered := []workerresource.Worker{{Name: "a"}, {Name: "b"}}
+	s := SelectWorkers(strategy.RoutingConfig{}, "bug", "high", registered)
+	if len(s.Workers) != 2 {
+		t.Errorf("workers = %v, want every registered worker", s.Workers)
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
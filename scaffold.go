@@ -7,6 +7,7 @@ type DoctorFinding struct {
 	Code    string `json:"code"`
 	Path    string `json:"path"`
 	Message string `json:"message"`
+	Fix     string `json:"fix,omitempty"` // command that resolves the finding
 }
 
 // DoctorReport summarizes scaffold compliance checks.
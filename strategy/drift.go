@@ -0,0 +1,160 @@
+package strategy
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Kinds of drift between a strategy file and its default.
+const (
+	DriftMissing     = "missing"      // the file is absent from .agentops/
+	DriftDefaultOnly = "default_only" // the default has a key the file lacks
+	DriftLocalOnly   = "local_only"   // the file has a key the default lacks
+	DriftChanged     = "changed"      // the values differ
+)
+
+// Drift is one difference between a file of .agentops/ and its default: the
+// file of the preset the strategy was initialized from.
+type Drift struct {
+	File    string
+	Key     string // dotted path; empty when the difference is the whole file
+	Change  string
+	Local   any
+	Default any
+}
+
+// Diff compares each file of the .agentops/ found from startDir with the
+// default of the same name, from the embedded defaults or the preset its
+// strategy.yaml records. YAML files are compared structurally,
+// key by key, so formatting and comments do not count; other files are
+// compared whole. A file the project leaves to a strategy it extends is not
+// reported missing.
+func Diff(startDir string) ([]Drift, error) {
+	root, err := findRoot(startDir)
+	if err != nil {
+		return nil, err
+	}
+	layers, err := Layers(root)
+	if err != nil {
+		return nil, err
+	}
+	local := layers[len(layers)-1]
+
+	preset, err := basePreset(root)
+	if err != nil {
+		return nil, err
+	}
+	names, err := preset.Files()
+	if err != nil {
+		return nil, err
+	}
+	var drifts []Drift
+	for _, name := range names {
+		def, err := preset.ReadFile(name)
+		if err != nil {
+			return nil, fmt.Errorf("read default %s: %w", name, err)
+		}
+		data, err := os.ReadFile(filepath.Join(root, ".agentops", name))
+		if os.IsNotExist(err) {
+			if !inherited(layers, name) {
+				drifts = append(drifts, Drift{File: name, Change: DriftMissing})
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !isYAML(name) {
+			if !bytes.Equal(data, def) {
+				drifts = append(drifts, Drift{File: name, Change: DriftChanged})
+			}
+			continue
+		}
+		l, err := parseDoc(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", local.label(name), err)
+		}
+		d, err := parseDoc(def)
+		if err != nil {
+			return nil, fmt.Errorf("default %s: %w", name, err)
+		}
+		diffNodes(name, "", body(l), body(d), &drifts)
+	}
+	return drifts, nil
+}
+
+// diffNodes appends the differences between local and def, found at path of
+// file, to out. Mappings are compared key by key in the default's order,
+// followed by keys only the file has.
+func diffNodes(file, path string, local, def *yaml.Node, out *[]Drift) {
+	if local.Kind == yaml.MappingNode && def.Kind == yaml.MappingNode {
+		eachEntry(def, func(key, val *yaml.Node) {
+			if lv := lookup(local, key.Value); lv != nil {
+				diffNodes(file, join(path, key.Value), lv, val, out)
+				return
+			}
+			*out = append(*out, Drift{File: file, Key: join(path, key.Value), Change: DriftDefaultOnly, Default: value(val)})
+		})
+		eachEntry(local, func(key, val *yaml.Node) {
+			if lookup(def, key.Value) == nil {
+				*out = append(*out, Drift{File: file, Key: join(path, key.Value), Change: DriftLocalOnly, Local: value(val)})
+			}
+		})
+		return
+	}
+	if lv, dv := value(local), value(def); !reflect.DeepEqual(lv, dv) {
+		*out = append(*out, Drift{File: file, Key: path, Change: DriftChanged, Local: lv, Default: dv})
+	}
+}
+
+// inherited reports whether a layer other than the project's own has name.
+func inherited(layers []Layer, name string) bool {
+	for _, l := range layers[:len(layers)-1] {
+		if l.Has(name) {
+			return true
+		}
+	}
+	return false
+}
+
+func isYAML(name string) bool {
+	return strings.HasSuffix(name, ".yaml")
+}
+
+// parseDoc parses data into a document node, or nil when data holds no
+// document.
+func parseDoc(data []byte) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+	return &doc, nil
+}
+
+// body returns the root value of a parsed document; an empty document is an
+// empty mapping.
+func body(doc *yaml.Node) *yaml.Node {
+	if doc == nil {
+		return &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	}
+	return doc.Content[0]
+}
+
+// value decodes n into plain Go values, or nil for a missing node.
+func value(n *yaml.Node) any {
+	if n == nil {
+		return nil
+	}
+	var v any
+	_ = n.Decode(&v) // n was parsed as YAML, so it decodes
+	return v
+}
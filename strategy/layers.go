@@ -0,0 +1,426 @@
+package strategy
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// configFile is the strategy file naming the strategies a strategy extends
+// and the preset it was initialized from.
+const configFile = "strategy.yaml"
+
+// PresetPrefix marks an extends entry naming a strategy embedded in agentops,
+// e.g. "preset:defaults".
+const PresetPrefix = "preset:"
+
+// localLayer is the name of the layer of the project's own .agentops/.
+const localLayer = ".agentops"
+
+// StrategyConfig is strategy.yaml.
+type StrategyConfig struct {
+	Extends Extends `yaml:"extends"`
+	Preset  string  `yaml:"preset"` // preset init wrote the strategy from; empty for the defaults
+}
+
+// Extends lists the parent strategies of a strategy, lowest precedence first.
+// Each entry is "preset:<name>" or the path of a strategy directory: a
+// directory holding .agentops/, or the strategy directory itself. Relative
+// paths are resolved from the directory holding the declaring .agentops/.
+// In YAML it is either a single entry or a list.
+type Extends []string
+
+// UnmarshalYAML accepts both the scalar and the list form of extends.
+func (e *Extends) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*e = Extends{value.Value}
+		return nil
+	}
+	var list []string
+	if err := value.Decode(&list); err != nil {
+		return err
+	}
+	*e = list
+	return nil
+}
+
+// Layer is one strategy of an inheritance chain.
+type Layer struct {
+	Name  string // "preset:<name>", or the strategy directory relative to the project root
+	Dir   string // absolute strategy directory; empty for presets
+	base  string // directory relative extends entries are resolved from
+	files fs.FS
+}
+
+// Has reports whether the layer contains the strategy file name.
+func (l Layer) Has(name string) bool {
+	_, err := fs.Stat(l.files, name)
+	return err == nil
+}
+
+// label names file name of the layer in messages. Files of the project's
+// own .agentops/ keep their bare name.
+func (l Layer) label(name string) string {
+	if l.Name == localLayer {
+		return name
+	}
+	return l.Name + "/" + name
+}
+
+// key identifies the layer for cycle detection.
+func (l Layer) key() string {
+	if l.Dir != "" {
+		return l.Dir
+	}
+	return l.Name
+}
+
+// Layers returns the strategies the project at root is built from, lowest
+// precedence first and ending with root's own .agentops/. A strategy comes
+// after every strategy it extends, and each appears once.
+func Layers(root string) ([]Layer, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Join(root, ".agentops")
+	r := &layerResolver{root: root, seen: map[string]bool{}}
+	if err := r.visit(Layer{Name: localLayer, Dir: dir, base: root, files: os.DirFS(dir)}, nil); err != nil {
+		return nil, err
+	}
+	return r.layers, nil
+}
+
+// layerResolver walks the extends graph depth first.
+type layerResolver struct {
+	root   string
+	seen   map[string]bool
+	layers []Layer
+}
+
+func (r *layerResolver) visit(l Layer, chain []Layer) error {
+	for i, c := range chain {
+		if c.key() == l.key() {
+			var names []string
+			for _, c := range chain[i:] {
+				names = append(names, c.Name)
+			}
+			return fmt.Errorf("extends cycle: %s -> %s", strings.Join(names, " -> "), l.Name)
+		}
+	}
+	if r.seen[l.key()] {
+		return nil
+	}
+	chain = append(chain, l)
+
+	var cfg StrategyConfig
+	data, err := fs.ReadFile(l.files, configFile)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%s: %w", l.label(configFile), err)
+	}
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("%s: %w", l.label(configFile), err)
+	}
+	for _, entry := range cfg.Extends {
+		parent, err := r.resolve(l, entry)
+		if err != nil {
+			return fmt.Errorf("%s: extends %q: %w", l.label(configFile), entry, err)
+		}
+		if err := r.visit(parent, chain); err != nil {
+			return err
+		}
+	}
+
+	r.seen[l.key()] = true
+	r.layers = append(r.layers, l)
+	return nil
+}
+
+// resolve finds the strategy an extends entry of from names.
+func (r *layerResolver) resolve(from Layer, entry string) (Layer, error) {
+	if name, ok := strings.CutPrefix(entry, PresetPrefix); ok {
+		return presetLayer(name)
+	}
+	dir := entry
+	if !filepath.IsAbs(dir) {
+		if from.base == "" {
+			return Layer{}, fmt.Errorf("a preset cannot extend a relative path")
+		}
+		dir = filepath.Join(from.base, dir)
+	}
+	base := dir
+	if info, err := os.Stat(filepath.Join(dir, ".agentops")); err == nil && info.IsDir() {
+		dir = filepath.Join(dir, ".agentops")
+	} else if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return Layer{}, fmt.Errorf("not a strategy directory")
+	}
+	name, err := filepath.Rel(r.root, dir)
+	if err != nil {
+		name = dir
+	}
+	return Layer{Name: filepath.ToSlash(name), Dir: dir, base: base, files: os.DirFS(dir)}, nil
+}
+
+// presetLayer returns the embedded preset called name.
+func presetLayer(name string) (Layer, error) {
+	p, err := embeddedPreset(name)
+	if err != nil {
+		return Layer{}, err
+	}
+	return Layer{Name: PresetPrefix + name, files: p.files}, nil
+}
+
+// readLayerFile parses the strategy file name of l. It returns nil when the
+// layer lacks the file or the file is empty.
+func readLayerFile(l Layer, name string) (*yaml.Node, error) {
+	data, err := fs.ReadFile(l.files, name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+	return doc.Content[0], nil
+}
+
+// merger overlays the strategy files of successive layers, remembering the
+// layer every node of the result came from.
+type merger struct {
+	origin map[*yaml.Node]*Layer
+}
+
+func newMerger() *merger {
+	return &merger{origin: map[*yaml.Node]*Layer{}}
+}
+
+// overlay merges src, parsed from layer l, onto dst. Mappings merge key by
+// key; a null value removes the key from dst; any other value replaces the
+// one in dst whole. Neither dst nor src is modified.
+func (m *merger) overlay(dst, src *yaml.Node, l *Layer) *yaml.Node {
+	m.claim(src, l)
+	return m.merge(dst, src)
+}
+
+// claim records l as the origin of n and everything below it.
+func (m *merger) claim(n *yaml.Node, l *Layer) {
+	m.origin[n] = l
+	for _, c := range n.Content {
+		m.claim(c, l)
+	}
+}
+
+func (m *merger) merge(dst, src *yaml.Node) *yaml.Node {
+	if dst == nil || dst.Kind != yaml.MappingNode || src.Kind != yaml.MappingNode {
+		return src
+	}
+	out := *src
+	m.origin[&out] = m.origin[src]
+	out.Content = append([]*yaml.Node(nil), dst.Content...)
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		key, val := src.Content[i], src.Content[i+1]
+		j := -1
+		for k := 0; k+1 < len(out.Content); k += 2 {
+			if out.Content[k].Value == key.Value {
+				j = k
+				break
+			}
+		}
+		switch {
+		case val.Kind == yaml.ScalarNode && val.Tag == "!!null":
+			if j >= 0 {
+				out.Content = append(out.Content[:j], out.Content[j+2:]...)
+			}
+		case j >= 0:
+			out.Content[j], out.Content[j+1] = key, m.merge(out.Content[j+1], val)
+		default:
+			out.Content = append(out.Content, key, val)
+		}
+	}
+	return &out
+}
+
+// mergeFile merges the strategy file name across layers.
+func (m *merger) mergeFile(layers []Layer, name string) (*yaml.Node, error) {
+	var doc *yaml.Node
+	for i := range layers {
+		n, err := readLayerFile(layers[i], name)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", layers[i].label(name), err)
+		}
+		if n != nil {
+			doc = m.overlay(doc, n, &layers[i])
+		}
+	}
+	return doc, nil
+}
+
+// Resolution is the effective strategy of a project, merged from its layers.
+type Resolution struct {
+	Root   string
+	Layers []Layer // lowest precedence first
+	docs   map[string]*yaml.Node
+	m      *merger
+}
+
+// Resolve merges the strategy found from startDir with the strategies it
+// extends.
+func Resolve(startDir string) (*Resolution, error) {
+	root, err := findRoot(startDir)
+	if err != nil {
+		return nil, err
+	}
+	return resolve(root)
+}
+
+func resolve(root string) (*Resolution, error) {
+	layers, err := Layers(root)
+	if err != nil {
+		return nil, err
+	}
+	r := &Resolution{Root: root, Layers: layers, docs: map[string]*yaml.Node{}, m: newMerger()}
+	for _, f := range strategyFiles {
+		doc, err := r.m.mergeFile(layers, f.name)
+		if err != nil {
+			return nil, err
+		}
+		if doc != nil {
+			r.docs[f.name] = doc
+		}
+	}
+	return r, nil
+}
+
+// decode decodes the merged strategy file name into target. A file no layer
+// has leaves target untouched.
+func (r *Resolution) decode(name string, target any) error {
+	doc := r.docs[name]
+	if doc == nil {
+		return nil
+	}
+	if err := doc.Decode(target); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+// ResolvedValue is one effective setting and the layer that supplied it.
+type ResolvedValue struct {
+	File  string // strategy file, e.g. "routing.yaml"
+	Key   string // dotted path within the file
+	Value any
+	Layer string // name of the supplying layer
+}
+
+// Values lists every effective setting: the scalars, lists and empty
+// mappings of each strategy file, in file and document order.
+func (r *Resolution) Values() []ResolvedValue {
+	var out []ResolvedValue
+	for _, f := range strategyFiles {
+		doc := r.docs[f.name]
+		if doc == nil {
+			continue
+		}
+		eachLeaf(doc, "", func(path string, n *yaml.Node) {
+			out = append(out, ResolvedValue{File: f.name, Key: path, Value: value(n), Layer: r.layerName(n)})
+		})
+	}
+	return out
+}
+
+// Annotated renders every merged strategy file as YAML under a "# <file>"
+// heading, with each setting commented with the layer that supplied it.
+func (r *Resolution) Annotated() (string, error) {
+	var buf bytes.Buffer
+	for _, f := range strategyFiles {
+		doc := r.docs[f.name]
+		if doc == nil {
+			continue
+		}
+		if buf.Len() > 0 {
+			buf.WriteString("\n")
+		}
+		fmt.Fprintf(&buf, "# %s\n", f.name)
+		out := r.annotate(doc)
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(out); err != nil {
+			return "", fmt.Errorf("%s: %w", f.name, err)
+		}
+		if err := enc.Close(); err != nil {
+			return "", err
+		}
+	}
+	return buf.String(), nil
+}
+
+// annotate copies n without its comments, commenting each setting with its
+// layer. Mappings are written in block style and lists in flow style, so
+// every setting and its comment share a line.
+func (r *Resolution) annotate(n *yaml.Node) *yaml.Node {
+	if isLeaf(n) {
+		out := uncommented(n)
+		if out.Kind == yaml.SequenceNode {
+			out.Style = yaml.FlowStyle
+		}
+		out.LineComment = r.layerName(n)
+		return out
+	}
+	out := *n
+	out.HeadComment, out.LineComment, out.FootComment = "", "", ""
+	out.Style = 0 // block style, so every setting gets its own line
+	out.Content = make([]*yaml.Node, len(n.Content))
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		out.Content[i] = uncommented(n.Content[i])
+		out.Content[i+1] = r.annotate(n.Content[i+1])
+	}
+	return &out
+}
+
+// uncommented returns a deep copy of n without comments.
+func uncommented(n *yaml.Node) *yaml.Node {
+	out := *n
+	out.HeadComment, out.LineComment, out.FootComment = "", "", ""
+	out.Content = make([]*yaml.Node, len(n.Content))
+	for i, c := range n.Content {
+		out.Content[i] = uncommented(c)
+	}
+	return &out
+}
+
+func (r *Resolution) layerName(n *yaml.Node) string {
+	if l := r.m.origin[n]; l != nil {
+		return l.Name
+	}
+	return ""
+}
+
+// eachLeaf calls fn for every setting below the mapping n, with its dotted
+// path.
+func eachLeaf(n *yaml.Node, path string, fn func(path string, n *yaml.Node)) {
+	eachEntry(n, func(key, val *yaml.Node) {
+		p := join(path, key.Value)
+		if isLeaf(val) {
+			fn(p, val)
+			return
+		}
+		eachLeaf(val, p, fn)
+	})
+}
+
+// isLeaf reports whether n is a setting rather than a mapping of settings.
+func isLeaf(n *yaml.Node) bool {
+	return n.Kind != yaml.MappingNode || len(n.Content) == 0
+}
@@ -0,0 +1,199 @@
+package strategy_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gh-xj/agentops/strategy"
+)
+
+// writeStrategy writes files, keyed by name, into dir/.agentops/.
+func writeStrategy(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, ".agentops", name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func layerNames(layers []strategy.Layer) []string {
+	names := make([]string, len(layers))
+	for i, l := range layers {
+		names[i] = l.Name
+	}
+	return names
+}
+
+// setupLayers creates an org strategy extending the defaults preset and a
+// repo strategy extending the org one, and returns the repo directory.
+func setupLayers(t *testing.T) string {
+	t.Helper()
+	tmp := t.TempDir()
+	writeStrategy(t, filepath.Join(tmp, "org"), map[string]string{
+		"strategy.yaml":    "extends: preset:defaults\n",
+		"risk.yaml":        "thresholds: {medium: 2, high: 5}\nescalation:\n  high: [require-review]\nrules:\n  auth:\n    score: 4\n    paths: [\"auth/**\"]\n",
+		"routing.yaml":     "default_route:\n  workers: [triage]\n  by_type:\n    bug: [review]\n",
+		"templates/bug.md": "# org bug\n",
+	})
+	repo := filepath.Join(tmp, "repo")
+	writeStrategy(t, repo, map[string]string{
+		"strategy.yaml":    "extends: [../org]\n",
+		"storage.yaml":     "backend: in-repo\n",
+		"risk.yaml":        "thresholds: {high: 6}\nescalation: null\n",
+		"routing.yaml":     "default_route:\n  by_type:\n    pr: [review]\n",
+		"transitions.yaml": "transitions:\n  unblock:\n    guards: null\n  reopen:\n    from: resolved\n    to: open\n",
+	})
+	return repo
+}
+
+func TestLayersOrder(t *testing.T) {
+	repo := setupLayers(t)
+	layers, err := strategy.Layers(repo)
+	if err != nil {
+		t.Fatalf("Layers: %v", err)
+	}
+	want := []string{"preset:defaults", "../org/.agentops", ".agentops"}
+	if got := layerNames(layers); !reflect.DeepEqual(got, want) {
+		t.Errorf("layers = %v, want %v", got, want)
+	}
+	if !layers[0].Has("transitions.yaml") || layers[1].Has("transitions.yaml") {
+		t.Error("Has should report the files of each layer")
+	}
+}
+
+func TestLayersErrors(t *testing.T) {
+	tmp := t.TempDir()
+	writeStrategy(t, filepath.Join(tmp, "a"), map[string]string{"strategy.yaml": "extends: ../b\n"})
+	writeStrategy(t, filepath.Join(tmp, "b"), map[string]string{"strategy.yaml": "extends: [preset:defaults, ../a]\n"})
+	writeStrategy(t, filepath.Join(tmp, "c"), map[string]string{"strategy.yaml": "extends: preset:nope\n"})
+	writeStrategy(t, filepath.Join(tmp, "d"), map[string]string{"strategy.yaml": "extends: ../missing\n"})
+
+	for dir, want := range map[string]string{
+		"a": "extends cycle: .agentops -> ../b/.agentops -> .agentops",
+		"c": `strategy.yaml: extends "preset:nope": unknown preset "nope"`,
+		"d": `strategy.yaml: extends "../missing": not a strategy directory`,
+	} {
+		_, err := strategy.Layers(filepath.Join(tmp, dir))
+		if err == nil || err.Error() != want {
+			t.Errorf("%s: err = %v, want %q", dir, err, want)
+		}
+	}
+}
+
+func TestDiscoverMergesLayers(t *testing.T) {
+	strat, err := strategy.Discover(setupLayers(t))
+	if err != nil {
+		t.Fatalf("Discover: %v", err)
+	}
+
+	if strat.Storage.Backend != "in-repo" {
+		t.Errorf("backend = %q", strat.Storage.Backend)
+	}
+	// Mappings merge key by key, the nearest layer winning.
+	if want := map[string]int{"medium": 2, "high": 6}; !reflect.DeepEqual(strat.Risk.Thresholds, want) {
+		t.Errorf("thresholds = %v, want %v", strat.Risk.Thresholds, want)
+	}
+	if strat.Risk.Rules["auth"].Score != 4 {
+		t.Errorf("rules = %v, want auth from the org layer", strat.Risk.Rules)
+	}
+	// A null value removes an inherited key.
+	if strat.Risk.Escalation != nil {
+		t.Errorf("escalation = %v, want removed", strat.Risk.Escalation)
+	}
+	if unblock := strat.Transitions.Transitions["unblock"]; unblock.Guards.Blockers || unblock.To != "in_progress" {
+		t.Errorf("unblock = %+v, want its guards removed", unblock)
+	}
+	if strat.Transitions.Initial != "open" || strat.Transitions.Transitions["start"].To != "in_progress" || strat.Transitions.Transitions["reopen"].To != "open" {
+		t.Errorf("transitions = %+v", strat.Transitions)
+	}
+	if want := map[string][]string{"bug": {"review"}, "pr": {"review"}}; !reflect.DeepEqual(strat.Routing.DefaultRoute.ByType, want) {
+		t.Errorf("by_type = %v, want %v", strat.Routing.DefaultRoute.ByType, want)
+	}
+	if !reflect.DeepEqual(strat.Routing.DefaultRoute.Workers, []string{"triage"}) {
+		t.Errorf("workers = %v", strat.Routing.DefaultRoute.Workers)
+	}
+	if strat.Templates["bug"] != "# org bug\n" || !strings.Contains(strat.SchemaTemplate, "---") {
+		t.Errorf("templates = %v, schema = %q; want both inherited", strat.Templates, strat.SchemaTemplate)
+	}
+}
+
+func TestResolveReportsLayers(t *testing.T) {
+	res, err := strategy.Resolve(setupLayers(t))
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	got := map[string]string{}
+	for _, v := range res.Values() {
+		got[v.File+":"+v.Key] = v.Layer
+	}
+	for key, want := range map[string]string{
+		"storage.yaml:backend":                    ".agentops",
+		"risk.yaml:thresholds.medium":             "../org/.agentops",
+		"risk.yaml:thresholds.high":               ".agentops",
+		"routing.yaml:default_route.workers":      "../org/.agentops",
+		"transitions.yaml:initial":                "preset:defaults",
+		"transitions.yaml:transitions.reopen.to":  ".agentops",
+		"transitions.yaml:transitions.unblock.to": "preset:defaults",
+	} {
+		if got[key] != want {
+			t.Errorf("%s from %q, want %q", key, got[key], want)
+		}
+	}
+	if _, ok := got["risk.yaml:escalation"]; ok {
+		t.Error("removed escalation should not be reported")
+	}
+
+	out, err := res.Annotated()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"# risk.yaml\nthresholds:\n  medium: 2 # ../org/.agentops\n  high: 6 # .agentops\n",
+		`    paths: ["auth/**"] # ../org/.agentops`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("annotated output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestValidateLocatesInheritedFindings(t *testing.T) {
+	repo := setupLayers(t)
+	writeStrategy(t, filepath.Join(repo, "..", "org"), map[string]string{
+		"routing.yaml": "cues:\n  crash:\n    type: bug\n    title: \"(\"\n",
+	})
+	writeStrategy(t, repo, map[string]string{"strategy.yaml": "extends: [../org]\nparent: x\n"})
+
+	report, err := strategy.Validate(repo)
+	if err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	got := map[string]string{}
+	for _, f := range report.Findings {
+		got[f.Path] = f.Code
+	}
+	want := map[string]string{
+		"strategy.yaml:2:1":                  "unknown_key",
+		"../org/.agentops/routing.yaml:4:12": "invalid_value",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("findings = %v, want %v", report.Findings, want)
+	}
+
+	writeStrategy(t, repo, map[string]string{"strategy.yaml": "extends: ../nowhere\n"})
+	report, err = strategy.Validate(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.OK || report.Findings[0].Code != "invalid_extends" {
+		t.Errorf("findings = %v, want invalid_extends", report.Findings)
+	}
+}
@@ -2,11 +2,13 @@ package strategy
 
 import (
 	"embed"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
-
-	"gopkg.in/yaml.v3"
+	"strings"
 )
 
 //go:embed defaults/*
@@ -22,31 +24,124 @@ func Discover(startDir string) (*Strategy, error) {
 }
 
 // Bootstrap creates .agentops/ with default files. Idempotent: does not overwrite existing files.
+// The files it writes are recorded as the baseline for Upgrade.
 func Bootstrap(projectDir string) error {
+	p, err := embeddedPreset(DefaultPreset)
+	if err != nil {
+		return err
+	}
+	return BootstrapPreset(projectDir, p)
+}
+
+// BootstrapPreset is Bootstrap with the files of preset p. Other presets than
+// the defaults are recorded in strategy.yaml, so that Diff and Upgrade
+// compare against them; a strategy initialized from one preset cannot be
+// bootstrapped from another.
+func BootstrapPreset(projectDir string, p Preset) error {
 	agentopsDir := filepath.Join(projectDir, ".agentops")
 	if err := os.MkdirAll(agentopsDir, 0o755); err != nil {
 		return fmt.Errorf("create .agentops/: %w", err)
 	}
 
-	entries, err := defaultsFS.ReadDir("defaults")
+	ref, err := presetRef(projectDir, p)
+	if err != nil {
+		return err
+	}
+	cfg, err := readConfig(agentopsDir)
 	if err != nil {
-		return fmt.Errorf("read embedded defaults: %w", err)
+		return err
+	}
+	names, err := p.Files()
+	if err != nil {
+		return err
+	}
+	if cfg.Preset != ref && initialized(agentopsDir, names) {
+		was := cfg.Preset
+		if was == "" {
+			was = DefaultPreset
+		}
+		return fmt.Errorf(".agentops/ was initialized from preset %s, not %s", was, p.Name)
+	}
+	sums, err := readSums(agentopsDir)
+	if err != nil {
+		return fmt.Errorf("read baseline: %w", err)
 	}
 
-	for _, entry := range entries {
-		target := filepath.Join(agentopsDir, entry.Name())
+	for _, name := range names {
+		target := filepath.Join(agentopsDir, name)
 		if _, err := os.Stat(target); err == nil {
 			continue // don't overwrite existing
 		}
-		data, err := defaultsFS.ReadFile("defaults/" + entry.Name())
+		data, err := p.ReadFile(name)
 		if err != nil {
-			return fmt.Errorf("read default %s: %w", entry.Name(), err)
+			return fmt.Errorf("read preset %s: %s: %w", p.Name, name, err)
 		}
 		if err := os.WriteFile(target, data, 0o644); err != nil {
-			return fmt.Errorf("write %s: %w", entry.Name(), err)
+			return fmt.Errorf("write %s: %w", name, err)
+		}
+		if err := recordBaseline(agentopsDir, name, data, sums); err != nil {
+			return err
 		}
 	}
-	return nil
+	if ref != "" && cfg.Preset == "" {
+		if err := recordPreset(agentopsDir, ref); err != nil {
+			return fmt.Errorf("write %s: %w", configFile, err)
+		}
+	}
+	return writeSums(agentopsDir, sums)
+}
+
+// recordPreset adds the preset ref to the strategy.yaml of dir, keeping any
+// settings it already has.
+func recordPreset(dir, ref string) error {
+	path := filepath.Join(dir, configFile)
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if len(data) > 0 && !strings.HasSuffix(string(data), "\n") {
+		data = append(data, '\n')
+	}
+	return os.WriteFile(path, append(data, "preset: "+ref+"\n"...), 0o644)
+}
+
+// presetRef returns how strategy.yaml of projectDir refers to p: empty for
+// the defaults, a name for an embedded preset, and a path relative to
+// projectDir for a preset directory.
+func presetRef(projectDir string, p Preset) (string, error) {
+	if !isPresetPath(p.Name) {
+		if p.Name == DefaultPreset {
+			return "", nil
+		}
+		return p.Name, nil
+	}
+	dir, err := filepath.Abs(p.Name)
+	if err != nil {
+		return "", err
+	}
+	root, err := filepath.Abs(projectDir)
+	if err != nil {
+		return "", err
+	}
+	if rel, err := filepath.Rel(root, dir); err == nil {
+		dir = rel
+	}
+	dir = filepath.ToSlash(dir)
+	if !isPresetPath(dir) {
+		dir = "./" + dir
+	}
+	return dir, nil
+}
+
+// initialized reports whether dir already holds any of the strategy files
+// names.
+func initialized(dir string, names []string) bool {
+	for _, name := range names {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return true
+		}
+	}
+	return false
 }
 
 func findRoot(startDir string) (string, error) {
@@ -67,51 +162,93 @@ func findRoot(startDir string) (string, error) {
 }
 
 func load(root string) (*Strategy, error) {
-	agentopsDir := filepath.Join(root, ".agentops")
+	r, err := resolve(root)
+	if err != nil {
+		return nil, err
+	}
 	s := &Strategy{Root: root}
 
 	// Load storage.yaml
-	if err := loadYAML(filepath.Join(agentopsDir, "storage.yaml"), &s.Storage); err != nil && !os.IsNotExist(err) {
-		return nil, fmt.Errorf("storage.yaml: %w", err)
+	if err := r.decode("storage.yaml", &s.Storage); err != nil {
+		return nil, err
 	}
-
-	// Load transitions.yaml
-	if err := loadYAML(filepath.Join(agentopsDir, "transitions.yaml"), &s.Transitions); err != nil && !os.IsNotExist(err) {
-		return nil, fmt.Errorf("transitions.yaml: %w", err)
+	switch s.Storage.Layout {
+	case "", LayoutFlat, LayoutGrouped:
+	default:
+		return nil, fmt.Errorf("storage.yaml: unknown layout %q (want %s or %s)", s.Storage.Layout, LayoutFlat, LayoutGrouped)
 	}
-
-	// Load risk.yaml (unstructured)
-	if err := loadYAML(filepath.Join(agentopsDir, "risk.yaml"), &s.Risk); err != nil && !os.IsNotExist(err) {
-		return nil, fmt.Errorf("risk.yaml: %w", err)
+	if s.Storage.Index != "" && s.Storage.Index != IndexSQLite {
+		return nil, fmt.Errorf("storage.yaml: unknown index %q (want %s)", s.Storage.Index, IndexSQLite)
+	}
+	if _, err := s.Storage.Retention.Age(); err != nil {
+		return nil, fmt.Errorf("storage.yaml: retention: %w", err)
+	}
+	switch s.Storage.Retention.Format {
+	case "", ArchiveFormatDir, ArchiveFormatTarGz:
+	default:
+		return nil, fmt.Errorf("storage.yaml: retention: unknown format %q (want %s or %s)", s.Storage.Retention.Format, ArchiveFormatDir, ArchiveFormatTarGz)
 	}
 
-	// Load routing.yaml (unstructured)
-	if err := loadYAML(filepath.Join(agentopsDir, "routing.yaml"), &s.Routing); err != nil && !os.IsNotExist(err) {
-		return nil, fmt.Errorf("routing.yaml: %w", err)
+	// Load transitions.yaml, risk.yaml and routing.yaml
+	if err := r.decode("transitions.yaml", &s.Transitions); err != nil {
+		return nil, err
+	}
+	if err := r.decode("risk.yaml", &s.Risk); err != nil {
+		return nil, err
+	}
+	if err := r.decode("routing.yaml", &s.Routing); err != nil {
+		return nil, err
 	}
 
-	// Load budget.yaml (unstructured)
-	if err := loadYAML(filepath.Join(agentopsDir, "budget.yaml"), &s.Budget); err != nil && !os.IsNotExist(err) {
+	// Load budget.yaml
+	if err := r.decode("budget.yaml", &s.Budget); err != nil {
+		return nil, err
+	}
+	if _, err := s.StaleAfter(); err != nil {
 		return nil, fmt.Errorf("budget.yaml: %w", err)
 	}
 
 	// Load hooks.yaml
-	if err := loadYAML(filepath.Join(agentopsDir, "hooks.yaml"), &s.Hooks); err != nil && !os.IsNotExist(err) {
-		return nil, fmt.Errorf("hooks.yaml: %w", err)
+	if err := r.decode("hooks.yaml", &s.Hooks); err != nil {
+		return nil, err
 	}
 
-	// Load schema.md (raw)
-	if data, err := os.ReadFile(filepath.Join(agentopsDir, "schema.md")); err == nil {
-		s.SchemaTemplate = string(data)
+	// Load schema.md and templates/<type>.md (raw); the nearest layer wins.
+	s.Templates = map[string]string{}
+	for _, l := range r.Layers {
+		if data, err := fs.ReadFile(l.files, "schema.md"); err == nil {
+			s.SchemaTemplate = string(data)
+		}
+		if err := loadTemplates(l.files, s.Templates); err != nil {
+			return nil, fmt.Errorf("%s: %w", l.label("templates"), err)
+		}
+	}
+	if len(s.Templates) == 0 {
+		s.Templates = nil
 	}
 
 	return s, nil
 }
 
-func loadYAML(path string, target any) error {
-	data, err := os.ReadFile(path)
+// loadTemplates adds every templates/<type>.md of files to templates, keyed
+// by type. A missing directory adds none.
+func loadTemplates(files fs.FS, templates map[string]string) error {
+	entries, err := fs.ReadDir(files, "templates")
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
 	if err != nil {
 		return err
 	}
-	return yaml.Unmarshal(data, target)
+	for _, e := range entries {
+		if e.IsDir() || path.Ext(e.Name()) != ".md" {
+			continue
+		}
+		data, err := fs.ReadFile(files, "templates/"+e.Name())
+		if err != nil {
+			return err
+		}
+		templates[strings.TrimSuffix(e.Name(), ".md")] = string(data)
+	}
+	return nil
 }
@@ -3,7 +3,9 @@ package strategy_test
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gh-xj/agentops/strategy"
 )
@@ -93,3 +95,182 @@ func TestBootstrapIdempotent(t *testing.T) {
 		t.Errorf("Bootstrap overwrote existing file: got %q, want %q", string(data), string(custom))
 	}
 }
+
+func TestLoadHooksScalarAndMapping(t *testing.T) {
+	tmp := t.TempDir()
+	if err := strategy.Bootstrap(tmp); err != nil {
+		t.Fatalf("Bootstrap failed: %v", err)
+	}
+	hooksYAML := "pre_dispatch: [\"echo pre\"]\non_case_transition:\n  - echo plain\n  - run: ./gate.sh\n    blocking: true\n"
+	if err := os.WriteFile(filepath.Join(tmp, ".agentops", "hooks.yaml"), []byte(hooksYAML), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	strat, err := strategy.Discover(tmp)
+	if err != nil {
+		t.Fatalf("Discover failed: %v", err)
+	}
+	if len(strat.Hooks.PreDispatch) != 1 || strat.Hooks.PreDispatch[0].Run != "echo pre" {
+		t.Errorf("PreDispatch = %+v, want [echo pre]", strat.Hooks.PreDispatch)
+	}
+	got := strat.Hooks.OnCaseTransition
+	if len(got) != 2 {
+		t.Fatalf("OnCaseTransition has %d hooks, want 2", len(got))
+	}
+	if got[0].Run != "echo plain" || got[0].Blocking {
+		t.Errorf("hook[0] = %+v, want non-blocking 'echo plain'", got[0])
+	}
+	if got[1].Run != "./gate.sh" || !got[1].Blocking {
+		t.Errorf("hook[1] = %+v, want blocking './gate.sh'", got[1])
+	}
+}
+
+func TestLoadRejectsUnknownLayout(t *testing.T) {
+	tmp := t.TempDir()
+	if err := strategy.Bootstrap(tmp); err != nil {
+		t.Fatalf("Bootstrap failed: %v", err)
+	}
+	storage := filepath.Join(tmp, ".agentops", "storage.yaml")
+	if err := os.WriteFile(storage, []byte("backend: in-repo\nlayout: nested\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := strategy.Discover(tmp); err == nil || !strings.Contains(err.Error(), "unknown layout") {
+		t.Errorf("Discover err = %v, want unknown layout", err)
+	}
+}
+
+func TestLoadIndex(t *testing.T) {
+	tmp := t.TempDir()
+	if err := strategy.Bootstrap(tmp); err != nil {
+		t.Fatalf("Bootstrap failed: %v", err)
+	}
+	storage := filepath.Join(tmp, ".agentops", "storage.yaml")
+	if err := os.WriteFile(storage, []byte("backend: in-repo\nindex: sqlite\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := strategy.Discover(tmp)
+	if err != nil {
+		t.Fatalf("Discover: %v", err)
+	}
+	if s.Storage.Index != strategy.IndexSQLite {
+		t.Errorf("Index = %q, want %q", s.Storage.Index, strategy.IndexSQLite)
+	}
+
+	if err := os.WriteFile(storage, []byte("backend: in-repo\nindex: bolt\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := strategy.Discover(tmp); err == nil || !strings.Contains(err.Error(), "unknown index") {
+		t.Errorf("Discover err = %v, want unknown index", err)
+	}
+}
+
+func TestLoadStaleAfter(t *testing.T) {
+	tmp := t.TempDir()
+	if err := strategy.Bootstrap(tmp); err != nil {
+		t.Fatalf("Bootstrap failed: %v", err)
+	}
+	s, err := strategy.Discover(tmp)
+	if err != nil {
+		t.Fatalf("Discover: %v", err)
+	}
+	stale, err := s.StaleAfter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stale["in_progress"] != 7*24*time.Hour || stale["blocked"] != 14*24*time.Hour {
+		t.Errorf("default stale_after = %v", stale)
+	}
+
+	budget := filepath.Join(tmp, ".agentops", "budget.yaml")
+	if err := os.WriteFile(budget, []byte("stale_after:\n  in_progress: soon\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := strategy.Discover(tmp); err == nil || !strings.Contains(err.Error(), "stale_after: in_progress") {
+		t.Errorf("Discover err = %v, want invalid stale_after", err)
+	}
+}
+
+func TestLoadTransitionGuards(t *testing.T) {
+	tmp := t.TempDir()
+	if err := strategy.Bootstrap(tmp); err != nil {
+		t.Fatalf("Bootstrap failed: %v", err)
+	}
+	transitions := `initial: open
+transitions:
+  resolve:
+    from: in_progress
+    to: resolved
+    guards:
+      fields: [claimed_by]
+      sections: ["## Close Criteria"]
+      blocking_workers: true
+`
+	if err := os.WriteFile(filepath.Join(tmp, ".agentops", "transitions.yaml"), []byte(transitions), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := strategy.Discover(tmp)
+	if err != nil {
+		t.Fatalf("Discover: %v", err)
+	}
+	g := s.Transitions.Transitions["resolve"].Guards
+	if len(g.Fields) != 1 || g.Fields[0] != "claimed_by" || len(g.Sections) != 1 || !g.BlockingWorkers {
+		t.Errorf("unexpected guards: %+v", g)
+	}
+}
+
+func TestLoadRetention(t *testing.T) {
+	tmp := t.TempDir()
+	if err := strategy.Bootstrap(tmp); err != nil {
+		t.Fatalf("Bootstrap failed: %v", err)
+	}
+	storage := filepath.Join(tmp, ".agentops", "storage.yaml")
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(storage, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("backend: in-repo\nretention:\n  completed_after: 14d\n  format: tar.gz\n")
+	s, err := strategy.Discover(tmp)
+	if err != nil {
+		t.Fatalf("Discover: %v", err)
+	}
+	if age, _ := s.Storage.Retention.Age(); age != 14*24*time.Hour || s.Storage.Retention.Format != strategy.ArchiveFormatTarGz {
+		t.Errorf("retention = %+v (age %s)", s.Storage.Retention, age)
+	}
+
+	for content, want := range map[string]string{
+		"retention:\n  completed_after: soon\n": "invalid completed_after",
+		"retention:\n  format: zip\n":           "unknown format",
+	} {
+		write(content)
+		if _, err := strategy.Discover(tmp); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Discover(%q) err = %v, want %s", content, err, want)
+		}
+	}
+}
+
+func TestLoadTemplates(t *testing.T) {
+	tmp := t.TempDir()
+	if err := strategy.Bootstrap(tmp); err != nil {
+		t.Fatalf("Bootstrap failed: %v", err)
+	}
+	dir := filepath.Join(tmp, ".agentops", "templates")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{"pr.md": "---\ntype: pr\n---\n", "notes.txt": "ignored"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s, err := strategy.Discover(tmp)
+	if err != nil {
+		t.Fatalf("Discover: %v", err)
+	}
+	if len(s.Templates) != 1 || s.Templates["pr"] != "---\ntype: pr\n---\n" {
+		t.Errorf("Templates = %v", s.Templates)
+	}
+}
@@ -0,0 +1,93 @@
+package strategy
+
+// Risk levels a case can be assessed at, lowest first.
+const (
+	RiskLow    = "low"
+	RiskMedium = "medium"
+	RiskHigh   = "high"
+)
+
+// RiskLevels lists the risk levels, lowest first.
+var RiskLevels = []string{RiskLow, RiskMedium, RiskHigh}
+
+// RiskConfig is risk.yaml.
+type RiskConfig struct {
+	// Thresholds maps a risk level to the minimum score that reaches it.
+	Thresholds map[string]int `yaml:"thresholds"`
+	// Escalation maps a risk level to the actions taken at that level.
+	Escalation map[string][]string `yaml:"escalation"`
+	// Rules add to a case's risk score when they match it.
+	Rules map[string]RiskRule `yaml:"rules"`
+}
+
+// RiskRule adds Score to the risk score of cases it matches. A list matches
+// when any of its entries does; every list given must match.
+type RiskRule struct {
+	Score    int      `yaml:"score"`
+	Types    []string `yaml:"types"`    // case types
+	Paths    []string `yaml:"paths"`    // globs over the case's touched paths
+	Labels   []string `yaml:"labels"`   // frontmatter labels
+	Keywords []string `yaml:"keywords"` // words in the body, ignoring case
+}
+
+// RoutingConfig is routing.yaml.
+type RoutingConfig struct {
+	DefaultRoute DefaultRoute `yaml:"default_route"`
+	// Overrides replace the workers of cases matching their type and risk.
+	Overrides map[string]RouteOverride `yaml:"overrides"`
+	// Cues assign a case type from its title, body or frontmatter.
+	Cues map[string]RouteCue `yaml:"cues"`
+}
+
+// DefaultRoute applies when no cue or override matches.
+type DefaultRoute struct {
+	Type    string              `yaml:"type"`    // case type when no cue matches
+	Workers []string            `yaml:"workers"` // workers for types not in ByType
+	ByType  map[string][]string `yaml:"by_type"` // workers by case type
+}
+
+// RouteOverride selects workers for cases of a type and risk level. Empty
+// Type or Risk matches any.
+type RouteOverride struct {
+	Type    string   `yaml:"type"`
+	Risk    string   `yaml:"risk"`
+	Workers []string `yaml:"workers"`
+}
+
+// RouteCue assigns Type to cases it matches. Title and Body are regular
+// expressions; Fields are frontmatter values that must be equal. Every
+// condition given must hold.
+type RouteCue struct {
+	Type   string            `yaml:"type"`
+	Title  string            `yaml:"title"`
+	Body   string            `yaml:"body"`
+	Fields map[string]string `yaml:"fields"`
+}
+
+// BudgetConfig is budget.yaml.
+type BudgetConfig struct {
+	Limits   BudgetLimits   `yaml:"limits"`
+	Tracking BudgetTracking `yaml:"tracking"`
+	// StaleAfter maps a status to how long a case may stay in it before
+	// doctor reports it stale, e.g. "7d".
+	StaleAfter map[string]string `yaml:"stale_after"`
+}
+
+// BudgetLimits caps spend per case and per slot. Zero values are unlimited.
+type BudgetLimits struct {
+	PerCase SpendLimit `yaml:"per_case"`
+	PerSlot SpendLimit `yaml:"per_slot"`
+}
+
+// SpendLimit caps each kind of spend.
+type SpendLimit struct {
+	WorkerInvocations int    `yaml:"worker_invocations"`
+	WallClock         string `yaml:"wall_clock"` // age such as "2h" or "1d"
+	LoopIterations    int    `yaml:"loop_iterations"`
+	Tokens            int    `yaml:"tokens"`
+}
+
+// BudgetTracking controls the recording of spend.
+type BudgetTracking struct {
+	Disabled bool `yaml:"disabled"` // record nothing
+}
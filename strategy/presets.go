@@ -0,0 +1,214 @@
+package strategy
+
+import (
+	"bufio"
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed presets
+var presetsFS embed.FS
+
+// DefaultPreset is the preset made of the embedded defaults alone.
+const DefaultPreset = "defaults"
+
+// Preset is a starting strategy for init. Embedded presets, and presets
+// loaded from a directory, hold only the files that differ from the
+// defaults; the others fall back to the defaults.
+type Preset struct {
+	Name        string // embedded preset name, or the directory it was loaded from
+	Description string // first paragraph of its strategy.md
+	files       fs.FS
+}
+
+// Presets lists the embedded presets, the defaults first.
+func Presets() ([]Preset, error) {
+	entries, err := presetsFS.ReadDir("presets")
+	if err != nil {
+		return nil, fmt.Errorf("read embedded presets: %w", err)
+	}
+	names := []string{DefaultPreset}
+	for _, e := range entries {
+		if e.IsDir() {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names[1:])
+
+	presets := make([]Preset, 0, len(names))
+	for _, name := range names {
+		p, err := embeddedPreset(name)
+		if err != nil {
+			return nil, err
+		}
+		presets = append(presets, p)
+	}
+	return presets, nil
+}
+
+// LoadPreset returns the preset ref names: an embedded preset, or, when ref
+// is a path, the directory holding .agentops/ or the strategy directory it
+// names.
+func LoadPreset(ref string) (Preset, error) {
+	if !isPresetPath(ref) {
+		return embeddedPreset(ref)
+	}
+	dir := ref
+	if info, err := os.Stat(filepath.Join(dir, ".agentops")); err == nil && info.IsDir() {
+		dir = filepath.Join(dir, ".agentops")
+	} else if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return Preset{}, fmt.Errorf("preset %s: not a strategy directory", ref)
+	}
+	return newPreset(ref, overlayFS{os.DirFS(dir), defaultFiles()})
+}
+
+// isPresetPath reports whether a preset reference is a directory rather than
+// the name of an embedded preset.
+func isPresetPath(ref string) bool {
+	return filepath.IsAbs(ref) || strings.HasPrefix(ref, ".") || strings.ContainsRune(ref, '/') || strings.ContainsRune(ref, filepath.Separator)
+}
+
+func embeddedPreset(name string) (Preset, error) {
+	if name == DefaultPreset {
+		p, err := newPreset(name, defaultFiles())
+		p.Description = "General case lifecycle: open, in progress, blocked, resolved."
+		return p, err
+	}
+	files, err := fs.Sub(presetsFS, "presets/"+name)
+	if err == nil {
+		_, err = fs.Stat(files, ".")
+	}
+	if err != nil {
+		return Preset{}, fmt.Errorf("unknown preset %q", name)
+	}
+	return newPreset(name, overlayFS{files, defaultFiles()})
+}
+
+func newPreset(name string, files fs.FS) (Preset, error) {
+	p := Preset{Name: name, files: files}
+	data, err := fs.ReadFile(files, "strategy.md")
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return Preset{}, fmt.Errorf("preset %s: %w", name, err)
+	}
+	p.Description = firstParagraph(data)
+	return p, nil
+}
+
+// Files lists the top-level strategy files of the preset, in name order.
+// strategy.yaml is left out: init writes its own.
+func (p Preset) Files() ([]string, error) {
+	seen := map[string]bool{}
+	for _, files := range p.layers() {
+		entries, err := fs.ReadDir(files, ".")
+		if err != nil {
+			return nil, fmt.Errorf("preset %s: %w", p.Name, err)
+		}
+		for _, e := range entries {
+			if !e.IsDir() && e.Name() != configFile {
+				seen[e.Name()] = true
+			}
+		}
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// ReadFile returns the strategy file name of the preset.
+func (p Preset) ReadFile(name string) ([]byte, error) {
+	return fs.ReadFile(p.files, name)
+}
+
+// layers returns the file systems the preset is made of.
+func (p Preset) layers() []fs.FS {
+	if o, ok := p.files.(overlayFS); ok {
+		return []fs.FS{o.top, o.base}
+	}
+	return []fs.FS{p.files}
+}
+
+// basePreset returns the preset the strategy of the project at root was
+// initialized from, as recorded in its strategy.yaml.
+func basePreset(root string) (Preset, error) {
+	cfg, err := readConfig(filepath.Join(root, ".agentops"))
+	if err != nil {
+		return Preset{}, err
+	}
+	if cfg.Preset == "" {
+		return embeddedPreset(DefaultPreset)
+	}
+	ref := cfg.Preset
+	if isPresetPath(ref) && !filepath.IsAbs(ref) {
+		ref = filepath.Join(root, ref)
+	}
+	p, err := LoadPreset(ref)
+	if err != nil {
+		return Preset{}, fmt.Errorf("%s: %w", configFile, err)
+	}
+	return p, nil
+}
+
+// readConfig reads the strategy.yaml of the strategy directory dir, if any.
+func readConfig(dir string) (StrategyConfig, error) {
+	var cfg StrategyConfig
+	data, err := os.ReadFile(filepath.Join(dir, configFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("%s: %w", configFile, err)
+	}
+	return cfg, nil
+}
+
+func defaultFiles() fs.FS {
+	files, _ := fs.Sub(defaultsFS, "defaults") // "defaults" is a valid embedded directory
+	return files
+}
+
+// firstParagraph joins the lines of the first paragraph of a markdown file
+// that is not a heading.
+func firstParagraph(data []byte) string {
+	var lines []string
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		switch {
+		case strings.HasPrefix(line, "#") && len(lines) == 0:
+		case line == "" && len(lines) > 0:
+			return strings.Join(lines, " ")
+		case line != "":
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, " ")
+}
+
+// overlayFS serves the files of top, falling back to base for those top
+// lacks. Directories are served whole from the first that has them.
+type overlayFS struct {
+	top, base fs.FS
+}
+
+func (o overlayFS) Open(name string) (fs.File, error) {
+	f, err := o.top.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return o.base.Open(name)
+	}
+	return f, err
+}
@@ -0,0 +1,156 @@
+package strategy_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gh-xj/agentops/strategy"
+)
+
+func TestPresetsBootstrapValidStrategies(t *testing.T) {
+	presets, err := strategy.Presets()
+	if err != nil {
+		t.Fatalf("Presets: %v", err)
+	}
+	var names []string
+	for _, p := range presets {
+		names = append(names, p.Name)
+		if p.Description == "" {
+			t.Errorf("preset %s has no description", p.Name)
+		}
+	}
+	if got := strings.Join(names, ","); got != "defaults,incident,pr-review,triage" {
+		t.Fatalf("presets = %s", got)
+	}
+
+	for _, p := range presets {
+		t.Run(p.Name, func(t *testing.T) {
+			tmp := t.TempDir()
+			if err := strategy.BootstrapPreset(tmp, p); err != nil {
+				t.Fatalf("BootstrapPreset: %v", err)
+			}
+			report, err := strategy.Validate(tmp)
+			if err != nil {
+				t.Fatalf("Validate: %v", err)
+			}
+			if !report.OK {
+				t.Errorf("preset should validate, got %+v", report.Findings)
+			}
+			drifts, err := strategy.Diff(tmp)
+			if err != nil || len(drifts) != 0 {
+				t.Errorf("fresh strategy should not drift from its preset: %v %+v", err, drifts)
+			}
+			config, err := os.ReadFile(filepath.Join(tmp, ".agentops", "strategy.yaml"))
+			if p.Name == strategy.DefaultPreset {
+				if !os.IsNotExist(err) {
+					t.Errorf("defaults should not write strategy.yaml: %s", config)
+				}
+				return
+			}
+			if string(config) != "preset: "+p.Name+"\n" {
+				t.Errorf("strategy.yaml = %q", config)
+			}
+		})
+	}
+}
+
+func TestPresetStatuses(t *testing.T) {
+	for name, want := range map[string][]string{
+		"triage":    {"triaged", "wontfix"},
+		"pr-review": {"needs_review", "changes_requested", "merged"},
+		"incident":  {"investigating", "mitigated"},
+	} {
+		p, err := strategy.LoadPreset(name)
+		if err != nil {
+			t.Fatalf("LoadPreset(%s): %v", name, err)
+		}
+		tmp := t.TempDir()
+		if err := strategy.BootstrapPreset(tmp, p); err != nil {
+			t.Fatal(err)
+		}
+		strat, err := strategy.Discover(tmp)
+		if err != nil {
+			t.Fatalf("%s: Discover: %v", name, err)
+		}
+		statuses := map[string]bool{}
+		for _, list := range strat.Transitions.Categories {
+			for _, s := range list {
+				statuses[s] = true
+			}
+		}
+		for _, s := range want {
+			if !statuses[s] {
+				t.Errorf("%s: missing status %s", name, s)
+			}
+		}
+	}
+	if _, err := strategy.LoadPreset("nope"); err == nil {
+		t.Error("unknown preset: want error")
+	}
+}
+
+func TestPresetFromDirectory(t *testing.T) {
+	tmp := t.TempDir()
+	shared := filepath.Join(tmp, "shared")
+	if err := os.MkdirAll(filepath.Join(shared, ".agentops"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	transitions := "categories:\n  active: [open]\n  completed: [done]\ninitial: open\ntransitions:\n  finish: {from: open, to: done}\n"
+	if err := os.WriteFile(filepath.Join(shared, ".agentops", "transitions.yaml"), []byte(transitions), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := strategy.LoadPreset(shared)
+	if err != nil {
+		t.Fatalf("LoadPreset: %v", err)
+	}
+	project := filepath.Join(tmp, "project")
+	if err := strategy.BootstrapPreset(project, p); err != nil {
+		t.Fatalf("BootstrapPreset: %v", err)
+	}
+	agentops := filepath.Join(project, ".agentops")
+	if data, _ := os.ReadFile(filepath.Join(agentops, "transitions.yaml")); string(data) != transitions {
+		t.Errorf("transitions.yaml should come from the preset directory, got:\n%s", data)
+	}
+	if _, err := os.Stat(filepath.Join(agentops, "routing.yaml")); err != nil {
+		t.Errorf("files the directory lacks should fall back to the defaults: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(agentops, "strategy.yaml")); string(data) != "preset: ../shared\n" {
+		t.Errorf("strategy.yaml = %q, want the preset relative to the project", data)
+	}
+	if drifts, err := strategy.Diff(project); err != nil || len(drifts) != 0 {
+		t.Errorf("Diff against the preset directory: %v %+v", err, drifts)
+	}
+
+	// Another preset cannot be bootstrapped over it.
+	triage, err := strategy.LoadPreset("triage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := strategy.BootstrapPreset(project, triage); err == nil {
+		t.Error("bootstrapping a different preset: want error")
+	}
+	if err := strategy.BootstrapPreset(project, p); err != nil {
+		t.Errorf("bootstrapping the same preset again: %v", err)
+	}
+}
+
+func TestExtendsEmbeddedPreset(t *testing.T) {
+	root := t.TempDir()
+	writeStrategy(t, root, map[string]string{
+		"strategy.yaml": "extends: preset:pr-review\n",
+		"storage.yaml":  "backend: in-repo\n",
+	})
+	strat, err := strategy.Discover(root)
+	if err != nil {
+		t.Fatalf("Discover: %v", err)
+	}
+	if strat.Transitions.Initial != "open" || strat.Routing.DefaultRoute.Type != "pr" {
+		t.Errorf("strategy should inherit pr-review, got %+v / %+v", strat.Transitions, strat.Routing.DefaultRoute)
+	}
+	if _, ok := strat.Transitions.Transitions["request_review"]; !ok {
+		t.Error("missing inherited request_review transition")
+	}
+}
@@ -1,23 +1,111 @@
 package strategy
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
 // Strategy holds the fully loaded .agentops/ configuration.
 type Strategy struct {
 	Root           string // absolute path to project root (parent of .agentops/)
 	Storage        StorageConfig
 	Transitions    TransitionsConfig
-	Risk           map[string]any
-	Routing        map[string]any
-	Budget         map[string]any
+	Risk           RiskConfig
+	Routing        RoutingConfig
+	Budget         BudgetConfig
 	Hooks          HooksConfig
-	SchemaTemplate string // raw content of schema.md
+	SchemaTemplate string            // raw content of schema.md
+	Templates      map[string]string // raw content of templates/<type>.md, by type
 }
 
 // StorageConfig controls where case records are stored.
 type StorageConfig struct {
-	Backend      string `yaml:"backend"`        // "separate-repo" or "in-repo"
-	CaseRepoPath string `yaml:"case_repo_path"` // relative path to case repo
+	Backend      string          `yaml:"backend"`        // BackendSeparateRepo (default) or BackendInRepo
+	CaseRepoPath string          `yaml:"case_repo_path"` // relative path to case repo
+	Layout       string          `yaml:"layout"`         // "flat" (default) or "grouped"
+	Retention    RetentionConfig `yaml:"retention"`
+	Index        string          `yaml:"index"` // IndexSQLite, or empty to scan markdown on every query
+}
+
+// RetentionConfig controls when completed cases are archived.
+type RetentionConfig struct {
+	// CompletedAfter is how long a case stays completed before it is
+	// archived, e.g. "30d" or "72h". Empty disables archiving by age.
+	CompletedAfter string `yaml:"completed_after"`
+	Format         string `yaml:"format"` // "dir" (default) or "tar.gz"
+}
+
+// Archive formats for retired cases.
+const (
+	ArchiveFormatDir   = "dir"    // archive/YYYY/MM/CASE-*/
+	ArchiveFormatTarGz = "tar.gz" // archive/YYYY/MM/CASE-*.tar.gz
+)
+
+// Age parses CompletedAfter with ParseAge. A zero duration means retention
+// is disabled.
+func (r RetentionConfig) Age() (time.Duration, error) {
+	d, err := ParseAge(r.CompletedAfter)
+	if err != nil {
+		return 0, fmt.Errorf("invalid completed_after %q", r.CompletedAfter)
+	}
+	return d, nil
+}
+
+// ParseAge parses an age such as "30d" or "72h": a whole number of days, or
+// anything time.ParseDuration accepts. An empty age is zero.
+func ParseAge(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid age %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age %q", s)
+	}
+	return d, nil
 }
 
+// StaleAfter returns how long a case may stay in each status before doctor
+// reports it stale, from the stale_after mapping of budget.yaml.
+func (s *Strategy) StaleAfter() (map[string]time.Duration, error) {
+	out := make(map[string]time.Duration, len(s.Budget.StaleAfter))
+	for status, age := range s.Budget.StaleAfter {
+		d, err := ParseAge(age)
+		if err != nil {
+			return nil, fmt.Errorf("stale_after: %s: %w", status, err)
+		}
+		out[status] = d
+	}
+	return out, nil
+}
+
+// Storage backends for case records.
+const (
+	BackendSeparateRepo = "separate-repo" // cases/ of a sibling git repository (default)
+	BackendInRepo       = "in-repo"       // cases/ of the project repository
+)
+
+// IndexSQLite keeps a SQLite copy of case frontmatter and history in
+// .agentops/cases.db for fast queries. Markdown stays the source of truth.
+const IndexSQLite = "sqlite"
+
+// Storage layouts for case directories.
+const (
+	LayoutFlat    = "flat"    // cases/CASE-*
+	LayoutGrouped = "grouped" // cases/{group}/{slot}/CASE-*
+)
+
 // TransitionsConfig defines the state machine for case lifecycle.
 type TransitionsConfig struct {
 	Categories  map[string][]string      `yaml:"categories"`
@@ -27,8 +115,17 @@ type TransitionsConfig struct {
 
 // TransitionDef describes one allowed state transition.
 type TransitionDef struct {
-	From any    `yaml:"from"` // string or []string
-	To   string `yaml:"to"`
+	From   any              `yaml:"from"` // string or []string
+	To     string           `yaml:"to"`
+	Guards TransitionGuards `yaml:"guards"`
+}
+
+// TransitionGuards are conditions a case must meet before a transition applies.
+type TransitionGuards struct {
+	Fields          []string `yaml:"fields"`           // frontmatter fields that must be non-empty
+	Sections        []string `yaml:"sections"`         // markdown sections that must have content
+	BlockingWorkers bool     `yaml:"blocking_workers"` // every blocking worker's sidecar must exist
+	Blockers        bool     `yaml:"blockers"`         // no blocked_by case may still be active
 }
 
 // FromStates returns the from states as a string slice.
@@ -46,8 +143,31 @@ func (t TransitionDef) FromStates() []string {
 	return nil
 }
 
-// HooksConfig defines pre/post lifecycle hooks.
+// HooksConfig binds lifecycle events to hook actions.
 type HooksConfig struct {
-	PreDispatch []string `yaml:"pre_dispatch"`
-	PostClose   []string `yaml:"post_close"`
+	PreDispatch      []HookDef `yaml:"pre_dispatch"`
+	PostClose        []HookDef `yaml:"post_close"`
+	OnCaseOpen       []HookDef `yaml:"on_case_open"`
+	OnCaseTransition []HookDef `yaml:"on_case_transition"`
+	OnWorkerComplete []HookDef `yaml:"on_worker_complete"`
+	OnReconcileDone  []HookDef `yaml:"on_reconcile_done"`
+	OnCaseClose      []HookDef `yaml:"on_case_close"`
+}
+
+// HookDef describes one hook action. In YAML it is either a plain shell
+// command string or a mapping with run and blocking keys.
+type HookDef struct {
+	Run      string `yaml:"run"`
+	Blocking bool   `yaml:"blocking"`
+}
+
+// UnmarshalYAML accepts both the scalar and the mapping form of a hook.
+func (h *HookDef) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		h.Run = value.Value
+		h.Blocking = false
+		return nil
+	}
+	type plain HookDef
+	return value.Decode((*plain)(h))
 }
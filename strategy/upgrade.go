@@ -0,0 +1,323 @@
+package strategy
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// The baseline of a strategy is the set of defaults its files were last
+// bootstrapped or upgraded from. baselineDir, inside .agentops/, holds a copy
+// of each, with their SHA-256 hashes listed in baselineSums.
+const (
+	baselineDir  = ".baseline"
+	baselineSums = "SHA256SUMS"
+)
+
+// Upgrade outcomes for a strategy file.
+const (
+	UpgradeCurrent   = "current"   // the file matches the defaults
+	UpgradeInherited = "inherited" // the file is left to a strategy it extends
+	UpgradeAdded     = "added"     // the missing file was written from the defaults
+	UpgradeUpdated   = "updated"   // the unedited file was replaced by the defaults
+	UpgradeKept      = "kept"      // the defaults have not changed since the baseline
+	UpgradeMerged    = "merged"    // local edits and changed defaults were merged
+	UpgradeConflict  = "conflict"  // local edits conflict with the defaults; not written
+	UpgradeForced    = "forced"    // conflicts were resolved in favor of the defaults
+)
+
+// UpgradeResult is the outcome of upgrading one strategy file.
+type UpgradeResult struct {
+	File      string
+	Outcome   string
+	Conflicts []string // keys whose local edits conflict with the defaults; none when the whole file does
+	Backup    string   // .orig copy of the previous file, relative to .agentops/, when it was rewritten
+}
+
+// Upgrade brings the files of the .agentops/ found from startDir up to date
+// with the embedded defaults, or the preset its strategy.yaml records,
+// limited to files when any are named. Each file
+// is three-way merged: changes to the defaults since the baseline are applied
+// unless the file changed the same setting. Files without a baseline merge
+// against an empty one, so only keys the file lacks are added. Conflicting
+// files are not written unless force is set, in which case the defaults win.
+// Rewritten files are first copied to <file>.orig, and the baseline is moved
+// to the defaults of every file that no longer conflicts.
+func Upgrade(startDir string, force bool, files ...string) ([]UpgradeResult, error) {
+	root, err := findRoot(startDir)
+	if err != nil {
+		return nil, err
+	}
+	layers, err := Layers(root)
+	if err != nil {
+		return nil, err
+	}
+	preset, err := basePreset(root)
+	if err != nil {
+		return nil, err
+	}
+	names, err := preset.Files()
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		if !contains(names, f) {
+			return nil, fmt.Errorf("%s: no such default (want one of %s)", f, strings.Join(names, ", "))
+		}
+	}
+
+	dir := filepath.Join(root, ".agentops")
+	sums, err := readSums(dir)
+	if err != nil {
+		return nil, err
+	}
+	var results []UpgradeResult
+	for _, name := range names {
+		if len(files) > 0 && !contains(files, name) {
+			continue
+		}
+		next, err := preset.ReadFile(name)
+		if err != nil {
+			return nil, fmt.Errorf("read default %s: %w", name, err)
+		}
+		r, err := upgradeFile(dir, name, next, baseline(dir, name, sums), force, inherited(layers, name))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if r.Outcome != UpgradeConflict && r.Outcome != UpgradeInherited {
+			if err := recordBaseline(dir, name, next, sums); err != nil {
+				return nil, err
+			}
+		}
+		results = append(results, r)
+	}
+	return results, writeSums(dir, sums)
+}
+
+// upgradeFile upgrades .agentops/name in dir to the default next, given the
+// baseline base (nil when unknown).
+func upgradeFile(dir, name string, next, base []byte, force, inherited bool) (UpgradeResult, error) {
+	r := UpgradeResult{File: name}
+	path := filepath.Join(dir, name)
+	local, err := os.ReadFile(path)
+	switch {
+	case os.IsNotExist(err) && inherited:
+		r.Outcome = UpgradeInherited
+		return r, nil
+	case os.IsNotExist(err):
+		r.Outcome = UpgradeAdded
+		return r, os.WriteFile(path, next, 0o644)
+	case err != nil:
+		return r, err
+	}
+
+	var merged []byte
+	switch {
+	case bytes.Equal(local, next):
+		r.Outcome = UpgradeCurrent
+		return r, nil
+	case base != nil && bytes.Equal(next, base):
+		r.Outcome = UpgradeKept
+		return r, nil
+	case base != nil && bytes.Equal(local, base):
+		r.Outcome, merged = UpgradeUpdated, next
+	case !isYAML(name):
+		r.Outcome, merged = UpgradeConflict, next
+	default:
+		if merged, r.Conflicts, err = mergeYAML(base, local, next, force); err != nil {
+			return r, err
+		}
+		switch {
+		case len(r.Conflicts) > 0:
+			r.Outcome = UpgradeConflict
+		case merged == nil:
+			r.Outcome = UpgradeKept
+			return r, nil
+		default:
+			r.Outcome = UpgradeMerged
+		}
+	}
+	if r.Outcome == UpgradeConflict {
+		if !force {
+			return r, nil
+		}
+		r.Outcome = UpgradeForced
+	}
+
+	r.Backup = name + ".orig"
+	if err := os.WriteFile(filepath.Join(dir, r.Backup), local, 0o644); err != nil {
+		return r, err
+	}
+	return r, os.WriteFile(path, merged, 0o644)
+}
+
+// mergeYAML three-way merges the YAML documents base, local and next. It
+// returns the merged document, or nil when it equals local, and the keys
+// where local and next changed the same setting differently. Conflicts keep
+// the local value unless force is set.
+func mergeYAML(base, local, next []byte, force bool) ([]byte, []string, error) {
+	b, err := parseDoc(base)
+	if err != nil {
+		return nil, nil, fmt.Errorf("baseline: %w", err)
+	}
+	l, err := parseDoc(local)
+	if err != nil {
+		return nil, nil, err
+	}
+	n, err := parseDoc(next)
+	if err != nil {
+		return nil, nil, fmt.Errorf("default: %w", err)
+	}
+
+	var baseBody *yaml.Node
+	if b != nil {
+		baseBody = body(b)
+	}
+	var conflicts []string
+	out := merge3(baseBody, body(l), body(n), "", force, &conflicts)
+	if equalNodes(out, body(l)) {
+		return nil, conflicts, nil
+	}
+
+	doc := l
+	if doc == nil {
+		doc = n
+	}
+	merged := *doc
+	merged.Content = []*yaml.Node{out}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&merged); err != nil {
+		return nil, nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, nil, err
+	}
+	return buf.Bytes(), conflicts, nil
+}
+
+// merge3 merges the change from base to next into local at path; a nil node
+// is an absent key. Mappings merge key by key, keeping the order of local and
+// appending keys new in next. Anything else changed differently on both
+// sides is a conflict, resolved to local, or to next when force is set.
+func merge3(base, local, next *yaml.Node, path string, force bool, conflicts *[]string) *yaml.Node {
+	switch {
+	case equalNodes(local, next), equalNodes(next, base):
+		return local
+	case equalNodes(local, base):
+		return next
+	case isMapping(local) && isMapping(next) && (base == nil || isMapping(base)):
+		out := *local
+		out.Content = nil
+		if len(local.Content) == 0 {
+			out.Style = 0 // keys are added to an empty {}: write them as a block
+		}
+		add := func(key *yaml.Node) {
+			b, l, n := lookup(base, key.Value), lookup(local, key.Value), lookup(next, key.Value)
+			if v := merge3(b, l, n, join(path, key.Value), force, conflicts); v != nil {
+				out.Content = append(out.Content, key, v)
+			}
+		}
+		eachEntry(local, func(key, _ *yaml.Node) { add(key) })
+		eachEntry(next, func(key, _ *yaml.Node) {
+			if lookup(local, key.Value) == nil {
+				add(key)
+			}
+		})
+		return &out
+	}
+	*conflicts = append(*conflicts, path)
+	if force {
+		return next
+	}
+	return local
+}
+
+func isMapping(n *yaml.Node) bool {
+	return n != nil && n.Kind == yaml.MappingNode
+}
+
+// equalNodes reports whether a and b hold the same values; two absent nodes
+// are equal.
+func equalNodes(a, b *yaml.Node) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return reflect.DeepEqual(value(a), value(b))
+}
+
+// baseline returns the recorded baseline of name, or nil when there is none
+// or its copy does not match the recorded hash.
+func baseline(dir, name string, sums map[string]string) []byte {
+	data, err := os.ReadFile(filepath.Join(dir, baselineDir, name))
+	if err != nil || sums[name] != hash(data) {
+		return nil
+	}
+	return data
+}
+
+// recordBaseline makes data the baseline of name.
+func recordBaseline(dir, name string, data []byte, sums map[string]string) error {
+	if err := os.MkdirAll(filepath.Join(dir, baselineDir), 0o755); err != nil {
+		return fmt.Errorf("create %s: %w", baselineDir, err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, baselineDir, name), data, 0o644); err != nil {
+		return fmt.Errorf("record baseline: %w", err)
+	}
+	sums[name] = hash(data)
+	return nil
+}
+
+// readSums reads the baseline hashes, by file name.
+func readSums(dir string) (map[string]string, error) {
+	sums := map[string]string{}
+	data, err := os.ReadFile(filepath.Join(dir, baselineDir, baselineSums))
+	if os.IsNotExist(err) {
+		return sums, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		if sum, name, ok := strings.Cut(sc.Text(), "  "); ok {
+			sums[name] = sum
+		}
+	}
+	return sums, sc.Err()
+}
+
+// writeSums writes the baseline hashes in sha256sum format.
+func writeSums(dir string, sums map[string]string) error {
+	if len(sums) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(sums))
+	for name := range sums {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var buf bytes.Buffer
+	for _, name := range names {
+		fmt.Fprintf(&buf, "%s  %s\n", sums[name], name)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, baselineDir), 0o755); err != nil {
+		return fmt.Errorf("create %s: %w", baselineDir, err)
+	}
+	return os.WriteFile(filepath.Join(dir, baselineDir, baselineSums), buf.Bytes(), 0o644)
+}
+
+func hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,239 @@
+package strategy_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gh-xj/agentops/strategy"
+)
+
+// writeBaseline records content as the baseline of name, as an older
+// Bootstrap would have.
+func writeBaseline(t *testing.T, root, name, content string) {
+	t.Helper()
+	dir := filepath.Join(root, ".agentops", ".baseline")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256([]byte(content))
+	f, err := os.OpenFile(filepath.Join(dir, "SHA256SUMS"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(hex.EncodeToString(sum[:]) + "  " + name + "\n"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func outcomes(results []strategy.UpgradeResult) map[string]string {
+	out := map[string]string{}
+	for _, r := range results {
+		out[r.File] = r.Outcome
+	}
+	return out
+}
+
+func TestDiffReportsDrift(t *testing.T) {
+	root := t.TempDir()
+	if err := strategy.Bootstrap(root); err != nil {
+		t.Fatal(err)
+	}
+	writeStrategy(t, root, map[string]string{
+		"budget.yaml":  "# reformatted\nstale_after: {in_progress: 10d, blocked: 14d}\nlimits: {}\ntracking: {}\ncustom: 1\n",
+		"strategy.md":  "# Strategy\n\nOur project.\n",
+		"routing.yaml": "",
+	})
+	if err := os.Remove(filepath.Join(root, ".agentops", "hooks.yaml")); err != nil {
+		t.Fatal(err)
+	}
+
+	drifts, err := strategy.Diff(root)
+	if err != nil {
+		t.Fatalf("Diff: %v", err)
+	}
+	var got []string
+	for _, d := range drifts {
+		got = append(got, d.File+" "+d.Key+" "+d.Change)
+	}
+	want := []string{
+		"budget.yaml stale_after.in_progress changed",
+		"budget.yaml custom local_only",
+		"hooks.yaml  missing",
+		"routing.yaml default_route default_only",
+		"routing.yaml overrides default_only",
+		"routing.yaml cues default_only",
+		"strategy.md  changed",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("drift =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if drifts[0].Local != "10d" || drifts[0].Default != "7d" {
+		t.Errorf("changed drift = %+v", drifts[0])
+	}
+}
+
+func TestUpgradeMergesDefaults(t *testing.T) {
+	root := t.TempDir()
+	if err := strategy.Bootstrap(root); err != nil {
+		t.Fatal(err)
+	}
+	agentops := filepath.Join(root, ".agentops")
+	os.RemoveAll(filepath.Join(agentops, ".baseline"))
+
+	// routing.yaml is unedited since an older default, which it still matches.
+	writeBaseline(t, root, "routing.yaml", "default_route: {}\n")
+	writeStrategy(t, root, map[string]string{"routing.yaml": "default_route: {}\n"})
+	// transitions.yaml was bootstrapped before close_no_action existed, and
+	// has since gained a local reopen transition.
+	writeBaseline(t, root, "transitions.yaml", "categories:\n  active: [open, in_progress, blocked]\n  completed: [resolved]\ninitial: open\ntransitions:\n  start: {from: open, to: in_progress}\n")
+	writeStrategy(t, root, map[string]string{"transitions.yaml": "# ours\ncategories:\n  active: [open, in_progress, blocked]\n  completed: [resolved]\ninitial: open\ntransitions:\n  start: {from: open, to: in_progress}\n  reopen: {from: resolved, to: open}\n"})
+	// risk.yaml predates the baseline; the keys it lacks are added.
+	writeStrategy(t, root, map[string]string{"risk.yaml": "rules:\n  auth: {score: 4}\n"})
+	// budget.yaml only differs locally from an unchanged default.
+	writeBaseline(t, root, "budget.yaml", readFile(t, filepath.Join(agentops, "budget.yaml")))
+	writeStrategy(t, root, map[string]string{"budget.yaml": "stale_after: {in_progress: 3d}\n"})
+	os.Remove(filepath.Join(agentops, "hooks.yaml"))
+
+	results, err := strategy.Upgrade(root, false)
+	if err != nil {
+		t.Fatalf("Upgrade: %v", err)
+	}
+	got := outcomes(results)
+	for file, want := range map[string]string{
+		"routing.yaml":     strategy.UpgradeUpdated,
+		"transitions.yaml": strategy.UpgradeMerged,
+		"risk.yaml":        strategy.UpgradeMerged,
+		"budget.yaml":      strategy.UpgradeKept,
+		"hooks.yaml":       strategy.UpgradeAdded,
+		"storage.yaml":     strategy.UpgradeCurrent,
+	} {
+		if got[file] != want {
+			t.Errorf("%s: outcome %q, want %q", file, got[file], want)
+		}
+	}
+
+	routing := readFile(t, filepath.Join(agentops, "routing.yaml"))
+	if !strings.Contains(routing, "cues: {}") {
+		t.Errorf("routing.yaml not replaced by the default:\n%s", routing)
+	}
+	if readFile(t, filepath.Join(agentops, "routing.yaml.orig")) != "default_route: {}\n" {
+		t.Error("routing.yaml.orig should hold the previous file")
+	}
+	transitions := readFile(t, filepath.Join(agentops, "transitions.yaml"))
+	for _, want := range []string{"# ours", "reopen:", "close_no_action:", "completed: [resolved, closed_no_action]"} {
+		if !strings.Contains(transitions, want) {
+			t.Errorf("transitions.yaml missing %q:\n%s", want, transitions)
+		}
+	}
+	risk := readFile(t, filepath.Join(agentops, "risk.yaml"))
+	for _, want := range []string{"auth: {score: 4}", "medium: 3", "escalation: {}"} {
+		if !strings.Contains(risk, want) {
+			t.Errorf("risk.yaml missing %q:\n%s", want, risk)
+		}
+	}
+	if readFile(t, filepath.Join(agentops, "budget.yaml")) != "stale_after: {in_progress: 3d}\n" {
+		t.Error("budget.yaml should be left alone")
+	}
+}
+
+func TestUpgradeConflicts(t *testing.T) {
+	root := t.TempDir()
+	if err := strategy.Bootstrap(root); err != nil {
+		t.Fatal(err)
+	}
+	agentops := filepath.Join(root, ".agentops")
+	os.RemoveAll(filepath.Join(agentops, ".baseline"))
+	writeBaseline(t, root, "budget.yaml", "stale_after:\n  in_progress: 5d\n")
+	local := "stale_after:\n  in_progress: 10d\nlimits:\n  per_case: {loop_iterations: 9}\n"
+	writeStrategy(t, root, map[string]string{
+		"budget.yaml": local,
+		"strategy.md": "# Strategy\n\nOurs.\n",
+	})
+
+	results, err := strategy.Upgrade(root, false)
+	if err != nil {
+		t.Fatalf("Upgrade: %v", err)
+	}
+	for _, r := range results {
+		switch r.File {
+		case "budget.yaml":
+			if r.Outcome != strategy.UpgradeConflict || !reflect.DeepEqual(r.Conflicts, []string{"stale_after.in_progress"}) {
+				t.Errorf("budget.yaml = %+v, want a conflict on stale_after.in_progress", r)
+			}
+		case "strategy.md":
+			if r.Outcome != strategy.UpgradeConflict {
+				t.Errorf("strategy.md = %+v, want a conflict", r)
+			}
+		}
+	}
+	if readFile(t, filepath.Join(agentops, "budget.yaml")) != local {
+		t.Error("conflicting budget.yaml was written")
+	}
+	if _, err := os.Stat(filepath.Join(agentops, "budget.yaml.orig")); !os.IsNotExist(err) {
+		t.Error("no backup should be written without changes")
+	}
+
+	results, err = strategy.Upgrade(root, true, "budget.yaml")
+	if err != nil {
+		t.Fatalf("Upgrade --force: %v", err)
+	}
+	if len(results) != 1 || results[0].Outcome != strategy.UpgradeForced || results[0].Backup != "budget.yaml.orig" {
+		t.Fatalf("forced results = %+v", results)
+	}
+	budget := readFile(t, filepath.Join(agentops, "budget.yaml"))
+	if !strings.Contains(budget, "in_progress: 7d") || !strings.Contains(budget, "loop_iterations: 9") {
+		t.Errorf("forced budget.yaml should take the default and keep local additions:\n%s", budget)
+	}
+	if readFile(t, filepath.Join(agentops, "budget.yaml.orig")) != local {
+		t.Error("budget.yaml.orig should hold the previous file")
+	}
+	if readFile(t, filepath.Join(agentops, "strategy.md")) != "# Strategy\n\nOurs.\n" {
+		t.Error("strategy.md was not named and must be left alone")
+	}
+
+	// The forced file is now at its baseline.
+	results, _ = strategy.Upgrade(root, false, "budget.yaml")
+	if results[0].Outcome != strategy.UpgradeKept {
+		t.Errorf("after force: %+v, want kept", results[0])
+	}
+	if _, err := strategy.Upgrade(root, false, "nope.yaml"); err == nil {
+		t.Error("unknown file: want error")
+	}
+}
+
+func TestUpgradeLeavesInheritedFiles(t *testing.T) {
+	root := t.TempDir()
+	writeStrategy(t, root, map[string]string{
+		"strategy.yaml": "extends: preset:defaults\n",
+		"storage.yaml":  "backend: in-repo\n",
+	})
+	results, err := strategy.Upgrade(root, false)
+	if err != nil {
+		t.Fatalf("Upgrade: %v", err)
+	}
+	got := outcomes(results)
+	if got["transitions.yaml"] != strategy.UpgradeInherited || got["storage.yaml"] != strategy.UpgradeConflict {
+		t.Errorf("outcomes = %v", got)
+	}
+	if _, err := os.Stat(filepath.Join(root, ".agentops", "transitions.yaml")); !os.IsNotExist(err) {
+		t.Error("inherited transitions.yaml should not be written")
+	}
+}
@@ -0,0 +1,546 @@
+package strategy
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	agentops "github.com/gh-xj/agentops"
+	"gopkg.in/yaml.v3"
+)
+
+// strategyFiles maps each YAML file of .agentops/ to the type it decodes into.
+var strategyFiles = []struct {
+	name string
+	typ  reflect.Type
+}{
+	{"storage.yaml", reflect.TypeOf(StorageConfig{})},
+	{"transitions.yaml", reflect.TypeOf(TransitionsConfig{})},
+	{"risk.yaml", reflect.TypeOf(RiskConfig{})},
+	{"routing.yaml", reflect.TypeOf(RoutingConfig{})},
+	{"budget.yaml", reflect.TypeOf(BudgetConfig{})},
+	{"hooks.yaml", reflect.TypeOf(HooksConfig{})},
+}
+
+// Validate checks the strategy files of the .agentops/ found from startDir,
+// and of the strategies it extends, against their schemas: unknown keys,
+// values of the wrong type, statuses that transitions.yaml does not define or
+// that no transition reaches, and policy values that cannot take effect. The
+// semantic checks run on the merged files. Each finding is located as
+// file:line:column, in the layer that supplied the value. Validate does not
+// need the strategy to load.
+func Validate(startDir string) (agentops.DoctorReport, error) {
+	root, err := findRoot(startDir)
+	if err != nil {
+		return agentops.DoctorReport{}, err
+	}
+
+	v := &validator{docs: map[string]*yaml.Node{}, merger: newMerger()}
+	layers, err := Layers(root)
+	if err != nil {
+		v.findings = append(v.findings, agentops.DoctorFinding{
+			Code:    "invalid_extends",
+			Path:    configFile,
+			Message: err.Error(),
+		})
+		layers = []Layer{{Name: localLayer, files: os.DirFS(filepath.Join(root, ".agentops"))}}
+	}
+	for i := range layers {
+		v.file = configFile
+		if doc := v.parse(&layers[i]); doc != nil {
+			v.check(doc, reflect.TypeOf(StrategyConfig{}), "")
+		}
+	}
+	for _, f := range strategyFiles {
+		v.file = f.name
+		for i := range layers {
+			doc := v.parse(&layers[i])
+			if doc == nil {
+				continue
+			}
+			v.check(doc, f.typ, "")
+			v.docs[f.name] = v.merge(v.docs[f.name], doc)
+		}
+	}
+
+	v.checkTransitions()
+	v.checkRisk()
+	v.checkRouting()
+	v.checkBudget()
+	v.checkStorage()
+
+	return agentops.DoctorReport{
+		SchemaVersion: "1.0",
+		OK:            len(v.findings) == 0,
+		Findings:      v.findings,
+	}, nil
+}
+
+// validator collects findings over the parsed strategy files.
+type validator struct {
+	*merger
+	file     string                // name of the strategy file being checked
+	docs     map[string]*yaml.Node // merged root node of each parsed file
+	statuses map[string]bool       // statuses defined by transitions.yaml
+	findings []agentops.DoctorFinding
+}
+
+// parse reads the current file from layer l, reporting YAML syntax errors.
+// It returns nil when the layer lacks the file or it does not parse.
+func (v *validator) parse(l *Layer) *yaml.Node {
+	doc, err := readLayerFile(*l, v.file)
+	if err != nil {
+		v.findings = append(v.findings, agentops.DoctorFinding{
+			Code:    "invalid_yaml",
+			Path:    l.label(v.file) + yamlErrorLine(err),
+			Message: err.Error(),
+		})
+		return nil
+	}
+	if doc != nil {
+		v.claim(doc, l)
+	}
+	return doc
+}
+
+// add reports a finding at n, in the layer n came from.
+func (v *validator) add(n *yaml.Node, code, format string, args ...any) {
+	file := v.file
+	if l := v.origin[n]; l != nil {
+		file = l.label(v.file)
+	}
+	v.findings = append(v.findings, agentops.DoctorFinding{
+		Code:    code,
+		Path:    fmt.Sprintf("%s:%d:%d", file, n.Line, n.Column),
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+var unmarshalerType = reflect.TypeOf((*yaml.Unmarshaler)(nil)).Elem()
+
+// check reports keys of n that t does not declare and values that do not
+// decode into their field's type. path names n in messages.
+func (v *validator) check(n *yaml.Node, t reflect.Type, path string) {
+	if n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+	if n.Kind == yaml.ScalarNode && n.Tag == "!!null" {
+		return
+	}
+	if reflect.PointerTo(t).Implements(unmarshalerType) {
+		if err := n.Decode(reflect.New(t).Interface()); err != nil {
+			v.add(n, "bad_type", "%s: %v", label(path), yamlErrorText(err))
+			return
+		}
+		if n.Kind != yaml.MappingNode {
+			return
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if n.Kind != yaml.MappingNode {
+			v.add(n, "bad_type", "%s: want a mapping", label(path))
+			return
+		}
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, val := n.Content[i], n.Content[i+1]
+			ft, ok := fields[key.Value]
+			if !ok {
+				v.add(key, "unknown_key", "%s: unknown key %q (want one of %s)", label(path), key.Value, strings.Join(sortedNames(fields), ", "))
+				continue
+			}
+			v.check(val, ft, join(path, key.Value))
+		}
+	case reflect.Map:
+		if n.Kind != yaml.MappingNode {
+			v.add(n, "bad_type", "%s: want a mapping", label(path))
+			return
+		}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			v.check(n.Content[i+1], t.Elem(), join(path, n.Content[i].Value))
+		}
+	case reflect.Slice:
+		if n.Kind != yaml.SequenceNode {
+			v.add(n, "bad_type", "%s: want a list", label(path))
+			return
+		}
+		for i, item := range n.Content {
+			v.check(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i))
+		}
+	case reflect.Interface:
+		// Any value is accepted; the semantic checks look closer.
+	default:
+		if n.Kind != yaml.ScalarNode {
+			v.add(n, "bad_type", "%s: want %s", label(path), article(kindName(t)))
+			return
+		}
+		if err := n.Decode(reflect.New(t).Interface()); err != nil {
+			v.add(n, "bad_type", "%s: %q is not %s", label(path), n.Value, article(kindName(t)))
+		}
+	}
+}
+
+// checkTransitions reports statuses transitions.yaml uses without defining
+// them in categories, and statuses no transition reaches from initial.
+func (v *validator) checkTransitions() {
+	doc := v.docs["transitions.yaml"]
+	if doc == nil {
+		return
+	}
+	v.file = "transitions.yaml"
+
+	v.statuses = map[string]bool{}
+	var order []*yaml.Node
+	if cats := lookup(doc, "categories"); cats != nil && cats.Kind == yaml.MappingNode {
+		for i := 1; i < len(cats.Content); i += 2 {
+			for _, s := range scalars(cats.Content[i]) {
+				v.statuses[s.Value] = true
+				order = append(order, s)
+			}
+		}
+	}
+
+	initial := lookup(doc, "initial")
+	switch {
+	case initial == nil || initial.Value == "":
+		v.add(doc, "missing_initial", "initial: not set")
+	case !v.statuses[initial.Value]:
+		v.add(initial, "unknown_status", "initial: %q is not a status in categories", initial.Value)
+	}
+
+	next := map[string][]string{}
+	if trans := lookup(doc, "transitions"); trans != nil && trans.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(trans.Content); i += 2 {
+			name, def := trans.Content[i].Value, trans.Content[i+1]
+			to := lookup(def, "to")
+			switch {
+			case to == nil || to.Value == "":
+				v.add(trans.Content[i], "missing_target", "transitions.%s: to is not set", name)
+			case !v.statuses[to.Value]:
+				v.add(to, "unknown_status", "transitions.%s.to: %q is not a status in categories", name, to.Value)
+			}
+			from := lookup(def, "from")
+			if from == nil {
+				v.add(trans.Content[i], "missing_source", "transitions.%s: from is not set", name)
+				continue
+			}
+			if from.Kind != yaml.ScalarNode && from.Kind != yaml.SequenceNode {
+				v.add(from, "bad_type", "transitions.%s.from: want a status or a list of statuses", name)
+				continue
+			}
+			for _, s := range scalars(from) {
+				if !v.statuses[s.Value] {
+					v.add(s, "unknown_status", "transitions.%s.from: %q is not a status in categories", name, s.Value)
+				}
+				if to != nil {
+					next[s.Value] = append(next[s.Value], to.Value)
+				}
+			}
+		}
+	}
+
+	if initial == nil || !v.statuses[initial.Value] {
+		return
+	}
+	reached := map[string]bool{initial.Value: true}
+	queue := []string{initial.Value}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, s := range next[cur] {
+			if !reached[s] {
+				reached[s] = true
+				queue = append(queue, s)
+			}
+		}
+	}
+	for _, s := range order {
+		if !reached[s.Value] {
+			v.add(s, "unreachable_status", "categories: status %q cannot be reached from %q", s.Value, initial.Value)
+		}
+	}
+}
+
+// checkRisk reports risk levels risk.yaml does not know, thresholds that do
+// not increase with the level and rules that cannot match.
+func (v *validator) checkRisk() {
+	doc := v.docs["risk.yaml"]
+	if doc == nil {
+		return
+	}
+	v.file = "risk.yaml"
+	v.checkLevelKeys(lookup(doc, "escalation"), "escalation")
+
+	thresholds := lookup(doc, "thresholds")
+	v.checkLevelKeys(thresholds, "thresholds")
+	prev, prevLevel := 0, ""
+	for _, level := range RiskLevels {
+		n := lookup(thresholds, level)
+		if n == nil {
+			continue
+		}
+		score, err := strconv.Atoi(n.Value)
+		if err != nil {
+			continue // reported as bad_type
+		}
+		if prevLevel != "" && score <= prev {
+			v.add(n, "invalid_value", "thresholds.%s: %d must be above %s (%d)", level, score, prevLevel, prev)
+		}
+		prev, prevLevel = score, level
+	}
+
+	eachEntry(lookup(doc, "rules"), func(key, rule *yaml.Node) {
+		conditions := 0
+		for _, field := range []string{"types", "paths", "labels", "keywords"} {
+			conditions += len(scalars(lookup(rule, field)))
+		}
+		if conditions == 0 {
+			v.add(key, "invalid_value", "rules.%s: no types, paths, labels or keywords to match", key.Value)
+		}
+		for _, glob := range scalars(lookup(rule, "paths")) {
+			if _, err := path.Match(glob.Value, ""); err != nil {
+				v.add(glob, "invalid_value", "rules.%s.paths: %q: %v", key.Value, glob.Value, err)
+			}
+		}
+	})
+}
+
+// checkRouting reports cue patterns that do not compile, cues without a type
+// or conditions and overrides naming an unknown risk level.
+func (v *validator) checkRouting() {
+	doc := v.docs["routing.yaml"]
+	if doc == nil {
+		return
+	}
+	v.file = "routing.yaml"
+	eachEntry(lookup(doc, "cues"), func(key, cue *yaml.Node) {
+		if t := lookup(cue, "type"); t == nil || t.Value == "" {
+			v.add(key, "invalid_value", "cues.%s: type is not set", key.Value)
+		}
+		fields := lookup(cue, "fields")
+		if lookup(cue, "title") == nil && lookup(cue, "body") == nil && (fields == nil || len(fields.Content) == 0) {
+			v.add(key, "invalid_value", "cues.%s: no title, body or fields to match", key.Value)
+		}
+		for _, field := range []string{"title", "body"} {
+			n := lookup(cue, field)
+			if n == nil {
+				continue
+			}
+			if _, err := regexp.Compile(n.Value); err != nil {
+				v.add(n, "invalid_value", "cues.%s.%s: %v", key.Value, field, err)
+			}
+		}
+	})
+	eachEntry(lookup(doc, "overrides"), func(key, o *yaml.Node) {
+		if r := lookup(o, "risk"); r != nil && r.Value != "" && !isRiskLevel(r.Value) {
+			v.add(r, "invalid_value", "overrides.%s.risk: %q is not a risk level (want %s)", key.Value, r.Value, strings.Join(RiskLevels, ", "))
+		}
+	})
+}
+
+// checkBudget reports stale_after entries for unknown statuses and ages
+// that do not parse.
+func (v *validator) checkBudget() {
+	doc := v.docs["budget.yaml"]
+	if doc == nil {
+		return
+	}
+	v.file = "budget.yaml"
+	eachEntry(lookup(doc, "stale_after"), func(key, age *yaml.Node) {
+		if v.statuses != nil && !v.statuses[key.Value] {
+			v.add(key, "unknown_status", "stale_after: %q is not a status in transitions.yaml", key.Value)
+		}
+		if _, err := ParseAge(age.Value); err != nil {
+			v.add(age, "invalid_value", "stale_after.%s: %v", key.Value, err)
+		}
+	})
+	for _, scope := range []string{"per_case", "per_slot"} {
+		if n := lookup(doc, "limits", scope, "wall_clock"); n != nil {
+			if _, err := ParseAge(n.Value); err != nil {
+				v.add(n, "invalid_value", "limits.%s.wall_clock: %v", scope, err)
+			}
+		}
+	}
+}
+
+// checkStorage reports storage.yaml values outside their allowed sets.
+func (v *validator) checkStorage() {
+	doc := v.docs["storage.yaml"]
+	if doc == nil {
+		return
+	}
+	v.file = "storage.yaml"
+	for _, c := range []struct {
+		path    []string
+		allowed []string
+	}{
+		{[]string{"backend"}, []string{BackendSeparateRepo, BackendInRepo}},
+		{[]string{"layout"}, []string{LayoutFlat, LayoutGrouped}},
+		{[]string{"index"}, []string{IndexSQLite}},
+		{[]string{"retention", "format"}, []string{ArchiveFormatDir, ArchiveFormatTarGz}},
+	} {
+		n := lookup(doc, c.path...)
+		if n == nil || n.Value == "" || contains(c.allowed, n.Value) {
+			continue
+		}
+		v.add(n, "invalid_value", "%s: %q is not one of %s", strings.Join(c.path, "."), n.Value, strings.Join(c.allowed, ", "))
+	}
+	if n := lookup(doc, "retention", "completed_after"); n != nil {
+		if _, err := ParseAge(n.Value); err != nil {
+			v.add(n, "invalid_value", "retention.completed_after: %v", err)
+		}
+	}
+}
+
+// checkLevelKeys reports keys of a mapping that are not risk levels.
+func (v *validator) checkLevelKeys(n *yaml.Node, path string) {
+	eachEntry(n, func(key, _ *yaml.Node) {
+		if !isRiskLevel(key.Value) {
+			v.add(key, "invalid_value", "%s: %q is not a risk level (want %s)", path, key.Value, strings.Join(RiskLevels, ", "))
+		}
+	})
+}
+
+// lookup follows keys through nested mappings and returns the value node,
+// or nil when a key is missing.
+func lookup(n *yaml.Node, keys ...string) *yaml.Node {
+	for _, key := range keys {
+		if n == nil || n.Kind != yaml.MappingNode {
+			return nil
+		}
+		var next *yaml.Node
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if n.Content[i].Value == key {
+				next = n.Content[i+1]
+				break
+			}
+		}
+		n = next
+	}
+	return n
+}
+
+// eachEntry calls fn for every key and value of a mapping node.
+func eachEntry(n *yaml.Node, fn func(key, val *yaml.Node)) {
+	if n == nil || n.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		fn(n.Content[i], n.Content[i+1])
+	}
+}
+
+// scalars returns n itself when it is a scalar, or the scalar items of a
+// sequence. A nil node has none.
+func scalars(n *yaml.Node) []*yaml.Node {
+	if n == nil {
+		return nil
+	}
+	switch n.Kind {
+	case yaml.ScalarNode:
+		return []*yaml.Node{n}
+	case yaml.SequenceNode:
+		var out []*yaml.Node
+		for _, item := range n.Content {
+			if item.Kind == yaml.ScalarNode {
+				out = append(out, item)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// yamlFields maps the yaml key of each field of struct type t to its type.
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
+
+func sortedNames(m map[string]reflect.Type) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func kindName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int64, reflect.Int32:
+		return "integer"
+	case reflect.Float64, reflect.Float32:
+		return "number"
+	}
+	return t.Kind().String()
+}
+
+// article returns name with its indefinite article.
+func article(name string) string {
+	if strings.ContainsAny(name[:1], "aeiou") {
+		return "an " + name
+	}
+	return "a " + name
+}
+
+func isRiskLevel(s string) bool { return contains(RiskLevels, s) }
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// label names the document root in messages.
+func label(path string) string {
+	if path == "" {
+		return "(top level)"
+	}
+	return path
+}
+
+var yamlLinePattern = regexp.MustCompile(`line (\d+)`)
+
+// yamlErrorLine returns ":<line>" for a YAML syntax error that names one.
+func yamlErrorLine(err error) string {
+	if m := yamlLinePattern.FindStringSubmatch(err.Error()); m != nil {
+		return ":" + m[1]
+	}
+	return ""
+}
+
+// yamlErrorText strips the "yaml: unmarshal errors" preamble from a decode
+// error.
+func yamlErrorText(err error) string {
+	msg := strings.TrimPrefix(err.Error(), "yaml: unmarshal errors:\n")
+	return strings.TrimSpace(msg)
+}
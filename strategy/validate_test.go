@@ -0,0 +1,155 @@
+package strategy_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gh-xj/agentops/strategy"
+)
+
+func TestValidateDefaults(t *testing.T) {
+	tmp := t.TempDir()
+	if err := strategy.Bootstrap(tmp); err != nil {
+		t.Fatalf("Bootstrap failed: %v", err)
+	}
+	report, err := strategy.Validate(tmp)
+	if err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if !report.OK || len(report.Findings) != 0 {
+		t.Errorf("embedded defaults should validate, got %+v", report.Findings)
+	}
+}
+
+func TestValidateReportsProblems(t *testing.T) {
+	tmp := t.TempDir()
+	if err := strategy.Bootstrap(tmp); err != nil {
+		t.Fatalf("Bootstrap failed: %v", err)
+	}
+	files := map[string]string{
+		"transitions.yaml": `categories:
+  active: [open, in_progress]
+  completed: [resolved, wontfix]
+initial: new
+transitions:
+  start:
+    from: open
+    to: in_progress
+  resolve:
+    from: [in_progress, reviewing]
+    to: resolved
+`,
+		"risk.yaml": `thresholds:
+  medium: 5
+  high: 3
+escalaton: {}
+rules:
+  empty:
+    score: 2
+  bad:
+    score: 1
+    paths: ["src/["]
+`,
+		"routing.yaml": `cues:
+  crash:
+    title: "panic("
+  empty:
+    type: bug
+overrides:
+  hot:
+    risk: critical
+    workers: reviewer
+`,
+		"budget.yaml": `limits:
+  per_case:
+    worker_invocations: lots
+stale_after:
+  in_progres: 7d
+`,
+		"storage.yaml": "backend: in-repo\nlayout: nested\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tmp, ".agentops", name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	report, err := strategy.Validate(tmp)
+	if err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if report.OK {
+		t.Fatal("report should fail")
+	}
+	got := map[string]string{}
+	for _, f := range report.Findings {
+		got[f.Path] = f.Code
+	}
+	// Reachability is not checked while initial is unknown.
+	want := map[string]string{
+		"transitions.yaml:4:10":  "unknown_status", // initial: new
+		"transitions.yaml:10:25": "unknown_status", // from: reviewing
+		"risk.yaml:3:9":          "invalid_value",  // high below medium
+		"risk.yaml:4:1":          "unknown_key",    // escalaton
+		"risk.yaml:6:3":          "invalid_value",  // rule without conditions
+		"risk.yaml:10:13":        "invalid_value",  // bad glob
+		"routing.yaml:2:3":       "invalid_value",  // cue without a type
+		"routing.yaml:3:12":      "invalid_value",  // bad regexp
+		"routing.yaml:4:3":       "invalid_value",  // cue without conditions
+		"routing.yaml:8:11":      "invalid_value",  // unknown risk level
+		"routing.yaml:9:14":      "bad_type",       // workers is not a list
+		"budget.yaml:3:25":       "bad_type",       // not an integer
+		"budget.yaml:5:3":        "unknown_status", // in_progres
+		"storage.yaml:2:9":       "invalid_value",  // layout
+	}
+	for path, code := range want {
+		if got[path] != code {
+			t.Errorf("finding at %s = %q, want %q", path, got[path], code)
+		}
+	}
+	if len(report.Findings) != len(want) {
+		t.Errorf("got %d findings, want %d: %+v", len(report.Findings), len(want), report.Findings)
+	}
+}
+
+func TestValidateUnreachableStatus(t *testing.T) {
+	tmp := t.TempDir()
+	if err := strategy.Bootstrap(tmp); err != nil {
+		t.Fatalf("Bootstrap failed: %v", err)
+	}
+	transitions := `categories:
+  active: [open, in_progress, blocked]
+  completed: [resolved, wontfix]
+initial: open
+transitions:
+  start:
+    from: open
+    to: in_progress
+  block:
+    from: in_progress
+    to: blocked
+  resolve:
+    from: [in_progress, blocked]
+    to: resolved
+`
+	if err := os.WriteFile(filepath.Join(tmp, ".agentops", "transitions.yaml"), []byte(transitions), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	report, err := strategy.Validate(tmp)
+	if err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if len(report.Findings) != 1 {
+		t.Fatalf("findings = %+v, want one", report.Findings)
+	}
+	if f := report.Findings[0]; f.Code != "unreachable_status" || f.Path != "transitions.yaml:3:25" {
+		t.Errorf("finding = %+v, want unreachable wontfix at transitions.yaml:3:25", f)
+	}
+}
+
+func TestValidateWithoutStrategy(t *testing.T) {
+	if _, err := strategy.Validate(t.TempDir()); err == nil {
+		t.Error("expected error without .agentops/")
+	}
+}
@@ -1,6 +1,9 @@
 package e2e
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
@@ -9,6 +12,7 @@ import (
 	"regexp"
 	"strings"
 	"testing"
+	"time"
 )
 
 // repoRoot returns the absolute path to the repository root.
@@ -225,6 +229,34 @@ func TestCaseWorkflow(t *testing.T) {
 	if !strings.Contains(out, caseID) {
 		t.Errorf("expected get output to contain %q, got:\n%s", caseID, out)
 	}
+
+	// Transition with a reason and read it back from the history.
+	out, code = runCmdInDir(t, binary, dir, "case", "transition", caseID, "start", "--reason", "picked up")
+	if code != 0 {
+		t.Fatalf("case transition failed (exit %d): %s", code, out)
+	}
+	out, code = runCmdInDir(t, binary, dir, "case", "history", caseID, "--json", "action,to,reason")
+	if code != 0 {
+		t.Fatalf("case history failed (exit %d): %s", code, out)
+	}
+	for _, want := range []string{`"action": "create"`, `"to": "in_progress"`, `"reason": "picked up"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected history output to contain %s, got:\n%s", want, out)
+		}
+	}
+
+	// Fill in a section and read it back.
+	out, code = runCmdInDir(t, binary, dir, "case", "section", "append", caseID, "Findings", "--content", "- reproduced locally")
+	if code != 0 {
+		t.Fatalf("case section append failed (exit %d): %s", code, out)
+	}
+	out, code = runCmdInDir(t, binary, dir, "case", "section", "get", caseID, "Findings")
+	if code != 0 {
+		t.Fatalf("case section get failed (exit %d): %s", code, out)
+	}
+	if strings.TrimSpace(out) != "- reproduced locally" {
+		t.Errorf("expected section content, got:\n%s", out)
+	}
 }
 
 func TestInitIdempotent(t *testing.T) {
@@ -263,6 +295,43 @@ func TestInitIdempotent(t *testing.T) {
 	}
 }
 
+func TestInitPreset(t *testing.T) {
+	binary := buildBinary(t)
+
+	out, code := runCmd(t, binary, "init", "--list-presets", "--json", "name")
+	if code != 0 {
+		t.Fatalf("init --list-presets failed (exit %d): %s", code, out)
+	}
+	for _, want := range []string{"defaults", "triage", "pr-review", "incident"} {
+		if !strings.Contains(out, `"`+want+`"`) {
+			t.Errorf("expected preset %q in list, got:\n%s", want, out)
+		}
+	}
+
+	dir := t.TempDir()
+	gitCmd := exec.Command("git", "init")
+	gitCmd.Dir = dir
+	if out, err := gitCmd.CombinedOutput(); err != nil {
+		t.Fatalf("git init failed: %s\n%s", err, out)
+	}
+	if out, code := runCmd(t, binary, "init", "--dir", dir, "--preset", "pr-review"); code != 0 {
+		t.Fatalf("init --preset pr-review failed (exit %d): %s", code, out)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, ".agentops", "transitions.yaml"))
+	if err != nil || !strings.Contains(string(data), "needs_review") {
+		t.Errorf("expected pr-review transitions, got %v:\n%s", err, data)
+	}
+	if out, code := runCmdInDir(t, binary, dir, "strategy", "validate"); code != 0 {
+		t.Errorf("pr-review strategy should validate (exit %d): %s", code, out)
+	}
+	if out, code := runCmd(t, binary, "init", "--dir", dir, "--preset", "incident"); code == 0 {
+		t.Errorf("init with a different preset should fail: %s", out)
+	}
+	if out, code := runCmd(t, binary, "init", "--dir", t.TempDir(), "--preset", "nope"); code != 2 || !strings.Contains(out, "unknown_preset") {
+		t.Errorf("expected exit 2 with unknown_preset, got %d: %s", code, out)
+	}
+}
+
 func TestDoctorWithoutInit(t *testing.T) {
 	binary := buildBinary(t)
 	dir := t.TempDir()
@@ -274,3 +343,265 @@ func TestDoctorWithoutInit(t *testing.T) {
 	}
 	_ = fmt.Sprintf("doctor exited %d as expected: %s", code, out)
 }
+
+func TestStrategyValidate(t *testing.T) {
+	binary := buildBinary(t)
+	dir := initProject(t, binary)
+
+	out, code := runCmdInDir(t, binary, dir, "strategy", "validate")
+	if code != 0 {
+		t.Fatalf("strategy validate failed on defaults (exit %d): %s", code, out)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, ".agentops", "storage.yaml"), []byte("backend: in-repo\nbakend: git\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out, code = runCmdInDir(t, binary, dir, "strategy", "validate")
+	if code != 13 {
+		t.Fatalf("expected exit 13 for an unknown key, got %d: %s", code, out)
+	}
+	if !strings.Contains(out, "storage.yaml:2:1") {
+		t.Errorf("output should locate the unknown key, got: %s", out)
+	}
+}
+
+func TestStrategyShowResolved(t *testing.T) {
+	binary := buildBinary(t)
+	dir := initProject(t, binary)
+	org := filepath.Join(dir, "org-policy", ".agentops")
+	if err := os.MkdirAll(org, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for path, content := range map[string]string{
+		filepath.Join(org, "risk.yaml"):                  "thresholds:\n  medium: 4\n  high: 9\n",
+		filepath.Join(dir, ".agentops", "strategy.yaml"): "extends: org-policy\n",
+		filepath.Join(dir, ".agentops", "risk.yaml"):     "thresholds:\n  high: 7\n",
+	} {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out, code := runCmdInDir(t, binary, dir, "strategy", "show")
+	if code != 0 || !strings.Contains(out, "org-policy/.agentops") {
+		t.Fatalf("strategy show (exit %d): %s", code, out)
+	}
+	out, code = runCmdInDir(t, binary, dir, "strategy", "show", "--resolved")
+	if code != 0 {
+		t.Fatalf("strategy show --resolved failed (exit %d): %s", code, out)
+	}
+	for _, want := range []string{"medium: 4 # org-policy/.agentops", "high: 7 # .agentops", "initial: open # .agentops"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in resolved strategy, got:\n%s", want, out)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, ".agentops", "strategy.yaml"), []byte("extends: missing\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if out, code := runCmdInDir(t, binary, dir, "strategy", "validate"); code != 13 || !strings.Contains(out, "invalid_extends") {
+		t.Errorf("expected exit 13 with invalid_extends, got %d: %s", code, out)
+	}
+}
+
+func TestStrategyDiffAndUpgrade(t *testing.T) {
+	binary := buildBinary(t)
+	dir := initProject(t, binary)
+	agentops := filepath.Join(dir, ".agentops")
+
+	// initProject switched storage.yaml to the in-repo backend.
+	out, code := runCmdInDir(t, binary, dir, "strategy", "diff", "--json", "file,key,change")
+	if code != 0 || strings.Count(out, `"file"`) != 1 || !strings.Contains(out, `"key": "backend"`) {
+		t.Fatalf("strategy diff (exit %d): %s", code, out)
+	}
+
+	// Pretend budget.yaml was bootstrapped from an older default, then edited.
+	baseline := "stale_after:\n  in_progress: 5d\n"
+	sum := sha256.Sum256([]byte(baseline))
+	local := "stale_after:\n  in_progress: 10d\n"
+	for path, content := range map[string]string{
+		filepath.Join(agentops, ".baseline", "budget.yaml"): baseline,
+		filepath.Join(agentops, ".baseline", "SHA256SUMS"):  hex.EncodeToString(sum[:]) + "  budget.yaml\n",
+		filepath.Join(agentops, "budget.yaml"):              local,
+	} {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out, code = runCmdInDir(t, binary, dir, "strategy", "diff")
+	if code != 0 || !strings.Contains(out, "stale_after.in_progress") || !strings.Contains(out, "changed") {
+		t.Fatalf("strategy diff (exit %d): %s", code, out)
+	}
+
+	out, code = runCmdInDir(t, binary, dir, "strategy", "upgrade")
+	if code != 17 || !strings.Contains(out, "upgrade_conflict") {
+		t.Fatalf("expected exit 17 with upgrade_conflict, got %d: %s", code, out)
+	}
+	if data, _ := os.ReadFile(filepath.Join(agentops, "budget.yaml")); string(data) != local {
+		t.Errorf("conflicting budget.yaml was overwritten:\n%s", data)
+	}
+
+	out, code = runCmdInDir(t, binary, dir, "strategy", "upgrade", "--force", "budget.yaml")
+	if code != 0 || !strings.Contains(out, "forced") {
+		t.Fatalf("strategy upgrade --force (exit %d): %s", code, out)
+	}
+	if data, _ := os.ReadFile(filepath.Join(agentops, "budget.yaml.orig")); string(data) != local {
+		t.Errorf("expected budget.yaml.orig to hold the edited file, got:\n%s", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(agentops, "budget.yaml")); !strings.Contains(string(data), "in_progress: 7d") {
+		t.Errorf("expected the default stale_after, got:\n%s", data)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	binary := buildBinary(t)
+	dir := initProject(t, binary)
+	hooksYAML := "on_case_transition: ['echo \"$AGENTOPS_CASE_NEW_STATUS\" > hook.out']\n"
+	if err := os.WriteFile(filepath.Join(dir, ".agentops", "hooks.yaml"), []byte(hooksYAML), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if out, code := runCmdInDir(t, binary, dir, "case", "create", "watched"); code != 0 {
+		t.Fatalf("case create failed (exit %d): %s", code, out)
+	}
+	matches, _ := filepath.Glob(filepath.Join(dir, "cases", "CASE-*", "case.md"))
+	if len(matches) != 1 {
+		t.Fatalf("expected one case, got %v", matches)
+	}
+
+	var stdout strings.Builder
+	cmd := exec.Command(binary, "watch", "--interval", "50ms")
+	cmd.Dir = dir
+	cmd.Stdout = &stdout
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(300 * time.Millisecond)
+	data, err := os.ReadFile(matches[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	edited := strings.Replace(string(data), "status: open", "status: in_progress", 1)
+	if err := os.WriteFile(matches[0], []byte(edited), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	hookOut := filepath.Join(dir, "hook.out")
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(50 * time.Millisecond) {
+		if _, err := os.Stat(hookOut); err == nil {
+			break
+		}
+	}
+	if err := cmd.Process.Signal(os.Interrupt); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Wait(); err != nil {
+		t.Fatalf("watch should exit cleanly on interrupt: %v\n%s", err, stdout.String())
+	}
+
+	if data, err := os.ReadFile(hookOut); err != nil || strings.TrimSpace(string(data)) != "in_progress" {
+		t.Errorf("on-case-transition hook did not run: %v %q", err, data)
+	}
+	var seen []string
+	for _, line := range strings.Split(strings.TrimSpace(stdout.String()), "\n") {
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("watch output is not NDJSON: %q", line)
+		}
+		seen = append(seen, fmt.Sprint(entry["event"]))
+	}
+	if got := strings.Join(seen, ","); got != "watch_started,status_changed,hook,watch_stopped" {
+		t.Errorf("events = %s", got)
+	}
+}
+
+func TestDispatch(t *testing.T) {
+	binary := buildBinary(t)
+	dir := initProject(t, binary)
+	for _, args := range [][]string{
+		{"config", "user.email", "test@test.com"},
+		{"config", "user.name", "test"},
+	} {
+		gitCmd := exec.Command("git", args...)
+		gitCmd.Dir = dir
+		if out, err := gitCmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %s\n%s", args, err, out)
+		}
+	}
+
+	out, code := runCmdInDir(t, binary, dir, "dispatch", "triage-me")
+	if code != 0 {
+		t.Fatalf("dispatch failed (exit %d): %s", code, out)
+	}
+	for _, phase := range []string{"detect-slot", "find-or-create", "commit"} {
+		if !strings.Contains(out, phase) {
+			t.Errorf("expected dispatch output to mention %q, got:\n%s", phase, out)
+		}
+	}
+
+	gitCmd := exec.Command("git", "log", "-1", "--format=%s")
+	gitCmd.Dir = dir
+	subject, err := gitCmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git log failed: %s\n%s", err, subject)
+	}
+	if !regexp.MustCompile(`^dispatch: CASE-\d{8}-triage-me`).Match(subject) {
+		t.Errorf("unexpected commit subject: %s", subject)
+	}
+
+	out, code = runCmdInDir(t, binary, dir, "budget", "report", "--json", "case_type,cases")
+	if code != 0 {
+		t.Fatalf("budget report failed (exit %d): %s", code, out)
+	}
+	if !strings.Contains(out, `"case_type": "intake"`) || !strings.Contains(out, `"cases": 1`) {
+		t.Errorf("budget report should count the dispatched case, got:\n%s", out)
+	}
+	out, code = runCmdInDir(t, binary, dir, "budget", "report", "--until", "2000-01-01", "--json", "cases")
+	if code != 0 || strings.Contains(out, "intake") {
+		t.Errorf("budget report before any spend (exit %d): %s", code, out)
+	}
+	if _, code = runCmdInDir(t, binary, dir, "budget", "report", "--since", "yesterday"); code != 2 {
+		t.Errorf("expected exit 2 for a malformed date, got %d", code)
+	}
+}
+
+func TestCaseClaimOwnership(t *testing.T) {
+	binary := buildBinary(t)
+	dir := initProject(t, binary)
+	setSlot := func(name string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, ".slot"), []byte(name+"\n"), 0o644); err != nil {
+			t.Fatalf("write .slot: %v", err)
+		}
+	}
+
+	out, code := runCmdInDir(t, binary, dir, "case", "create", "owned")
+	if code != 0 {
+		t.Fatalf("case create failed (exit %d): %s", code, out)
+	}
+	caseID := regexp.MustCompile(`CASE-\d{8}-owned`).FindString(out)
+
+	setSlot("alpha")
+	out, code = runCmdInDir(t, binary, dir, "case", "claim", caseID)
+	if code != 0 || !strings.Contains(out, "alpha") {
+		t.Fatalf("case claim failed (exit %d): %s", code, out)
+	}
+
+	setSlot("beta")
+	out, code = runCmdInDir(t, binary, dir, "case", "transition", caseID, "start")
+	if code != 14 {
+		t.Fatalf("transition from other slot: exit %d, want 14: %s", code, out)
+	}
+	out, code = runCmdInDir(t, binary, dir, "case", "claim", caseID)
+	if code != 14 {
+		t.Fatalf("claim from other slot: exit %d, want 14: %s", code, out)
+	}
+
+	out, code = runCmdInDir(t, binary, dir, "case", "claim", caseID, "--force")
+	if code != 0 || !strings.Contains(out, "beta") {
+		t.Fatalf("forced claim failed (exit %d): %s", code, out)
+	}
+	out, code = runCmdInDir(t, binary, dir, "case", "release", caseID)
+	if code != 0 || !strings.Contains(out, "none") {
+		t.Fatalf("case release failed (exit %d): %s", code, out)
+	}
+}
@@ -0,0 +1,390 @@
+// Package watch polls a project's strategy and case files, reloading the
+// strategy when it changes and firing hooks for case changes made outside
+// agentops commands. Every observation is logged as one JSON line.
+package watch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gh-xj/agentops/dal"
+	"github.com/gh-xj/agentops/hooks"
+	caseresource "github.com/gh-xj/agentops/resource/case"
+	workerresource "github.com/gh-xj/agentops/resource/worker"
+	"github.com/gh-xj/agentops/strategy"
+)
+
+// Events logged by a Watcher.
+const (
+	EventStarted          = "watch_started"
+	EventStopped          = "watch_stopped"
+	EventStrategyReloaded = "strategy_reloaded"
+	EventStrategyInvalid  = "strategy_invalid" // the previous strategy stays in effect
+	EventCaseAdded        = "case_added"
+	EventCaseRemoved      = "case_removed"
+	EventStatusChanged    = "status_changed"
+	EventSidecarWritten   = "sidecar_written"
+	EventHook             = "hook" // one hook run
+	EventError            = "error"
+)
+
+// Entry is one line of the watch log.
+type Entry struct {
+	Time     string   `json:"time"` // RFC 3339
+	Event    string   `json:"event"`
+	CaseID   string   `json:"case_id,omitempty"`
+	Path     string   `json:"path,omitempty"`
+	Files    []string `json:"files,omitempty"` // strategy files that changed, relative to the project root
+	From     string   `json:"from,omitempty"`
+	To       string   `json:"to,omitempty"`
+	Recorded bool     `json:"recorded,omitempty"` // the change is in history.jsonl, so its hooks already ran
+	Worker   string   `json:"worker,omitempty"`
+	Hook     string   `json:"hook,omitempty"` // hook event
+	Command  string   `json:"command,omitempty"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// Watcher keeps the state of the last poll of one project.
+type Watcher struct {
+	fs   dal.FileSystem
+	exec dal.Executor
+	log  *json.Encoder
+
+	strat     *strategy.Strategy
+	sm        *caseresource.StateMachine
+	hooks     *hooks.Engine
+	workers   []workerresource.Worker
+	casesRoot string
+
+	files map[string]stamp      // strategy and worker files, by path
+	cases map[string]*caseState // by case ID, so moves between groups are not changes
+}
+
+// stamp identifies a version of a file.
+type stamp struct {
+	mod  time.Time
+	size int64
+}
+
+// caseState is what the last poll saw of one case.
+type caseState struct {
+	dir      string
+	status   string
+	history  int              // history.jsonl entries
+	pending  bool             // status changed without a history entry; recheck once
+	sidecars map[string]stamp // by worker name
+}
+
+// New loads the strategy found from startDir and records the current state
+// of its files and cases, without logging or firing anything. Entries are
+// written to out.
+func New(fs dal.FileSystem, exec dal.Executor, startDir string, out io.Writer) (*Watcher, error) {
+	strat, err := strategy.Discover(startDir)
+	if err != nil {
+		return nil, err
+	}
+	log := json.NewEncoder(out)
+	log.SetEscapeHTML(false) // hook commands are shell, not HTML
+	w := &Watcher{fs: fs, exec: exec, log: log}
+	if err := w.load(strat); err != nil {
+		return nil, err
+	}
+	w.files = w.snapshot()
+	w.scanCases(false)
+	return w, nil
+}
+
+// Strategy returns the strategy in effect.
+func (w *Watcher) Strategy() *strategy.Strategy {
+	return w.strat
+}
+
+// Run polls every interval until ctx is done.
+func (w *Watcher) Run(ctx context.Context, interval time.Duration) error {
+	w.emit(Entry{Event: EventStarted, Path: w.strat.Root})
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			w.emit(Entry{Event: EventStopped})
+			return nil
+		case <-ticker.C:
+			w.Poll()
+		}
+	}
+}
+
+// Poll reloads the strategy when any of its files changed, then compares
+// every case with the previous poll.
+func (w *Watcher) Poll() {
+	if changed := w.changedFiles(); len(changed) > 0 {
+		w.reload(changed)
+	}
+	w.scanCases(true)
+}
+
+// load makes strat the strategy in effect.
+func (w *Watcher) load(strat *strategy.Strategy) error {
+	workers, err := workerresource.Discover(w.fs, strat.Root)
+	if err != nil {
+		return err
+	}
+	w.strat = strat
+	w.sm = caseresource.NewStateMachine(strat.Transitions)
+	w.hooks = hooks.NewEngine(w.exec, strat.Hooks, strat.Root)
+	w.workers = workers
+	w.casesRoot = caseresource.NewStorage(w.fs, w.exec, strat).Root()
+	return nil
+}
+
+// reload rediscovers the strategy after files changed. A strategy that no
+// longer loads is reported and the previous one kept.
+func (w *Watcher) reload(changed []string) {
+	strat, err := strategy.Discover(w.strat.Root)
+	if err == nil {
+		casesRoot := w.casesRoot
+		if err = w.load(strat); err == nil {
+			w.emit(Entry{Event: EventStrategyReloaded, Files: changed})
+			if w.casesRoot != casesRoot {
+				w.cases = nil
+				w.scanCases(false)
+			}
+			return
+		}
+	}
+	w.emit(Entry{Event: EventStrategyInvalid, Files: changed, Error: err.Error()})
+}
+
+// changedFiles snapshots the watched files and returns those added, removed
+// or modified since the previous snapshot.
+func (w *Watcher) changedFiles() []string {
+	next := w.snapshot()
+	var changed []string
+	for path, s := range next {
+		if prev, ok := w.files[path]; !ok || prev != s {
+			changed = append(changed, w.rel(path))
+		}
+	}
+	for path := range w.files {
+		if _, ok := next[path]; !ok {
+			changed = append(changed, w.rel(path))
+		}
+	}
+	w.files = next
+	sort.Strings(changed)
+	return changed
+}
+
+// snapshot stamps the files of every strategy layer on disk and of the
+// worker skill directories. The upgrade baseline, .orig backups and the case
+// index are not strategy.
+func (w *Watcher) snapshot() map[string]stamp {
+	dirs := []string{filepath.Join(w.strat.Root, ".agentops")}
+	if layers, err := strategy.Layers(w.strat.Root); err == nil {
+		for _, l := range layers {
+			if l.Dir != "" && l.Dir != dirs[0] {
+				dirs = append(dirs, l.Dir)
+			}
+		}
+	}
+	for _, rel := range workerresource.SearchDirs() {
+		dirs = append(dirs, filepath.Join(w.strat.Root, rel))
+	}
+
+	files := map[string]stamp{}
+	for _, dir := range dirs {
+		_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			name := d.Name()
+			if d.IsDir() {
+				if name == ".baseline" || (path != dir && filepath.Clean(path) == w.casesRoot) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if strings.HasPrefix(name, "cases.db") || strings.HasSuffix(name, ".orig") {
+				return nil
+			}
+			if info, err := d.Info(); err == nil {
+				files[path] = stamp{info.ModTime(), info.Size()}
+			}
+			return nil
+		})
+	}
+	return files
+}
+
+// scanCases compares the cases on disk with the previous poll. With fire
+// unset it only records them.
+func (w *Watcher) scanCases(fire bool) {
+	if w.cases == nil {
+		w.cases = map[string]*caseState{}
+	}
+	locs, err := caseresource.ScanCases(w.fs, w.casesRoot)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) { // a missing root has no cases
+		w.emit(Entry{Event: EventError, Path: w.casesRoot, Error: err.Error()})
+		return
+	}
+	dirs := make(map[string]string, len(locs))
+	for _, loc := range locs {
+		dirs[loc.ID] = loc.Dir
+	}
+	for _, id := range sortedKeys(dirs) {
+		dir := dirs[id]
+		status, ok := readStatus(filepath.Join(dir, "case.md"))
+		if !ok {
+			continue // being written; look again next poll
+		}
+		c, known := w.cases[id]
+		if !known {
+			c = &caseState{dir: dir, status: status, history: w.historyLen(dir), sidecars: w.sidecars(dir)}
+			w.cases[id] = c
+			if fire {
+				w.emit(Entry{Event: EventCaseAdded, CaseID: id, Path: dir, To: status})
+			}
+			continue
+		}
+		c.dir = dir
+		w.checkStatus(id, c, status)
+		w.checkSidecars(id, c)
+	}
+	for _, id := range sortedKeys(w.cases) {
+		if _, ok := dirs[id]; !ok {
+			c := w.cases[id]
+			delete(w.cases, id)
+			if fire {
+				w.emit(Entry{Event: EventCaseRemoved, CaseID: id, Path: c.dir})
+			}
+		}
+	}
+}
+
+// checkStatus reacts to a change of the case's status. A change recorded in
+// history.jsonl was made by agentops, which fired its hooks already. Any
+// other change fires on-case-transition, and on-case-close when it completes
+// the case, once a second poll has given a transition in progress time to
+// write its history.
+func (w *Watcher) checkStatus(id string, c *caseState, status string) {
+	if status == c.status {
+		c.pending = false
+		return
+	}
+	entries, _ := caseresource.ReadHistory(w.fs, c.dir)
+	recorded := len(entries) > c.history && entries[len(entries)-1].To == status
+	if !recorded && !c.pending {
+		c.pending = true
+		return
+	}
+
+	from := c.status
+	c.status, c.history, c.pending = status, len(entries), false
+	w.emit(Entry{Event: EventStatusChanged, CaseID: id, Path: c.dir, From: from, To: status, Recorded: recorded})
+	if recorded {
+		return
+	}
+	ev := hooks.Event{Name: hooks.EventCaseTransition, CaseID: id, OldStatus: from, NewStatus: status, CasePath: c.dir}
+	w.fire(ev)
+	if w.sm.CategoryForStatus(status) == "completed" && w.sm.CategoryForStatus(from) != "completed" {
+		ev.Name = hooks.EventCaseClose
+		w.fire(ev)
+	}
+}
+
+// checkSidecars fires on-worker-complete for every sidecar written since the
+// previous poll.
+func (w *Watcher) checkSidecars(id string, c *caseState) {
+	next := w.sidecars(c.dir)
+	for _, wk := range w.workers {
+		s, ok := next[wk.Name]
+		if !ok || c.sidecars[wk.Name] == s {
+			continue
+		}
+		w.emit(Entry{Event: EventSidecarWritten, CaseID: id, Path: filepath.Join(c.dir, wk.SidecarPath), Worker: wk.Name})
+		w.fire(hooks.Event{
+			Name:      hooks.EventWorkerComplete,
+			CaseID:    id,
+			OldStatus: c.status,
+			NewStatus: c.status,
+			CasePath:  c.dir,
+			Worker:    wk.Name,
+		})
+	}
+	c.sidecars = next
+}
+
+// sidecars stamps the sidecars of the registered workers present in dir.
+func (w *Watcher) sidecars(dir string) map[string]stamp {
+	out := map[string]stamp{}
+	for _, wk := range w.workers {
+		if wk.SidecarPath == "" {
+			continue
+		}
+		if info, err := os.Stat(filepath.Join(dir, wk.SidecarPath)); err == nil && !info.IsDir() {
+			out[wk.Name] = stamp{info.ModTime(), info.Size()}
+		}
+	}
+	return out
+}
+
+func (w *Watcher) historyLen(dir string) int {
+	entries, _ := caseresource.ReadHistory(w.fs, dir)
+	return len(entries)
+}
+
+// fire runs the hooks bound to ev and logs each run. Blocking hooks cannot
+// veto a change already on disk; their failure stops the remaining hooks.
+func (w *Watcher) fire(ev hooks.Event) {
+	results, _ := w.hooks.Fire(ev)
+	for _, r := range results {
+		e := Entry{Event: EventHook, CaseID: ev.CaseID, Hook: r.Event, Command: r.Command, Worker: ev.Worker}
+		if r.Err != nil {
+			e.Error = strings.Join(strings.Fields(r.Err.Error()), " ")
+		}
+		w.emit(e)
+	}
+}
+
+func (w *Watcher) emit(e Entry) {
+	e.Time = time.Now().UTC().Format(time.RFC3339)
+	_ = w.log.Encode(e) // a closed log leaves nothing to report to
+}
+
+func (w *Watcher) rel(path string) string {
+	if rel, err := filepath.Rel(w.strat.Root, path); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return path
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// readStatus returns the status in the frontmatter of the case.md at path.
+func readStatus(path string) (string, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false
+	}
+	fm, _, err := caseresource.ParseFrontmatter(string(data))
+	if err != nil {
+		return "", false
+	}
+	return fm.Status, true
+}
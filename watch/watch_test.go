@@ -0,0 +1,218 @@
+package watch
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gh-xj/agentops/dal"
+	"github.com/gh-xj/agentops/strategy"
+)
+
+const caseID = "CASE-20260101-x"
+
+// setupProject bootstraps a project with in-repo storage, a worker writing
+// out.json and hooks that append their event to hooks.out.
+func setupProject(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	if err := strategy.Bootstrap(root); err != nil {
+		t.Fatal(err)
+	}
+	hook := `echo "$AGENTOPS_HOOK_EVENT $AGENTOPS_CASE_OLD_STATUS $AGENTOPS_CASE_NEW_STATUS $AGENTOPS_WORKER" >> hooks.out`
+	writeFile(t, root, ".agentops/storage.yaml", "backend: in-repo\n")
+	writeFile(t, root, ".agentops/hooks.yaml", "on_case_transition: ['"+hook+"']\non_case_close: ['"+hook+"']\non_worker_complete: ['"+hook+"']\n")
+	writeFile(t, root, ".agentops/workers/review/SKILL.md", "---\nworker-type: analysis\nsidecar-path: out.json\n---\n# review\n")
+	writeCase(t, root, "open")
+	return root
+}
+
+func writeFile(t *testing.T, root, rel, content string) {
+	t.Helper()
+	path := filepath.Join(root, rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeCase(t *testing.T, root, status string) {
+	t.Helper()
+	writeFile(t, root, "cases/"+caseID+"/case.md", "---\ntype: intake\nstatus: "+status+"\nclaimed_by: none\ncreated: \"2026-01-01\"\n---\n# X\n")
+}
+
+func newWatcher(t *testing.T, root string) (*Watcher, *bytes.Buffer) {
+	t.Helper()
+	var out bytes.Buffer
+	w, err := New(dal.NewFileSystem(), dal.NewExecutor(), root, &out)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return w, &out
+}
+
+// events decodes and clears the log.
+func events(t *testing.T, out *bytes.Buffer) []Entry {
+	t.Helper()
+	var entries []Entry
+	dec := json.NewDecoder(out)
+	for dec.More() {
+		var e Entry
+		if err := dec.Decode(&e); err != nil {
+			t.Fatalf("log is not NDJSON: %v", err)
+		}
+		if e.Time == "" {
+			t.Errorf("entry without time: %+v", e)
+		}
+		entries = append(entries, e)
+	}
+	out.Reset()
+	return entries
+}
+
+func kinds(entries []Entry) string {
+	var names []string
+	for _, e := range entries {
+		name := e.Event
+		if e.Hook != "" {
+			name += ":" + e.Hook
+		}
+		names = append(names, name)
+	}
+	return strings.Join(names, ",")
+}
+
+func hookLog(t *testing.T, root string) string {
+	t.Helper()
+	data, _ := os.ReadFile(filepath.Join(root, "hooks.out"))
+	os.Remove(filepath.Join(root, "hooks.out"))
+	return string(data)
+}
+
+func TestPollReloadsStrategy(t *testing.T) {
+	root := setupProject(t)
+	w, out := newWatcher(t, root)
+	if w.Strategy().Budget.StaleAfter["in_progress"] != "7d" {
+		t.Fatalf("unexpected initial strategy: %+v", w.Strategy().Budget)
+	}
+
+	w.Poll()
+	if got := events(t, out); len(got) != 0 {
+		t.Fatalf("nothing changed, got %+v", got)
+	}
+
+	writeFile(t, root, ".agentops/budget.yaml", "stale_after: {in_progress: 2d}\n")
+	writeFile(t, root, ".agentops/.baseline/budget.yaml", "ignored\n")
+	w.Poll()
+	got := events(t, out)
+	if kinds(got) != EventStrategyReloaded || strings.Join(got[0].Files, ",") != ".agentops/budget.yaml" {
+		t.Fatalf("events = %+v", got)
+	}
+	if w.Strategy().Budget.StaleAfter["in_progress"] != "2d" {
+		t.Errorf("strategy not reloaded: %+v", w.Strategy().Budget)
+	}
+
+	writeFile(t, root, ".agentops/budget.yaml", "stale_after: [\n")
+	w.Poll()
+	got = events(t, out)
+	if kinds(got) != EventStrategyInvalid || got[0].Error == "" {
+		t.Fatalf("events = %+v", got)
+	}
+	if w.Strategy().Budget.StaleAfter["in_progress"] != "2d" {
+		t.Error("an invalid strategy should leave the previous one in effect")
+	}
+}
+
+func TestPollFiresHooksForStatusEdits(t *testing.T) {
+	root := setupProject(t)
+	w, out := newWatcher(t, root)
+
+	// A hand edit fires once a second poll finds no history for it.
+	writeCase(t, root, "resolved")
+	w.Poll()
+	if got := events(t, out); len(got) != 0 {
+		t.Fatalf("first poll should wait for history, got %+v", got)
+	}
+	w.Poll()
+	got := events(t, out)
+	if kinds(got) != "status_changed,hook:on-case-transition,hook:on-case-close" {
+		t.Fatalf("events = %s", kinds(got))
+	}
+	if got[0].From != "open" || got[0].To != "resolved" || got[0].Recorded {
+		t.Errorf("status_changed = %+v", got[0])
+	}
+	if log := hookLog(t, root); log != "on-case-transition open resolved \non-case-close open resolved \n" {
+		t.Errorf("hooks ran with %q", log)
+	}
+
+	// A transition agentops recorded already fired its hooks.
+	writeCase(t, root, "open")
+	writeFile(t, root, "cases/"+caseID+"/history.jsonl", `{"timestamp":"2026-01-02T00:00:00Z","action":"reopen","from":"resolved","to":"open"}`+"\n")
+	w.Poll()
+	got = events(t, out)
+	if kinds(got) != EventStatusChanged || !got[0].Recorded {
+		t.Fatalf("events = %+v", got)
+	}
+	if log := hookLog(t, root); log != "" {
+		t.Errorf("recorded transition ran hooks: %q", log)
+	}
+}
+
+func TestPollFiresWorkerComplete(t *testing.T) {
+	root := setupProject(t)
+	w, out := newWatcher(t, root)
+
+	writeFile(t, root, "cases/"+caseID+"/out.json", "{}\n")
+	w.Poll()
+	got := events(t, out)
+	if kinds(got) != "sidecar_written,hook:on-worker-complete" || got[0].Worker != "review" {
+		t.Fatalf("events = %+v", got)
+	}
+	if log := hookLog(t, root); log != "on-worker-complete open open review\n" {
+		t.Errorf("hooks ran with %q", log)
+	}
+
+	w.Poll()
+	if got := events(t, out); len(got) != 0 {
+		t.Errorf("unchanged sidecar fired again: %+v", got)
+	}
+	writeFile(t, root, "cases/"+caseID+"/out.json", `{"findings": []}`+"\n")
+	w.Poll()
+	if got := events(t, out); kinds(got) != "sidecar_written,hook:on-worker-complete" {
+		t.Errorf("rewritten sidecar: events = %s", kinds(got))
+	}
+}
+
+func TestPollTracksCases(t *testing.T) {
+	root := setupProject(t)
+	w, out := newWatcher(t, root)
+
+	other := "CASE-20260101-y"
+	writeFile(t, root, "cases/"+other+"/case.md", "---\ntype: intake\nstatus: open\nclaimed_by: none\ncreated: \"2026-01-01\"\n---\n# Y\n")
+	w.Poll()
+	got := events(t, out)
+	if kinds(got) != EventCaseAdded || got[0].CaseID != other || got[0].To != "open" {
+		t.Fatalf("events = %+v", got)
+	}
+
+	// Moving a case between groups is not a change.
+	if err := os.MkdirAll(filepath.Join(root, "cases", "active", "default"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(filepath.Join(root, "cases", caseID), filepath.Join(root, "cases", "active", "default", caseID)); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.RemoveAll(filepath.Join(root, "cases", other)); err != nil {
+		t.Fatal(err)
+	}
+	w.Poll()
+	got = events(t, out)
+	if kinds(got) != EventCaseRemoved || got[0].CaseID != other {
+		t.Fatalf("events = %+v", got)
+	}
+}